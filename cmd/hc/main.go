@@ -0,0 +1,233 @@
+// Command hc queries the health endpoint of an app using the healthcheck package and prints its health as a table of
+// checks, e.g. to see at a glance which dependency is failing, or to wait in a deploy script until an app is healthy:
+//
+//	hc [flags] url
+//
+// If the url has no path, /health is queried. The exit status is 0 if the app is OK, 1 if it is WARNING, 2 if it is
+// CRITICAL and 3 if its health could not be read. With -wait-for, the health is polled until the app is at least as
+// healthy as the given status, with an exit status of 0, or until the timeout, with the exit status of the last
+// health read.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// The exit status for each status of the app
+const (
+	exitOK = iota
+	exitWarning
+	exitCritical
+	exitUnknown
+)
+
+// exitCodes are the exit status for each status of the app
+var exitCodes = map[string]int{
+	health.StatusOK:       exitOK,
+	health.StatusWarning:  exitWarning,
+	health.StatusCritical: exitCritical,
+}
+
+// options are the options of the command
+type options struct {
+	url      string
+	waitFor  string
+	timeout  time.Duration
+	interval time.Duration
+	group    string
+	history  bool
+	colour   bool
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs the command with the provided arguments, returning its exit status
+func run(args []string, stdout, stderr io.Writer) int {
+	opts, err := parseOptions(args, stderr)
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+	if err != nil {
+		return exitUnknown
+	}
+
+	if opts.waitFor == "" {
+		hc, err := fetch(context.Background(), opts)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUnknown
+		}
+		render(stdout, hc, opts.colour, time.Now().UTC())
+		return exitCode(hc.Status)
+	}
+	return wait(opts, stdout, stderr)
+}
+
+// parseOptions parses the options of the command from the provided arguments, writing usage and errors to the
+// provided writer
+func parseOptions(args []string, stderr io.Writer) (options, error) {
+	var opts options
+	noColour := false
+
+	flags := flag.NewFlagSet("hc", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.StringVar(&opts.waitFor, "wait-for", "", "poll until the app is at least as healthy as this status, e.g. OK")
+	flags.DurationVar(&opts.timeout, "timeout", 60*time.Second, "how long to wait for the status given by -wait-for")
+	flags.DurationVar(&opts.interval, "interval", 2*time.Second, "how often to poll while waiting")
+	flags.StringVar(&opts.group, "group", "", "only show the checks of this group")
+	flags.BoolVar(&opts.history, "history", false, "show the recent results of each check, if the app keeps them")
+	flags.BoolVar(&noColour, "no-color", os.Getenv("NO_COLOR") != "", "do not colour the output")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: hc [flags] url")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return opts, fmt.Errorf("expected one url, got %d", flags.NArg())
+	}
+
+	if opts.waitFor != "" {
+		status := normaliseStatus(opts.waitFor)
+		if _, ok := exitCodes[status]; !ok {
+			err := fmt.Errorf("invalid status %q for -wait-for, expected OK, WARNING or CRITICAL", opts.waitFor)
+			fmt.Fprintln(stderr, err)
+			return opts, err
+		}
+		opts.waitFor = status
+	}
+
+	u, err := healthURL(flags.Arg(0), opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return opts, err
+	}
+	opts.url = u
+	opts.colour = !noColour
+	return opts, nil
+}
+
+// healthURL returns the URL of the health endpoint to query for the provided url, which defaults to the /health path
+func healthURL(rawURL string, opts options) (string, error) {
+	if !strings.Contains(rawURL, "://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url %q: %s", rawURL, err)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = health.DefaultPaths.Health
+	}
+
+	query := u.Query()
+	if opts.group != "" {
+		query.Set("group", opts.group)
+	}
+	if opts.history {
+		query.Set("history", "true")
+	}
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// fetch reads the health of the app from its health endpoint. The health is read whatever the status code of the
+// response, as the health handler responds with an error status code while the app is not OK.
+func fetch(ctx context.Context, opts options) (health.HealthCheck, error) {
+	var hc health.HealthCheck
+
+	req, err := http.NewRequest(http.MethodGet, opts.url, nil)
+	if err != nil {
+		return hc, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return hc, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(&hc); err != nil {
+		return hc, fmt.Errorf("failed to read health from %s, which responded with %s: %s", opts.url, resp.Status, err)
+	}
+	hc.Status = normaliseStatus(hc.Status)
+	return hc, nil
+}
+
+// wait polls the health of the app until it is at least as healthy as the status to wait for, or until the timeout,
+// rendering the health last read and returning the exit status
+func wait(opts options, stdout, stderr io.Writer) int {
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	var (
+		hc      health.HealthCheck
+		lastErr error
+		read    bool
+	)
+	for {
+		current, err := fetch(ctx, opts)
+		if err == nil {
+			hc, read, lastErr = current, true, nil
+			if exitCode(hc.Status) <= exitCodes[opts.waitFor] {
+				render(stdout, hc, opts.colour, time.Now().UTC())
+				return exitOK
+			}
+		} else if ctx.Err() == nil {
+			lastErr = err
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Fprintf(stderr, "timed out after %s waiting for %s to be %s\n", opts.timeout, opts.url, opts.waitFor)
+			if lastErr != nil {
+				fmt.Fprintln(stderr, lastErr)
+			}
+			if !read {
+				return exitUnknown
+			}
+			render(stdout, hc, opts.colour, time.Now().UTC())
+			return exitCode(hc.Status)
+		case <-time.After(opts.interval):
+		}
+	}
+}
+
+// exitCode returns the exit status for the provided status of the app
+func exitCode(status string) int {
+	if code, ok := exitCodes[normaliseStatus(status)]; ok {
+		return code
+	}
+	return exitUnknown
+}
+
+// normaliseStatus returns the status used by the healthcheck package for the provided status, which may be in upper
+// or lower case, or one of the IETF status names
+func normaliseStatus(status string) string {
+	switch strings.ToLower(status) {
+	case strings.ToLower(health.StatusOK), health.IETFStatusNames.OK:
+		return health.StatusOK
+	case strings.ToLower(health.StatusWarning), health.IETFStatusNames.Warning:
+		return health.StatusWarning
+	case strings.ToLower(health.StatusCritical), health.IETFStatusNames.Critical:
+		return health.StatusCritical
+	}
+	return status
+}
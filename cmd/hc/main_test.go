@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var version = health.VersionInfo{
+	BuildTime:       time.Unix(0, 0),
+	GitCommit:       "d6cd1e2bd19e03a81132a23b2025920577f84e37",
+	Language:        "go",
+	LanguageVersion: "1.12",
+	Version:         "1.0.0",
+}
+
+// newHealthServer returns a server for the health handler of a health check with an OK check and a check that records
+// the provided status, once both have run
+func newHealthServer(status string, opts ...health.Option) *httptest.Server {
+	hc, err := health.New(version, time.Hour, time.Hour, opts...)
+	So(err, ShouldBeNil)
+	So(hc.AddCheck("mongoDB", func(ctx context.Context, state *health.CheckState) error {
+		return state.Update(health.StatusOK, "mongoDB is OK", 0)
+	}, health.WithGroup("storage")), ShouldBeNil)
+	So(hc.AddCheck("zebedee API", func(ctx context.Context, state *health.CheckState) error {
+		return state.Update(status, "zebedee API is "+status, 0)
+	}), ShouldBeNil)
+	hc.Tick(context.Background())
+
+	return httptest.NewServer(http.HandlerFunc(hc.Handler))
+}
+
+func TestRun(t *testing.T) {
+	Convey("Given an app with a check that is WARNING", t, func() {
+		server := newHealthServer(health.StatusWarning, health.WithHistory(5))
+		defer server.Close()
+
+		Convey("When its health is queried", func() {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"-no-color", "-history", server.URL + "/health"}, &stdout, &stderr)
+
+			Convey("Then the exit status is that of WARNING", func() {
+				So(code, ShouldEqual, exitWarning)
+				So(stderr.String(), ShouldBeEmpty)
+			})
+
+			Convey("Then the status of the app, its groups and each check are printed", func() {
+				So(stdout.String(), ShouldStartWith, "WARNING  version 1.0.0 d6cd1e2")
+				So(stdout.String(), ShouldContainSubstring, "groups: storage OK")
+				So(stdout.String(), ShouldContainSubstring, "GROUP    CHECK        STATUS   LAST CHECKED")
+				So(stdout.String(), ShouldContainSubstring, "storage  mongoDB      OK")
+				So(stdout.String(), ShouldContainSubstring, "         zebedee API  WARNING")
+				So(stdout.String(), ShouldContainSubstring, "zebedee API is WARNING")
+			})
+
+			Convey("Then the history of each check is printed", func() {
+				So(stdout.String(), ShouldContainSubstring, "history (oldest first): WARNING")
+			})
+		})
+	})
+
+	Convey("Given an app that is OK and reports IETF status names", t, func() {
+		server := newHealthServer(health.StatusOK, health.WithStatusNames(health.IETFStatusNames))
+		defer server.Close()
+
+		Convey("When its health is queried without a path", func() {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"-no-color", server.URL}, &stdout, &stderr)
+
+			Convey("Then the health endpoint is queried and the statuses are understood", func() {
+				So(code, ShouldEqual, exitOK)
+				So(stdout.String(), ShouldStartWith, "OK")
+			})
+		})
+
+		Convey("When its health is queried in colour", func() {
+			var stdout, stderr bytes.Buffer
+			run([]string{server.URL}, &stdout, &stderr)
+
+			Convey("Then statuses are coloured", func() {
+				So(stdout.String(), ShouldStartWith, ansiGreen+"OK"+ansiReset)
+			})
+		})
+	})
+
+	Convey("Given a url that does not respond with health", t, func() {
+		server := httptest.NewServer(http.NotFoundHandler())
+		defer server.Close()
+
+		Convey("Then the exit status is unknown", func() {
+			var stdout, stderr bytes.Buffer
+			So(run([]string{server.URL}, &stdout, &stderr), ShouldEqual, exitUnknown)
+			So(stderr.String(), ShouldContainSubstring, "404 Not Found")
+		})
+	})
+
+	Convey("Given an invalid status to wait for", t, func() {
+		Convey("Then the exit status is unknown", func() {
+			var stdout, stderr bytes.Buffer
+			So(run([]string{"-wait-for=GREEN", "localhost:8080"}, &stdout, &stderr), ShouldEqual, exitUnknown)
+			So(stderr.String(), ShouldContainSubstring, `invalid status "GREEN"`)
+		})
+	})
+}
+
+func TestWait(t *testing.T) {
+	Convey("Given an app that is CRITICAL for its first two responses and then OK", t, func() {
+		critical := newHealthServer(health.StatusCritical)
+		defer critical.Close()
+		ok := newHealthServer(health.StatusOK)
+		defer ok.Close()
+
+		var requests int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			target := ok.URL
+			if atomic.AddInt32(&requests, 1) <= 2 {
+				target = critical.URL
+			}
+			resp, err := http.Get(target + req.URL.RequestURI())
+			if err != nil {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			defer resp.Body.Close()
+			w.WriteHeader(resp.StatusCode)
+			var b bytes.Buffer
+			b.ReadFrom(resp.Body)
+			w.Write(b.Bytes())
+		}))
+		defer server.Close()
+
+		Convey("When waiting for it to be OK", func() {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"--wait-for=OK", "--timeout=5s", "--interval=10ms", "-no-color", server.URL}, &stdout, &stderr)
+
+			Convey("Then its health is polled until it is OK", func() {
+				So(code, ShouldEqual, exitOK)
+				So(atomic.LoadInt32(&requests), ShouldEqual, 3)
+				So(stdout.String(), ShouldStartWith, "OK")
+			})
+		})
+
+		Convey("When waiting for it to be OK for less time than it takes", func() {
+			var stdout, stderr bytes.Buffer
+			code := run([]string{"--wait-for=OK", "--timeout=50ms", "--interval=1s", "-no-color", server.URL}, &stdout, &stderr)
+
+			Convey("Then the exit status is that of its last status", func() {
+				So(code, ShouldEqual, exitWarning)
+				So(stderr.String(), ShouldContainSubstring, "timed out after 50ms")
+			})
+		})
+	})
+}
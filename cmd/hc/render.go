@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// The ANSI escape codes used to colour the output
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiGrey   = "\x1b[90m"
+	ansiBold   = "\x1b[1m"
+	ansiReset  = "\x1b[0m"
+)
+
+// statusColours are the colours of each status
+var statusColours = map[string]string{
+	health.StatusOK:       ansiGreen,
+	health.StatusWarning:  ansiYellow,
+	health.StatusCritical: ansiRed,
+}
+
+// cell is a cell of the table of checks, with the colour it is written in, if any
+type cell struct {
+	text   string
+	colour string
+}
+
+// render writes the health of the app, as at the provided time, as a summary followed by a table of its checks and
+// the status of each group, if any
+func render(w io.Writer, hc health.HealthCheck, colour bool, now time.Time) {
+	paint := func(c cell) string {
+		if !colour || c.colour == "" {
+			return c.text
+		}
+		return c.colour + c.text + ansiReset
+	}
+
+	summary := []string{paint(cell{text: hc.Status, colour: statusColour(hc.Status)})}
+	if hc.Version.Version != "" || hc.Version.GitCommit != "" {
+		summary = append(summary, strings.TrimSpace(fmt.Sprintf("version %s %s", hc.Version.Version, shortCommit(hc.Version.GitCommit))))
+	}
+	if hc.Uptime > 0 && !hc.StartTime.IsZero() {
+		summary = append(summary, "up "+(hc.Uptime*time.Millisecond).Round(time.Second).String())
+	}
+	if hc.Override != nil {
+		summary = append(summary, fmt.Sprintf("overridden to %s", paint(cell{text: normaliseStatus(hc.Override.Status), colour: statusColour(hc.Override.Status)})))
+	}
+	fmt.Fprintln(w, strings.Join(summary, "  "))
+
+	if len(hc.Groups) > 0 {
+		groups := make([]string, 0, len(hc.Groups))
+		for group := range hc.Groups {
+			groups = append(groups, group)
+		}
+		sort.Strings(groups)
+		for i, group := range groups {
+			groups[i] = group + " " + paint(cell{text: normaliseStatus(hc.Groups[group]), colour: statusColour(hc.Groups[group])})
+		}
+		fmt.Fprintln(w, "groups: "+strings.Join(groups, ", "))
+	}
+
+	if len(hc.Checks) == 0 {
+		return
+	}
+	fmt.Fprintln(w)
+
+	hasGroups := false
+	for _, check := range hc.Checks {
+		if check.State().Group() != "" {
+			hasGroups = true
+		}
+	}
+
+	header := []cell{{text: "CHECK"}, {text: "STATUS"}, {text: "LAST CHECKED"}, {text: "LAST SUCCESS"}, {text: "MESSAGE"}}
+	if hasGroups {
+		header = append([]cell{{text: "GROUP"}}, header...)
+	}
+	for i := range header {
+		header[i].colour = ansiBold
+	}
+
+	rows := [][]cell{header}
+	histories := [][]health.CheckResult{nil}
+	for _, check := range hc.Checks {
+		state := check.State()
+		status := normaliseStatus(state.Status())
+		row := []cell{
+			{text: state.Name()},
+			{text: status, colour: statusColour(status)},
+			{text: age(state.LastChecked(), now)},
+			{text: age(state.LastSuccess(), now)},
+			{text: state.Message()},
+		}
+		if hasGroups {
+			row = append([]cell{{text: state.Group()}}, row...)
+		}
+		rows = append(rows, row)
+		histories = append(histories, state.History())
+	}
+
+	widths := make([]int, len(header))
+	for _, row := range rows {
+		for i, c := range row {
+			if n := utf8.RuneCountInString(c.text); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	for i, row := range rows {
+		cells := make([]string, len(row))
+		for j, c := range row {
+			text := paint(c)
+			if j < len(row)-1 {
+				text += strings.Repeat(" ", widths[j]-utf8.RuneCountInString(c.text))
+			}
+			cells[j] = text
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, "  "), " "))
+
+		if len(histories[i]) > 0 {
+			results := make([]string, 0, len(histories[i]))
+			for _, result := range histories[i] {
+				status := normaliseStatus(result.Status)
+				results = append(results, paint(cell{text: status, colour: statusColour(status)}))
+			}
+			fmt.Fprintln(w, "  "+paint(cell{text: "history (oldest first):", colour: ansiGrey})+" "+strings.Join(results, " "))
+		}
+	}
+}
+
+// statusColour returns the colour of the provided status, if any
+func statusColour(status string) string {
+	return statusColours[normaliseStatus(status)]
+}
+
+// age returns how long before the provided time the provided time was, to the nearest second, or - if it is not set
+func age(t *time.Time, now time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+	return now.Sub(*t).Round(time.Second).String() + " ago"
+}
+
+// shortCommit returns the abbreviated form of the provided git commit
+func shortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
@@ -7,8 +7,15 @@ require (
 	github.com/ONSdigital/go-ns v0.0.0-20191104121206-f144c4ec2e58 // indirect
 	github.com/ONSdigital/log.go v0.0.0-20191127134126-2a610b254f20
 	github.com/fatih/color v1.7.0 // indirect
+	github.com/go-chi/chi/v5 v5.0.7
+	github.com/gorilla/mux v1.8.0
 	github.com/hokaccha/go-prettyjson v0.0.0-20190818114111-108c894c2c0e // indirect
 	github.com/mattn/go-colorable v0.1.4 // indirect
 	github.com/mattn/go-isatty v0.0.11 // indirect
+	github.com/prometheus/client_golang v1.4.1
 	github.com/smartystreets/goconvey v1.6.4
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+	google.golang.org/grpc v1.27.1
 )
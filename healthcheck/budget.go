@@ -0,0 +1,50 @@
+package healthcheck
+
+import (
+	"sync"
+	"time"
+)
+
+// probeBudget is a token bucket limiting the number of checker runs across all checks, refilling continuously up to
+// its capacity
+type probeBudget struct {
+	capacity float64
+	tokens   float64
+	rate     float64 // tokens added per second
+	per      time.Duration
+	last     time.Time
+	mutex    *sync.Mutex
+}
+
+// newProbeBudget creates a full probe budget allowing the provided number of probes per window
+func newProbeBudget(probes int, per time.Duration) *probeBudget {
+	return &probeBudget{
+		capacity: float64(probes),
+		tokens:   float64(probes),
+		rate:     float64(probes) / per.Seconds(),
+		per:      per,
+		mutex:    &sync.Mutex{},
+	}
+}
+
+// take returns true and uses a probe from the budget if one is available at the provided time
+func (b *probeBudget) take(now time.Time) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if !b.last.IsZero() && now.After(b.last) {
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+	}
+	if now.After(b.last) {
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,69 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProbeBudget(t *testing.T) {
+	Convey("Given a probe budget of 2 probes per second", t, func() {
+		t0 := time.Now().UTC()
+		budget := newProbeBudget(2, time.Second)
+
+		Convey("Then the full budget can be used at once", func() {
+			So(budget.take(t0), ShouldBeTrue)
+			So(budget.take(t0), ShouldBeTrue)
+
+			Convey("And further probes are refused until the budget refills", func() {
+				So(budget.take(t0), ShouldBeFalse)
+				So(budget.take(t0.Add(100*time.Millisecond)), ShouldBeFalse)
+				So(budget.take(t0.Add(500*time.Millisecond)), ShouldBeTrue)
+				So(budget.take(t0.Add(500*time.Millisecond)), ShouldBeFalse)
+			})
+		})
+
+		Convey("Then the budget does not refill beyond its capacity", func() {
+			So(budget.take(t0), ShouldBeTrue)
+			t1 := t0.Add(time.Minute)
+			So(budget.take(t1), ShouldBeTrue)
+			So(budget.take(t1), ShouldBeTrue)
+			So(budget.take(t1), ShouldBeFalse)
+		})
+	})
+}
+
+func TestHealthCheckWithProbeBudget(t *testing.T) {
+	Convey("Given a started Health Check with 2 checks and a budget of 1 probe per hour", t, func() {
+		var mutex sync.Mutex
+		runs := 0
+		cf := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			runs++
+			return state.Update(StatusOK, "I'm OK", 0)
+		}
+
+		hc, err := New(version, criticalTimeout, interval, WithProbeBudget(1, time.Hour))
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		So(hc.AddCheck("check 2", cf), ShouldBeNil)
+		hc.Start(context.Background())
+
+		Convey("When several intervals have passed", func() {
+			time.Sleep(4 * interval)
+			hc.Stop()
+
+			Convey("Then only one check has run and the other runs were deferred", func() {
+				mutex.Lock()
+				So(runs, ShouldEqual, 1)
+				mutex.Unlock()
+				So(hc.Checks[0].state.Deferrals()+hc.Checks[1].state.Deferrals(), ShouldBeGreaterThan, 0)
+			})
+		})
+	})
+}
@@ -0,0 +1,56 @@
+package healthcheck
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// develVersion is the version of the main module reported by the go toolchain when it was not built from a tagged
+// version, e.g. by go build from a local checkout
+const develVersion = "(devel)"
+
+// buildSettings are the version control settings recorded in the build information of the app
+type buildSettings struct {
+	revision string
+	time     *time.Time
+	dirty    bool
+}
+
+// NewVersionInfoFromBuildInfo returns a health check version info object populated from the build information that
+// the go toolchain embeds in the app, so that it does not rely on values being injected when the app is built:
+// the git commit, build time and dirty flag are taken from the version control settings, and the version from the
+// version of the main module, if it was built from a tagged version. Caller to provide the values to fall back to
+// for any that the build information does not hold, as for NewVersionInfo:
+// buildTime for when the app was built as a unix time stamp in string form
+// gitCommit the SHA-1 commit hash of the built app
+// version the semantic version of the built app
+// An error is only returned if the build time is neither in the build information nor a valid fall back.
+func NewVersionInfoFromBuildInfo(buildTime, gitCommit, version string) (VersionInfo, error) {
+	info, _ := debug.ReadBuildInfo()
+	return newVersionInfoFromBuildInfo(info, buildTime, gitCommit, version)
+}
+
+// newVersionInfoFromBuildInfo returns a version info object populated from the provided build information, if any,
+// falling back to the provided values
+func newVersionInfoFromBuildInfo(info *debug.BuildInfo, buildTime, gitCommit, version string) (VersionInfo, error) {
+	versionInfo, err := NewVersionInfo(buildTime, gitCommit, version)
+	if info == nil {
+		return versionInfo, err
+	}
+
+	versionInfo.Module = info.Main.Path
+	if info.Main.Version != "" && info.Main.Version != develVersion {
+		versionInfo.Version = info.Main.Version
+	}
+
+	settings := readBuildSettings(info)
+	if settings.revision != "" {
+		versionInfo.GitCommit = settings.revision
+	}
+	versionInfo.GitDirty = settings.dirty
+	if settings.time != nil {
+		versionInfo.BuildTime = *settings.time
+		err = nil
+	}
+	return versionInfo, err
+}
@@ -0,0 +1,105 @@
+//go:build go1.18
+// +build go1.18
+
+package healthcheck
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNewVersionInfoFromBuildInfo(t *testing.T) {
+	gitCommit := "d6cd1e2bd19e03a81132a23b2025920577f84e37"
+
+	Convey("Given build information with version control settings and a tagged module version", t, func() {
+		info := &debug.BuildInfo{
+			Main: debug.Module{Path: "github.com/ONSdigital/dp-app", Version: "v1.2.3"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "0123456789abcdef0123456789abcdef01234567"},
+				{Key: "vcs.time", Value: "2020-01-02T03:04:05Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}
+
+		Convey("When the version info is created with fall back values", func() {
+			versionInfo, err := newVersionInfoFromBuildInfo(info, "0", gitCommit, "1.0.0")
+
+			Convey("Then the values from the build information are used", func() {
+				So(err, ShouldBeNil)
+				So(versionInfo, ShouldResemble, VersionInfo{
+					BuildTime:       time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+					GitCommit:       "0123456789abcdef0123456789abcdef01234567",
+					Language:        language,
+					LanguageVersion: runtime.Version(),
+					Version:         "v1.2.3",
+					GitDirty:        true,
+					Module:          "github.com/ONSdigital/dp-app",
+				})
+			})
+		})
+
+		Convey("When the version info is created without a valid fall back build time", func() {
+			versionInfo, err := newVersionInfoFromBuildInfo(info, "", "", "")
+
+			Convey("Then no error is returned as the build time is in the build information", func() {
+				So(err, ShouldBeNil)
+				So(versionInfo.BuildTime, ShouldEqual, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+			})
+		})
+	})
+
+	Convey("Given build information of a development build without version control settings", t, func() {
+		info := &debug.BuildInfo{
+			Main: debug.Module{Path: "github.com/ONSdigital/dp-app", Version: "(devel)"},
+		}
+
+		Convey("When the version info is created with fall back values", func() {
+			versionInfo, err := newVersionInfoFromBuildInfo(info, "0", gitCommit, "1.0.0")
+
+			Convey("Then the fall back values are used", func() {
+				So(err, ShouldBeNil)
+				So(versionInfo, ShouldResemble, VersionInfo{
+					BuildTime:       time.Unix(0, 0),
+					GitCommit:       gitCommit,
+					Language:        language,
+					LanguageVersion: runtime.Version(),
+					Version:         "1.0.0",
+					Module:          "github.com/ONSdigital/dp-app",
+				})
+			})
+		})
+
+		Convey("When the version info is created with an invalid fall back build time", func() {
+			_, err := newVersionInfoFromBuildInfo(info, "some invalid date", gitCommit, "1.0.0")
+
+			Convey("Then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given no build information", t, func() {
+		Convey("Then the version info is created from the fall back values", func() {
+			versionInfo, err := newVersionInfoFromBuildInfo(nil, "0", gitCommit, "1.0.0")
+			expected, _ := NewVersionInfo("0", gitCommit, "1.0.0")
+
+			So(err, ShouldBeNil)
+			So(versionInfo, ShouldResemble, expected)
+		})
+	})
+
+	Convey("Given the build information of the running binary", t, func() {
+		Convey("Then the version info is created with the fall back values for any missing", func() {
+			versionInfo, err := NewVersionInfoFromBuildInfo("0", gitCommit, "1.0.0")
+
+			So(err, ShouldBeNil)
+			So(versionInfo.Language, ShouldEqual, language)
+			So(versionInfo.GitCommit, ShouldNotBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,27 @@
+//go:build go1.18
+// +build go1.18
+
+package healthcheck
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// readBuildSettings returns the version control settings recorded in the provided build information
+func readBuildSettings(info *debug.BuildInfo) buildSettings {
+	var settings buildSettings
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			settings.revision = setting.Value
+		case "vcs.time":
+			if t, err := time.Parse(time.RFC3339, setting.Value); err == nil {
+				settings.time = &t
+			}
+		case "vcs.modified":
+			settings.dirty = setting.Value == "true"
+		}
+	}
+	return settings
+}
@@ -0,0 +1,12 @@
+//go:build !go1.18
+// +build !go1.18
+
+package healthcheck
+
+import "runtime/debug"
+
+// readBuildSettings returns no version control settings, as they are only recorded in the build information from go
+// 1.18
+func readBuildSettings(info *debug.BuildInfo) buildSettings {
+	return buildSettings{}
+}
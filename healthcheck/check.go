@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,36 +17,133 @@ const (
 	StatusCritical = "CRITICAL"
 )
 
+// StatusSkipped is recorded for a check that was not run as a check it depends on was critical. It is not a valid
+// status for a checker to update the state of a check with.
+const StatusSkipped = "SKIPPED"
+
 // Checker represents the interface all checker functions abide to
 type Checker func(context.Context, *CheckState) error
 
 // CheckState represents the health status returned by a checker
 type CheckState struct {
-	name        string
+	name string
+	// group is the name of the group the check belongs to, if any
+	group string
+	// labels are the key/value labels attached to the check. Once the check is created the map is replaced rather than
+	// modified, so that it can be shared by copies of the state
+	labels      map[string]string
 	status      string
 	statusCode  int
 	message     string
 	lastChecked *time.Time
 	lastSuccess *time.Time
 	lastFailure *time.Time
-	mutex       *sync.RWMutex
+	lastError   string
+	timeouts    int
+	deferrals   int
+	// consecutiveFailures is the number of consecutive runs of the checker that have recorded a critical status
+	consecutiveFailures int
+	// totalFailures is the number of runs of the checker that have recorded a critical status since the check was added
+	totalFailures int
+	// stale is set once the check has been recorded as critical as it has not completed a run within the configured
+	// number of its intervals, until it next completes a run
+	stale bool
+	// restored is set while the recorded result was restored from a state store, until the check next completes a run
+	restored bool
+	// lastStatusChange is the time at which a run of the checker last changed the recorded status
+	lastStatusChange *time.Time
+	// duration is how long the most recent run of the checker took, including any retries
+	duration time.Duration
+	// nextCheck is the time the ticker of the check is next due to run it, allowing for any backoff
+	nextCheck *time.Time
+	// history holds the results of the most recent runs of the checker in a ring buffer, of which historyNext is the
+	// index of the oldest result once the buffer is full
+	history     []CheckResult
+	historyNext int
+	// includeHistory reports the history in the JSON representation
+	includeHistory bool
+	// clock provides the time at which the state is updated
+	clock Clock
+	// relativeTo is the time from which the age of each timestamp is reported in the JSON representation, if set
+	relativeTo *time.Time
+	mutex      *sync.RWMutex
 }
 
 // checkStateJSON represents the health status struct for use with json marshal/unmarshal (to deal with unexported fields)
 type checkStateJSON struct {
-	Name        string     `json:"name"`
-	Status      string     `json:"status"`
-	StatusCode  int        `json:"status_code,omitempty"`
-	Message     string     `json:"message"`
-	LastChecked *time.Time `json:"last_checked"`
-	LastSuccess *time.Time `json:"last_success"`
-	LastFailure *time.Time `json:"last_failure"`
+	Name        string            `json:"name"`
+	Group       string            `json:"group,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Status      string            `json:"status"`
+	StatusCode  int               `json:"status_code,omitempty"`
+	Message     string            `json:"message"`
+	LastChecked *time.Time        `json:"last_checked"`
+	LastSuccess *time.Time        `json:"last_success"`
+	LastFailure *time.Time        `json:"last_failure"`
+	LastError   string            `json:"last_error,omitempty"`
+	Timeouts    int               `json:"timeouts,omitempty"`
+	Deferrals   int               `json:"deferrals,omitempty"`
+	NextCheck   *time.Time        `json:"next_check,omitempty"`
+
+	Duration            *durationJSON `json:"duration,omitempty"`
+	ConsecutiveFailures int           `json:"consecutive_failures,omitempty"`
+	TotalFailures       int           `json:"total_failures,omitempty"`
+	Stale               bool          `json:"stale,omitempty"`
+
+	LastCheckedAgo string `json:"last_checked_ago,omitempty"`
+	LastSuccessAgo string `json:"last_success_ago,omitempty"`
+	LastFailureAgo string `json:"last_failure_ago,omitempty"`
+
+	History []CheckResult `json:"history,omitempty"`
+}
+
+// CheckResult represents the result of a single run of a checker, as kept in the history of a check
+type CheckResult struct {
+	Time     time.Time     `json:"time"`
+	Status   string        `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Message  string        `json:"message"`
 }
 
+// SeverityFunc maps the recorded state of a check to the status used for the check when aggregating the
+// overall health status, allowing checks to express bespoke criticality rules
+type SeverityFunc func(state *CheckState) string
+
+// RecordFilter inspects a fresh result of a check, returning whether it should be recorded. The previous check holds
+// the currently recorded state and the current check holds the fresh result. If the result is not recorded the
+// previous state is kept, allowing custom debouncing or smoothing of results.
+type RecordFilter func(previous, current Check) (record bool)
+
 // Check represents a check performed by the health check
 type Check struct {
-	state   *CheckState
-	checker Checker
+	state        *CheckState
+	checker      Checker
+	severity     SeverityFunc
+	recordFilter RecordFilter
+	panicPolicy  PanicPolicy
+	// interval overrides the interval of the health check for this check, if set
+	interval time.Duration
+	// timeout bounds how long a single run of the checker may take, overriding the default derived from the interval
+	timeout time.Duration
+	// criticalTimeout overrides the critical error timeout of the health check for this check, if set
+	criticalTimeout time.Duration
+	// retries is the number of times a failed run of the checker is retried before the failure is recorded
+	retries int
+	// retryBackoff is the time waited before each retry
+	retryBackoff time.Duration
+	// backoffAfter is the number of consecutive failures after which the interval of the check is backed off, if set
+	backoffAfter int
+	// backoffMax caps the interval of the check while it is backed off
+	backoffMax time.Duration
+	// informational checks are reported but do not contribute to the overall health status
+	informational bool
+	// nonCritical checks contribute at most a warning to the overall health status, however long they have been failing
+	nonCritical bool
+	// dependsOn are the names of the checks the check depends on, and is skipped while any of them is critical
+	dependsOn []string
+	// running is the number of runs of the checker that have not returned, including any abandoned at their timeout
+	running int32
+	debug   int32
 }
 
 // Name gets the check name
@@ -56,6 +154,42 @@ func (s *CheckState) Name() string {
 	return s.name
 }
 
+// Group gets the name of the group the check belongs to, or an empty string if it does not belong to a group
+func (s *CheckState) Group() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.group
+}
+
+// Labels gets a copy of the labels attached to the check
+func (s *CheckState) Labels() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.labels == nil {
+		return nil
+	}
+	labels := make(map[string]string, len(s.labels))
+	for key, value := range s.labels {
+		labels[key] = value
+	}
+	return labels
+}
+
+// hasLabels returns true if the check has all of the provided labels
+func (s *CheckState) hasLabels(labels map[string]string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for key, value := range labels {
+		if v, ok := s.labels[key]; !ok || v != value {
+			return false
+		}
+	}
+	return true
+}
+
 // Status gets the check status
 func (s *CheckState) Status() string {
 	s.mutex.RLock()
@@ -119,16 +253,99 @@ func (s *CheckState) LastFailure() *time.Time {
 	return &t
 }
 
+// LastError gets the error returned by the most recent failed run of the checker
+func (s *CheckState) LastError() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.lastError
+}
+
+// Timeouts gets the number of runs of the checker that have failed due to a timeout
+func (s *CheckState) Timeouts() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.timeouts
+}
+
+// Deferrals gets the number of runs of the checker that have been deferred as the probe budget was exhausted
+func (s *CheckState) Deferrals() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.deferrals
+}
+
+// ConsecutiveFailures gets the number of consecutive runs of the checker, up to and including the most recent run,
+// that have recorded a critical status
+func (s *CheckState) ConsecutiveFailures() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.consecutiveFailures
+}
+
+// TotalFailures gets the number of runs of the checker that have recorded a critical status since the check was added
+func (s *CheckState) TotalFailures() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.totalFailures
+}
+
+// Duration gets how long the most recent run of the checker took, including any retries
+func (s *CheckState) Duration() time.Duration {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.duration
+}
+
+// History gets the results of the most recent runs of the checker, oldest first. The number of results kept is
+// configured with WithHistory, and no results are kept by default.
+func (s *CheckState) History() []CheckResult {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.orderedHistory()
+}
+
+// orderedHistory returns a copy of the history, oldest first. Callers must hold the lock.
+func (s *CheckState) orderedHistory() []CheckResult {
+	if len(s.history) == 0 {
+		return nil
+	}
+
+	history := make([]CheckResult, 0, len(s.history))
+	history = append(history, s.history[s.historyNext:]...)
+	return append(history, s.history[:s.historyNext]...)
+}
+
+// NextCheck gets the time the check is next due to be run by its ticker, allowing for any backoff, or nil if its ticker
+// is not running
+func (s *CheckState) NextCheck() *time.Time {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.nextCheck == nil {
+		return nil
+	}
+
+	t := *s.nextCheck
+	return &t
+}
+
 // Update updates the relevant state fields based on the status provided
 // status of the check, must be one of healthcheck.StatusOK, healthcheck.StatusWarning or healthcheck.StatusCritical
 // message briefly describing the check state
 // statusCode returned if the check was an HTTP check (optional, provide 0 if not relevant)
 func (s *CheckState) Update(status, message string, statusCode int) error {
-	now := time.Now().UTC()
-
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	now := s.now()
+
 	switch status {
 	case StatusOK:
 		s.lastSuccess = &now
@@ -146,6 +363,268 @@ func (s *CheckState) Update(status, message string, statusCode int) error {
 	return nil
 }
 
+// UpdateFromError records the result of a checker whose dependency is checked by a call returning an error, e.g.
+// return state.UpdateFromError(db.PingContext(ctx)). The check is CRITICAL with the error as its message, and as its
+// last error, if err is not nil, and OK otherwise, with its timestamps updated as by Update.
+func (s *CheckState) UpdateFromError(err error) error {
+	if err == nil {
+		return s.Update(StatusOK, "", 0)
+	}
+
+	if updateErr := s.Update(StatusCritical, err.Error(), 0); updateErr != nil {
+		return updateErr
+	}
+	s.setError(err)
+	return nil
+}
+
+// clone returns a copy of the check state with its own mutex, for a checker to update in isolation
+func (s *CheckState) clone() *CheckState {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return &CheckState{
+		name:        s.name,
+		group:       s.group,
+		labels:      s.labels,
+		status:      s.status,
+		statusCode:  s.statusCode,
+		message:     s.message,
+		lastChecked: s.lastChecked,
+		lastSuccess: s.lastSuccess,
+		lastFailure: s.lastFailure,
+		lastError:   s.lastError,
+		timeouts:    s.timeouts,
+		deferrals:   s.deferrals,
+
+		consecutiveFailures: s.consecutiveFailures,
+		totalFailures:       s.totalFailures,
+		stale:               s.stale,
+		restored:            s.restored,
+		lastStatusChange:    s.lastStatusChange,
+		duration:            s.duration,
+		nextCheck:           s.nextCheck,
+		history:             append([]CheckResult(nil), s.history...),
+		historyNext:         s.historyNext,
+		includeHistory:      s.includeHistory,
+		clock:               s.clock,
+		mutex:               &sync.RWMutex{},
+	}
+}
+
+// isUpdate returns true if the state has been checked since the provided last checked time was recorded,
+// or if the provided checker error differs from the last recorded one
+func (s *CheckState) isUpdate(lastChecked *time.Time, lastError string) bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.lastChecked != lastChecked || s.lastError != lastError
+}
+
+// setError records the error returned by the checker
+func (s *CheckState) setError(err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastError = err.Error()
+}
+
+// recordRetriedFailure records the time at which an attempt of the checker that is to be retried failed, leaving the
+// recorded status unchanged
+func (s *CheckState) recordRetriedFailure(lastFailure *time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.lastFailure = lastFailure
+}
+
+// recordTimeout increments the number of runs of the checker that have failed due to a timeout
+func (s *CheckState) recordTimeout() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.timeouts++
+}
+
+// recordDeferral increments the number of runs of the checker deferred as the probe budget was exhausted
+func (s *CheckState) recordDeferral() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.deferrals++
+}
+
+// recordDuration records how long the run of the checker that produced the state took
+func (s *CheckState) recordDuration(duration time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.duration = duration
+}
+
+// setClock sets the clock that provides the time at which the state is updated
+func (s *CheckState) setClock(clock Clock) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.clock = clock
+}
+
+// now returns the current time of the clock of the state, in UTC. Callers must hold the lock.
+func (s *CheckState) now() time.Time {
+	if s.clock == nil {
+		return time.Now().UTC()
+	}
+	return s.clock.Now().UTC()
+}
+
+// setGroup sets the name of the group the check belongs to
+func (s *CheckState) setGroup(group string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.group = group
+}
+
+// setLabels replaces the labels attached to the check
+func (s *CheckState) setLabels(labels map[string]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.labels = labels
+}
+
+// setNextCheck records the time the check is next due to be run by its ticker
+func (s *CheckState) setNextCheck(nextCheck *time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextCheck = nextCheck
+}
+
+// recordHistory adds the provided result to the history, replacing the oldest result once the provided number of
+// results are kept
+func (s *CheckState) recordHistory(result CheckResult, size int) {
+	if size <= 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.history) < size {
+		s.history = append(s.history, result)
+		return
+	}
+	s.history[s.historyNext] = result
+	s.historyNext = (s.historyNext + 1) % len(s.history)
+}
+
+// set records the fields of the provided state as the current check state, returning the previously recorded status
+func (s *CheckState) set(state *CheckState) (previous string) {
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous = s.status
+
+	if s.status != "" && s.status != state.status {
+		s.lastStatusChange = state.lastChecked
+	}
+
+	isNewRun := state.lastChecked != nil && (s.lastChecked == nil || !state.lastChecked.Equal(*s.lastChecked))
+	if isNewRun {
+		if state.status == StatusCritical {
+			s.consecutiveFailures++
+			s.totalFailures++
+		} else {
+			s.consecutiveFailures = 0
+		}
+	}
+
+	s.status = state.status
+	s.statusCode = state.statusCode
+	s.message = state.message
+	s.lastChecked = state.lastChecked
+	s.lastSuccess = state.lastSuccess
+	s.lastFailure = state.lastFailure
+	s.lastError = state.lastError
+	s.duration = state.duration
+	s.stale = false
+	s.restored = false
+	return previous
+}
+
+// State gets the state of the check
+func (c *Check) State() *CheckState {
+	return c.state
+}
+
+// backoffInterval returns the interval at which the check is run given the provided base interval, which is doubled
+// for each consecutive failure from the configured number of failures onwards, up to the configured cap
+func (c *Check) backoffInterval(interval time.Duration) time.Duration {
+	if c.backoffAfter <= 0 {
+		return interval
+	}
+
+	backoff := interval
+	for failures := c.state.ConsecutiveFailures(); failures >= c.backoffAfter && backoff < c.backoffMax; failures-- {
+		backoff *= 2
+	}
+	if backoff > c.backoffMax {
+		backoff = c.backoffMax
+	}
+	if backoff < interval {
+		return interval
+	}
+	return backoff
+}
+
+// isRunning returns true if a run of the checker has not returned, including one abandoned at its timeout
+func (c *Check) isRunning() bool {
+	return atomic.LoadInt32(&c.running) > 0
+}
+
+// History gets the results of the most recent runs of the check, oldest first
+func (c *Check) History() []CheckResult {
+	return c.state.History()
+}
+
+// clone returns a copy of the check with a copy of its state
+func (c *Check) clone() Check {
+	return Check{
+		state:           c.state.clone(),
+		checker:         c.checker,
+		severity:        c.severity,
+		recordFilter:    c.recordFilter,
+		panicPolicy:     c.panicPolicy,
+		interval:        c.interval,
+		timeout:         c.timeout,
+		criticalTimeout: c.criticalTimeout,
+		retries:         c.retries,
+		retryBackoff:    c.retryBackoff,
+		backoffAfter:    c.backoffAfter,
+		backoffMax:      c.backoffMax,
+		informational:   c.informational,
+		nonCritical:     c.nonCritical,
+		dependsOn:       c.dependsOn,
+		debug:           atomic.LoadInt32(&c.debug),
+	}
+}
+
+// shouldRecord returns true if the provided fresh result of the check should be recorded, according to its record filter
+func (c *Check) shouldRecord(state *CheckState) bool {
+	if c.recordFilter == nil {
+		return true
+	}
+
+	current := c.clone()
+	current.state = state.clone()
+	return c.recordFilter(c.clone(), current)
+}
+
 // hasRun returns true if the check has been run and has state
 func (c *Check) hasRun() bool {
 	if c.state.LastChecked() == nil {
@@ -155,16 +634,44 @@ func (c *Check) hasRun() bool {
 }
 
 // NewCheck returns a pointer to a new instantiated Check with
-// the provided checker function
-func NewCheck(name string, checker Checker) (*Check, error) {
+// the provided checker function and options
+func NewCheck(name string, checker Checker, opts ...CheckOption) (*Check, error) {
 	if checker == nil {
 		return nil, errors.New("expected checker but none provided")
 	}
 
-	return &Check{
+	check := &Check{
 		state:   NewCheckState(name),
 		checker: checker,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(check)
+	}
+
+	return check, nil
+}
+
+// setDebug sets whether every run of the check is logged
+func (c *Check) setDebug(enabled bool) {
+	var debug int32
+	if enabled {
+		debug = 1
+	}
+	atomic.StoreInt32(&c.debug, debug)
+}
+
+// isDebug returns true if every run of the check is logged
+func (c *Check) isDebug() bool {
+	return atomic.LoadInt32(&c.debug) == 1
+}
+
+// getSeverity returns the status of the check to be used when aggregating the overall health status
+func (c *Check) getSeverity() string {
+	if c.severity != nil {
+		return c.severity(c.state)
+	}
+	return c.state.Status()
 }
 
 // NewCheckState returns a pointer to a new instantiated CheckState
@@ -185,17 +692,58 @@ func (s *CheckState) MarshalJSON() ([]byte, error) {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
 
+	// the duration is only reported once a run of the checker has been timed
+	var duration *durationJSON
+	if s.duration > 0 {
+		d := newDurationJSON(s.duration)
+		duration = &d
+	}
+
 	return json.Marshal(checkStateJSON{
 		Name:        s.name,
+		Group:       s.group,
+		Labels:      s.labels,
 		Status:      s.status,
 		StatusCode:  s.statusCode,
 		Message:     s.message,
 		LastChecked: s.lastChecked,
 		LastSuccess: s.lastSuccess,
 		LastFailure: s.lastFailure,
+		LastError:   s.lastError,
+		Timeouts:    s.timeouts,
+		Deferrals:   s.deferrals,
+		NextCheck:   s.nextCheck,
+
+		Duration:            duration,
+		ConsecutiveFailures: s.consecutiveFailures,
+		TotalFailures:       s.totalFailures,
+		Stale:               s.stale,
+
+		LastCheckedAgo: s.ago(s.lastChecked),
+		LastSuccessAgo: s.ago(s.lastSuccess),
+		LastFailureAgo: s.ago(s.lastFailure),
+
+		History: s.jsonHistory(),
 	})
 }
 
+// jsonHistory returns the history to report in the JSON representation, or nil if it is not to be reported
+func (s *CheckState) jsonHistory() []CheckResult {
+	if !s.includeHistory {
+		return nil
+	}
+	return s.orderedHistory()
+}
+
+// ago returns the age of the provided time relative to the time the state reports ages from, to the nearest second,
+// or an empty string if either time is not set
+func (s *CheckState) ago(t *time.Time) string {
+	if s.relativeTo == nil || t == nil {
+		return ""
+	}
+	return s.relativeTo.Sub(*t).Round(time.Second).String()
+}
+
 // UnmarshalJSON takes the json representation of a check as a byte array and populates the Check object
 func (c *Check) UnmarshalJSON(b []byte) error {
 	if c.state == nil {
@@ -217,12 +765,26 @@ func (s *CheckState) UnmarshalJSON(b []byte) error {
 		defer s.mutex.Unlock()
 
 		s.name = temp.Name
+		s.group = temp.Group
+		s.labels = temp.Labels
 		s.status = temp.Status
 		s.statusCode = temp.StatusCode
 		s.message = temp.Message
 		s.lastChecked = temp.LastChecked
 		s.lastSuccess = temp.LastSuccess
 		s.lastFailure = temp.LastFailure
+		s.lastError = temp.LastError
+		s.timeouts = temp.Timeouts
+		s.deferrals = temp.Deferrals
+		s.consecutiveFailures = temp.ConsecutiveFailures
+		s.totalFailures = temp.TotalFailures
+		s.stale = temp.Stale
+		s.history = temp.History
+		s.historyNext = 0
+		s.includeHistory = len(temp.History) > 0
+		if temp.Duration != nil {
+			s.duration = temp.Duration.duration()
+		}
 	}
 	return err
 }
@@ -3,6 +3,7 @@ package healthcheck
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"sync"
 	"testing"
 	"time"
@@ -37,6 +38,21 @@ func TestCreateNew(t *testing.T) {
 		So(check2.checker, ShouldEqual, check.checker)
 		So(check2.state.mutex, ShouldNotPointTo, check.state.mutex)
 	})
+	Convey("Create a new check with a severity function", t, func() {
+		severity := func(state *CheckState) string {
+			return StatusWarning
+		}
+		check4, err := NewCheck("check 4", checkerFunc, WithSeverity(severity))
+		So(err, ShouldBeNil)
+		So(check4.severity, ShouldNotBeNil)
+		So(check4.getSeverity(), ShouldEqual, StatusWarning)
+	})
+	Convey("A new check without a severity function uses its recorded status", t, func() {
+		check5, err := NewCheck("check 5", checkerFunc)
+		So(err, ShouldBeNil)
+		check5.state.status = StatusCritical
+		So(check5.getSeverity(), ShouldEqual, StatusCritical)
+	})
 	Convey("Fail to create a new check as checker given is nil", t, func() {
 		check3, err := NewCheck("nil check", nil)
 		So(check3, ShouldBeNil)
@@ -44,6 +60,59 @@ func TestCreateNew(t *testing.T) {
 	})
 }
 
+func TestUpdateFromError(t *testing.T) {
+	Convey("Given a new check state", t, func() {
+		state := NewCheckState("mongo")
+		before := time.Now().UTC()
+
+		Convey("When it is updated from an error", func() {
+			So(state.UpdateFromError(errors.New("connection refused")), ShouldBeNil)
+
+			Convey("Then the check is CRITICAL with the error as its message and last error", func() {
+				So(state.Status(), ShouldEqual, StatusCritical)
+				So(state.Message(), ShouldEqual, "connection refused")
+				So(state.LastError(), ShouldEqual, "connection refused")
+				So(state.LastChecked().Before(before), ShouldBeFalse)
+				So(*state.LastFailure(), ShouldEqual, *state.LastChecked())
+				So(state.LastSuccess(), ShouldBeNil)
+			})
+		})
+
+		Convey("When it is updated from a nil error", func() {
+			So(state.UpdateFromError(nil), ShouldBeNil)
+
+			Convey("Then the check is OK", func() {
+				So(state.Status(), ShouldEqual, StatusOK)
+				So(state.Message(), ShouldEqual, "")
+				So(state.LastError(), ShouldEqual, "")
+				So(state.LastChecked().Before(before), ShouldBeFalse)
+				So(*state.LastSuccess(), ShouldEqual, *state.LastChecked())
+				So(state.LastFailure(), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a health check with a checker that records its result from an error", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", func(ctx context.Context, state *CheckState) error {
+			return state.UpdateFromError(errors.New("connection refused"))
+		}), ShouldBeNil)
+
+		Convey("When the check is run", func() {
+			hc.Tick(context.Background())
+
+			Convey("Then the result is recorded", func() {
+				state := hc.Checks[0].state
+				So(state.Status(), ShouldEqual, StatusCritical)
+				So(state.Message(), ShouldEqual, "connection refused")
+				So(state.LastError(), ShouldEqual, "connection refused")
+				So(state.ConsecutiveFailures(), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
 func TestUpdate(t *testing.T) {
 	var (
 		checkName   = "check name"
@@ -185,6 +254,9 @@ func TestGets(t *testing.T) {
 			lastChecked: &t0,
 			lastSuccess: &t1,
 			lastFailure: &t2,
+			lastError:   "connection refused",
+			timeouts:    3,
+			deferrals:   2,
 			mutex:       &sync.RWMutex{},
 		}
 
@@ -243,6 +315,30 @@ func TestGets(t *testing.T) {
 				So(lastFailure, ShouldResemble, state.lastFailure)
 			})
 		})
+
+		Convey("When getting the last error", func() {
+			lastError := state.LastError()
+
+			Convey("Then the correct error should be returned", func() {
+				So(lastError, ShouldEqual, state.lastError)
+			})
+		})
+
+		Convey("When getting the number of timeouts", func() {
+			timeouts := state.Timeouts()
+
+			Convey("Then the correct number should be returned", func() {
+				So(timeouts, ShouldEqual, state.timeouts)
+			})
+		})
+
+		Convey("When getting the number of deferrals", func() {
+			deferrals := state.Deferrals()
+
+			Convey("Then the correct number should be returned", func() {
+				So(deferrals, ShouldEqual, state.deferrals)
+			})
+		})
 	})
 
 	Convey("Given an unpopulated check state", t, func() {
@@ -358,4 +454,122 @@ func TestJSONMarshalling(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Given a check state with a last error", t, func() {
+		t0 := time.Unix(0, 0).UTC()
+		state := NewCheckState("some check")
+		state.status = "CRITICAL"
+		state.message = "failed to connect"
+		state.lastChecked = &t0
+		state.lastFailure = &t0
+		state.lastError = "dial tcp: connection refused"
+
+		Convey("When marshalling to json", func() {
+			j, err := json.Marshal(state)
+
+			Convey("Then the last error is included alongside the message", func() {
+				So(err, ShouldBeNil)
+				So(string(j), ShouldEqual, "{\"name\":\"some check\",\"status\":\"CRITICAL\",\"message\":\"failed to connect\",\"last_checked\":\"1970-01-01T00:00:00Z\",\"last_success\":null,\"last_failure\":\"1970-01-01T00:00:00Z\",\"last_error\":\"dial tcp: connection refused\"}")
+			})
+
+			Convey("When unmarshalling from json to an empty CheckState", func() {
+				state2 := &CheckState{}
+
+				err := json.Unmarshal(j, state2)
+
+				So(err, ShouldBeNil)
+				So(state2.message, ShouldEqual, state.message)
+				So(state2.lastError, ShouldEqual, state.lastError)
+			})
+		})
+	})
+}
+
+func TestJSONMarshallingRunStats(t *testing.T) {
+	Convey("Given a check state with a timed run and failures", t, func() {
+		t0 := time.Unix(0, 0).UTC()
+		state := NewCheckState("some check")
+		state.status = StatusCritical
+		state.lastChecked = &t0
+		state.duration = 1500 * time.Millisecond
+		state.consecutiveFailures = 2
+		state.totalFailures = 5
+
+		Convey("When marshalling to json", func() {
+			j, err := json.Marshal(state)
+			So(err, ShouldBeNil)
+
+			Convey("Then the duration of the run and the failure counts are included", func() {
+				var body map[string]interface{}
+				So(json.Unmarshal(j, &body), ShouldBeNil)
+				So(body["duration"], ShouldResemble, map[string]interface{}{"seconds": 1.5, "text": "1.5s"})
+				So(body["consecutive_failures"], ShouldEqual, 2)
+				So(body["total_failures"], ShouldEqual, 5)
+			})
+
+			Convey("Then unmarshalling restores the duration and failure counts", func() {
+				unmarshalled := &CheckState{}
+				So(json.Unmarshal(j, unmarshalled), ShouldBeNil)
+				So(unmarshalled.Duration(), ShouldEqual, 1500*time.Millisecond)
+				So(unmarshalled.ConsecutiveFailures(), ShouldEqual, 2)
+				So(unmarshalled.TotalFailures(), ShouldEqual, 5)
+			})
+		})
+	})
+
+	Convey("Given a check state with history that is included in its json", t, func() {
+		t0 := time.Unix(0, 0).UTC()
+		state := NewCheckState("some check")
+		state.status = StatusOK
+		state.lastChecked = &t0
+		state.includeHistory = true
+		state.recordHistory(CheckResult{Time: t0, Status: StatusCritical, Duration: time.Second, Message: "down"}, 3)
+		state.recordHistory(CheckResult{Time: t0, Status: StatusOK, Duration: time.Second, Message: "up"}, 3)
+
+		Convey("When marshalling to json and back", func() {
+			j, err := json.Marshal(state)
+			So(err, ShouldBeNil)
+			unmarshalled := &CheckState{}
+			So(json.Unmarshal(j, unmarshalled), ShouldBeNil)
+
+			Convey("Then the history is restored, oldest first", func() {
+				So(unmarshalled.History(), ShouldResemble, state.History())
+			})
+		})
+	})
+}
+
+func TestBackoffInterval(t *testing.T) {
+	Convey("Given a check that backs off after 2 consecutive failures, up to 5 minutes", t, func() {
+		check, err := NewCheck("check", func(ctx context.Context, state *CheckState) error { return nil }, WithBackoff(2, 5*time.Minute))
+		So(err, ShouldBeNil)
+
+		Convey("Then its interval is the base interval until it has failed twice", func() {
+			So(check.backoffInterval(time.Minute), ShouldEqual, time.Minute)
+			check.state.consecutiveFailures = 1
+			So(check.backoffInterval(time.Minute), ShouldEqual, time.Minute)
+		})
+
+		Convey("Then its interval doubles for each failure from the second onwards", func() {
+			check.state.consecutiveFailures = 2
+			So(check.backoffInterval(time.Minute), ShouldEqual, 2*time.Minute)
+			check.state.consecutiveFailures = 3
+			So(check.backoffInterval(time.Minute), ShouldEqual, 4*time.Minute)
+		})
+
+		Convey("Then its interval is capped at the maximum", func() {
+			check.state.consecutiveFailures = 10
+			So(check.backoffInterval(time.Minute), ShouldEqual, 5*time.Minute)
+		})
+	})
+
+	Convey("Given a check without backoff that has failed many times", t, func() {
+		check, err := NewCheck("check", func(ctx context.Context, state *CheckState) error { return nil })
+		So(err, ShouldBeNil)
+		check.state.consecutiveFailures = 10
+
+		Convey("Then its interval is the base interval", func() {
+			So(check.backoffInterval(time.Minute), ShouldEqual, time.Minute)
+		})
+	})
 }
@@ -0,0 +1,36 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package checks
+
+import (
+	"context"
+	"fmt"
+	"syscall"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// NewDiskSpaceChecker returns a checker that reports the space available to the app on the filesystem containing
+// path, reporting WARNING when it is below warnBelow bytes and CRITICAL when it is below critBelow bytes. A failure
+// to read the filesystem is reported as CRITICAL and its error returned. The available space is included in the check
+// message. The name describes the filesystem in the check message. It is not available on Windows or Plan 9.
+func NewDiskSpaceChecker(name, path string, warnBelow, critBelow uint64) health.Checker {
+	return func(ctx context.Context, state *health.CheckState) error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("failed to read free space of %s", name), 0)
+			return err
+		}
+		available := uint64(stat.Bavail) * uint64(stat.Bsize)
+
+		switch {
+		case available < critBelow:
+			return state.Update(health.StatusCritical, fmt.Sprintf("%s has %d bytes available, below critical threshold of %d", name, available, critBelow), 0)
+		case available < warnBelow:
+			return state.Update(health.StatusWarning, fmt.Sprintf("%s has %d bytes available, below warning threshold of %d", name, available, warnBelow), 0)
+		default:
+			return state.Update(health.StatusOK, fmt.Sprintf("%s has %d bytes available", name, available), 0)
+		}
+	}
+}
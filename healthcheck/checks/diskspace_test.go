@@ -0,0 +1,78 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package checks
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDiskSpaceChecker(t *testing.T) {
+	ctx := context.Background()
+	dir := os.TempDir()
+
+	Convey("Given a disk space checker whose thresholds are below the available space", t, func() {
+		checker := NewDiskSpaceChecker("temp dir", dir, 1, 0)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("temp dir")
+			err := checker(ctx, state)
+
+			Convey("Then the filesystem is reported as OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldStartWith, "temp dir has ")
+			})
+		})
+	})
+
+	Convey("Given a disk space checker whose warning threshold is above the available space", t, func() {
+		checker := NewDiskSpaceChecker("temp dir", dir, math.MaxUint64, 0)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("temp dir")
+			err := checker(ctx, state)
+
+			Convey("Then the filesystem is reported as WARNING", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusWarning)
+			})
+		})
+	})
+
+	Convey("Given a disk space checker whose critical threshold is above the available space", t, func() {
+		checker := NewDiskSpaceChecker("temp dir", dir, math.MaxUint64, math.MaxUint64)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("temp dir")
+			err := checker(ctx, state)
+
+			Convey("Then the filesystem is reported as CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+			})
+		})
+	})
+
+	Convey("Given a disk space checker for a path that does not exist", t, func() {
+		checker := NewDiskSpaceChecker("temp dir", filepath.Join(dir, "does-not-exist", "really"), 1, 0)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("temp dir")
+			err := checker(ctx, state)
+
+			Convey("Then the filesystem is reported as CRITICAL and the error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "failed to read free space of temp dir")
+			})
+		})
+	})
+}
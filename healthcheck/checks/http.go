@@ -0,0 +1,42 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// NewHTTPChecker returns a checker that makes a GET request to url, reporting OK when the response status code is
+// within the inclusive range minStatus to maxStatus, e.g. 200 to 299, and CRITICAL otherwise. A failed request is
+// reported as CRITICAL and its error returned. The status code of the response is recorded with the check. If client
+// is nil, http.DefaultClient is used. The name describes the endpoint in the check message.
+func NewHTTPChecker(name, url string, client *http.Client, minStatus, maxStatus int) health.Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context, state *health.CheckState) error {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("invalid %s URL", name), 0)
+			return err
+		}
+
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("%s is unreachable", name), 0)
+			return err
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode < minStatus || resp.StatusCode > maxStatus {
+			return state.Update(health.StatusCritical, fmt.Sprintf("%s responded with unexpected status %d", name, resp.StatusCode), resp.StatusCode)
+		}
+		return state.Update(health.StatusOK, fmt.Sprintf("%s is OK", name), resp.StatusCode)
+	}
+}
@@ -0,0 +1,72 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHTTPChecker(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given an endpoint that responds with a status within the expected range", t, func() {
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer endpoint.Close()
+		checker := NewHTTPChecker("search API", endpoint.URL, endpoint.Client(), 200, 299)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("search API")
+			err := checker(ctx, state)
+
+			Convey("Then the endpoint is reported as OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, "search API is OK")
+				So(state.StatusCode(), ShouldEqual, http.StatusNoContent)
+			})
+		})
+	})
+
+	Convey("Given an endpoint that responds with a status outside the expected range", t, func() {
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer endpoint.Close()
+		checker := NewHTTPChecker("search API", endpoint.URL, endpoint.Client(), 200, 299)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("search API")
+			err := checker(ctx, state)
+
+			Convey("Then the endpoint is reported as CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "search API responded with unexpected status 404")
+				So(state.StatusCode(), ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+
+	Convey("Given an endpoint that is down", t, func() {
+		endpoint := httptest.NewServer(http.NotFoundHandler())
+		endpoint.Close()
+		checker := NewHTTPChecker("search API", endpoint.URL, nil, 200, 299)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("search API")
+			err := checker(ctx, state)
+
+			Convey("Then the endpoint is reported as CRITICAL and the error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "search API is unreachable")
+			})
+		})
+	})
+}
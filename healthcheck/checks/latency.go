@@ -0,0 +1,38 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// Probe makes a request to a dependency, returning an error if the request fails
+type Probe func(ctx context.Context) error
+
+// NewLatencyChecker returns a checker that times the provided probe, reporting WARNING when its latency exceeds
+// warnAbove and CRITICAL when it exceeds critAbove, even if the probe succeeds. A failed probe is reported as
+// CRITICAL and its error returned. The name describes the dependency in the check message, along with the measured
+// latency and, when it is exceeded, the threshold.
+func NewLatencyChecker(name string, probe Probe, warnAbove, critAbove time.Duration) health.Checker {
+	return func(ctx context.Context, state *health.CheckState) error {
+		start := time.Now()
+		err := probe(ctx)
+		latency := time.Since(start)
+
+		if err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("%s probe failed after %s", name, latency), 0)
+			return err
+		}
+
+		switch {
+		case latency > critAbove:
+			return state.Update(health.StatusCritical, fmt.Sprintf("%s latency of %s is above critical threshold of %s", name, latency, critAbove), 0)
+		case latency > warnAbove:
+			return state.Update(health.StatusWarning, fmt.Sprintf("%s latency of %s is above warning threshold of %s", name, latency, warnAbove), 0)
+		default:
+			return state.Update(health.StatusOK, fmt.Sprintf("%s latency of %s", name, latency), 0)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func sleepingProbe(d time.Duration, err error) Probe {
+	return func(ctx context.Context) error {
+		time.Sleep(d)
+		return err
+	}
+}
+
+// measuredLatency returns the latency in the message of a latency check, or zero if there is none
+func measuredLatency(message string) time.Duration {
+	fields := strings.Fields(message)
+	if len(fields) < 4 {
+		return 0
+	}
+	latency, _ := time.ParseDuration(fields[3])
+	return latency
+}
+
+func TestLatencyChecker(t *testing.T) {
+	ctx := context.Background()
+	warnAbove := 20 * time.Millisecond
+	critAbove := 50 * time.Millisecond
+
+	Convey("Given a probe that responds within the warning threshold", t, func() {
+		checker := NewLatencyChecker("mongodb", sleepingProbe(0, nil), warnAbove, critAbove)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("latency")
+			err := checker(ctx, state)
+
+			Convey("Then the status is OK and the latency is in the message", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldStartWith, "mongodb latency of ")
+			})
+		})
+	})
+
+	Convey("Given a probe that responds above the warning threshold", t, func() {
+		checker := NewLatencyChecker("mongodb", sleepingProbe(30*time.Millisecond, nil), warnAbove, critAbove)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("latency")
+			err := checker(ctx, state)
+
+			Convey("Then the status is WARNING", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusWarning)
+				So(state.Message(), ShouldStartWith, "mongodb latency of ")
+				So(state.Message(), ShouldEndWith, " is above warning threshold of 20ms")
+				So(measuredLatency(state.Message()), ShouldBeGreaterThanOrEqualTo, 30*time.Millisecond)
+			})
+		})
+	})
+
+	Convey("Given a probe that responds above the critical threshold", t, func() {
+		checker := NewLatencyChecker("mongodb", sleepingProbe(60*time.Millisecond, nil), warnAbove, critAbove)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("latency")
+			err := checker(ctx, state)
+
+			Convey("Then the status is CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldStartWith, "mongodb latency of ")
+				So(state.Message(), ShouldEndWith, " is above critical threshold of 50ms")
+				So(measuredLatency(state.Message()), ShouldBeGreaterThanOrEqualTo, 60*time.Millisecond)
+			})
+		})
+	})
+
+	Convey("Given a probe that fails", t, func() {
+		probeErr := errors.New("connection refused")
+		checker := NewLatencyChecker("mongodb", sleepingProbe(0, probeErr), warnAbove, critAbove)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("latency")
+			err := checker(ctx, state)
+
+			Convey("Then the status is CRITICAL and the probe error is returned", func() {
+				So(err, ShouldEqual, probeErr)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldStartWith, "mongodb probe failed after ")
+			})
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package checks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// minIntervalResult is the result of the most recent run of a checker wrapped by WithMinInterval
+type minIntervalResult struct {
+	status     string
+	message    string
+	statusCode int
+	err        error
+	time       time.Time
+}
+
+// WithMinInterval wraps the provided checker so that it runs at most once per min interval, however often the
+// wrapped checker is called. Calls within the interval record the result of the most recent run and return its error
+// without running the checker, protecting a costly dependency from being probed too often.
+func WithMinInterval(checker health.Checker, min time.Duration) health.Checker {
+	var (
+		mutex sync.Mutex
+		last  *minIntervalResult
+	)
+
+	return func(ctx context.Context, state *health.CheckState) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if last != nil && time.Since(last.time) < min {
+			if last.status != "" {
+				state.Update(last.status, last.message, last.statusCode)
+			}
+			return last.err
+		}
+
+		err := checker(ctx, state)
+		last = &minIntervalResult{
+			status:     state.Status(),
+			message:    state.Message(),
+			statusCode: state.StatusCode(),
+			err:        err,
+			time:       time.Now(),
+		}
+		return err
+	}
+}
@@ -0,0 +1,57 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWithMinInterval(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a checker wrapped with a minimum interval", t, func() {
+		runs := 0
+		checker := func(ctx context.Context, state *health.CheckState) error {
+			runs++
+			state.Update(health.StatusCritical, "dependency unavailable", 503)
+			return errors.New("connection refused")
+		}
+		min := 50 * time.Millisecond
+		wrapped := WithMinInterval(checker, min)
+
+		Convey("When the checker is called repeatedly within the interval", func() {
+			state := health.NewCheckState("costly")
+			So(wrapped(ctx, state), ShouldNotBeNil)
+			lastChecked := *state.LastChecked()
+			err := wrapped(ctx, state)
+
+			Convey("Then the checker is only run once", func() {
+				So(runs, ShouldEqual, 1)
+			})
+
+			Convey("Then the cached result is recorded and its error returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "connection refused")
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "dependency unavailable")
+				So(state.StatusCode(), ShouldEqual, 503)
+				So(*state.LastChecked(), ShouldHappenOnOrAfter, lastChecked)
+			})
+		})
+
+		Convey("When the checker is called again after the interval", func() {
+			state := health.NewCheckState("costly")
+			wrapped(ctx, state)
+			time.Sleep(min)
+			wrapped(ctx, state)
+
+			Convey("Then the checker is run again", func() {
+				So(runs, ShouldEqual, 2)
+			})
+		})
+	})
+}
@@ -0,0 +1,43 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// NewProxyChecker returns a checker that requests the outbound proxy at proxyURL directly, so that a failed proxy is
+// reported as a single root cause rather than appearing as every dependency failing. Any response from the proxy
+// below 500 (including 4xx responses to a request that is not being proxied) shows it is up and is reported as OK.
+// A 5xx response or a failed request is reported as CRITICAL. The client must not itself be configured to use the
+// proxy. If client is nil, http.DefaultClient is used. The name describes the proxy in the check message.
+func NewProxyChecker(name, proxyURL string, client *http.Client) health.Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return func(ctx context.Context, state *health.CheckState) error {
+		req, err := http.NewRequest(http.MethodGet, proxyURL, nil)
+		if err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("invalid %s URL", name), 0)
+			return err
+		}
+
+		resp, err := client.Do(req.WithContext(ctx))
+		if err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("%s is unreachable", name), 0)
+			return err
+		}
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		if resp.StatusCode >= http.StatusInternalServerError {
+			return state.Update(health.StatusCritical, fmt.Sprintf("%s is failing", name), resp.StatusCode)
+		}
+		return state.Update(health.StatusOK, fmt.Sprintf("%s is reachable", name), resp.StatusCode)
+	}
+}
@@ -0,0 +1,72 @@
+package checks
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestProxyChecker(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a proxy that rejects requests that are not being proxied", t, func() {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer proxy.Close()
+		checker := NewProxyChecker("egress proxy", proxy.URL, proxy.Client())
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("egress proxy")
+			err := checker(ctx, state)
+
+			Convey("Then the proxy is reported as OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, "egress proxy is reachable")
+				So(state.StatusCode(), ShouldEqual, http.StatusBadRequest)
+			})
+		})
+	})
+
+	Convey("Given a proxy that is failing", t, func() {
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer proxy.Close()
+		checker := NewProxyChecker("egress proxy", proxy.URL, proxy.Client())
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("egress proxy")
+			err := checker(ctx, state)
+
+			Convey("Then the proxy is reported as CRITICAL", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "egress proxy is failing")
+				So(state.StatusCode(), ShouldEqual, http.StatusBadGateway)
+			})
+		})
+	})
+
+	Convey("Given a proxy that is down", t, func() {
+		proxy := httptest.NewServer(http.NotFoundHandler())
+		proxy.Close()
+		checker := NewProxyChecker("egress proxy", proxy.URL, nil)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("egress proxy")
+			err := checker(ctx, state)
+
+			Convey("Then the proxy is reported as CRITICAL and the error returned", func() {
+				So(err, ShouldNotBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "egress proxy is unreachable")
+			})
+		})
+	})
+}
@@ -0,0 +1,123 @@
+// Package checks provides reusable checker functions for the health check library
+package checks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// severities orders the check statuses from healthiest to least healthy
+var severities = map[string]int{
+	health.StatusOK:       0,
+	health.StatusWarning:  1,
+	health.StatusCritical: 2,
+}
+
+// healthResponse represents the parts of a health endpoint response used by the recursive checker
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// RecursiveChecker checks the health of an app by requesting its health endpoint and then the health endpoints
+// of its dependencies, up to a maximum depth. The worst status found within the traversal is reported.
+type RecursiveChecker struct {
+	URL    string
+	Client *http.Client
+	// MaxDepth is the number of levels of dependencies followed below the app at URL
+	MaxDepth int
+	// Dependencies maps the health endpoint URL of an app to the health endpoint URLs of its dependencies
+	Dependencies map[string][]string
+}
+
+// NewRecursiveChecker returns a checker that reports the worst health status found by following health endpoints
+// from the provided URL through the dependencies map, to at most maxDepth levels below the URL.
+// Each health endpoint is only requested once per check, so cycles in the dependency graph are not followed.
+func NewRecursiveChecker(url string, client *http.Client, maxDepth int, dependencies map[string][]string) health.Checker {
+	checker := &RecursiveChecker{
+		URL:          url,
+		Client:       client,
+		MaxDepth:     maxDepth,
+		Dependencies: dependencies,
+	}
+	return checker.Check
+}
+
+// Check traverses the health endpoints and updates the provided state with the worst status found
+func (r *RecursiveChecker) Check(ctx context.Context, state *health.CheckState) error {
+	visited := make(map[string]bool)
+	status, url, err := r.worstStatus(ctx, r.URL, 0, visited)
+	if err != nil {
+		return state.Update(health.StatusCritical, fmt.Sprintf("failed to get health of %s: %s", url, err.Error()), 0)
+	}
+
+	if status == health.StatusOK {
+		return state.Update(health.StatusOK, fmt.Sprintf("%d health endpoints reported %s", len(visited), health.StatusOK), 0)
+	}
+	return state.Update(status, fmt.Sprintf("%s reported %s", url, status), 0)
+}
+
+// worstStatus returns the worst status, and the URL it was found at, of the app at the provided URL and its
+// dependencies. Traversal stops at the first error.
+func (r *RecursiveChecker) worstStatus(ctx context.Context, url string, depth int, visited map[string]bool) (string, string, error) {
+	visited[url] = true
+
+	status, err := r.getStatus(ctx, url)
+	if err != nil {
+		return "", url, err
+	}
+	worstURL := url
+
+	if depth >= r.MaxDepth {
+		return status, worstURL, nil
+	}
+
+	for _, dependency := range r.Dependencies[url] {
+		if visited[dependency] {
+			continue
+		}
+
+		dependencyStatus, dependencyURL, err := r.worstStatus(ctx, dependency, depth+1, visited)
+		if err != nil {
+			return "", dependencyURL, err
+		}
+		if severities[dependencyStatus] > severities[status] {
+			status = dependencyStatus
+			worstURL = dependencyURL
+		}
+	}
+
+	return status, worstURL, nil
+}
+
+// getStatus requests the health endpoint at the provided URL and returns the overall status it reports
+func (r *RecursiveChecker) getStatus(ctx context.Context, url string) (string, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var response healthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("invalid health response with status code %d: %s", resp.StatusCode, err.Error())
+	}
+
+	if _, ok := severities[response.Status]; !ok {
+		return "", fmt.Errorf("unrecognised health status: %s", response.Status)
+	}
+	return response.Status, nil
+}
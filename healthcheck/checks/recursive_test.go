@@ -0,0 +1,143 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// healthServer returns a test server that responds to every request with the provided health status,
+// counting the requests it receives
+func healthServer(status string, requests *int, mutex *sync.Mutex) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mutex.Lock()
+		*requests++
+		mutex.Unlock()
+		fmt.Fprintf(w, `{"status":"%s"}`, status)
+	}))
+}
+
+func TestRecursiveChecker(t *testing.T) {
+	ctx := context.Background()
+	mutex := &sync.Mutex{}
+
+	Convey("Given an app whose dependencies are all healthy", t, func() {
+		var requestsA, requestsB, requestsC int
+		a := healthServer(health.StatusOK, &requestsA, mutex)
+		defer a.Close()
+		b := healthServer(health.StatusOK, &requestsB, mutex)
+		defer b.Close()
+		c := healthServer(health.StatusOK, &requestsC, mutex)
+		defer c.Close()
+
+		dependencies := map[string][]string{
+			a.URL: {b.URL},
+			b.URL: {c.URL},
+		}
+
+		Convey("When the recursive checker is run", func() {
+			state := health.NewCheckState("recursive")
+			err := NewRecursiveChecker(a.URL, nil, 2, dependencies)(ctx, state)
+
+			Convey("Then every endpoint is requested and the status is OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, "3 health endpoints reported OK")
+				So(requestsA, ShouldEqual, 1)
+				So(requestsB, ShouldEqual, 1)
+				So(requestsC, ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the recursive checker is run with a max depth of 1", func() {
+			state := health.NewCheckState("recursive")
+			err := NewRecursiveChecker(a.URL, nil, 1, dependencies)(ctx, state)
+
+			Convey("Then dependencies beyond the max depth are not requested", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(requestsC, ShouldEqual, 0)
+			})
+		})
+	})
+
+	Convey("Given an app with a transitive dependency that is critical", t, func() {
+		var requestsA, requestsB, requestsC int
+		a := healthServer(health.StatusOK, &requestsA, mutex)
+		defer a.Close()
+		b := healthServer(health.StatusWarning, &requestsB, mutex)
+		defer b.Close()
+		c := healthServer(health.StatusCritical, &requestsC, mutex)
+		defer c.Close()
+
+		dependencies := map[string][]string{
+			a.URL: {b.URL},
+			b.URL: {c.URL},
+		}
+
+		Convey("When the recursive checker is run", func() {
+			state := health.NewCheckState("recursive")
+			err := NewRecursiveChecker(a.URL, nil, 5, dependencies)(ctx, state)
+
+			Convey("Then the worst status found is reported", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, c.URL+" reported CRITICAL")
+			})
+		})
+	})
+
+	Convey("Given apps whose dependencies form a cycle", t, func() {
+		var requestsA, requestsB int
+		a := healthServer(health.StatusOK, &requestsA, mutex)
+		defer a.Close()
+		b := healthServer(health.StatusWarning, &requestsB, mutex)
+		defer b.Close()
+
+		dependencies := map[string][]string{
+			a.URL: {b.URL},
+			b.URL: {a.URL},
+		}
+
+		Convey("When the recursive checker is run", func() {
+			state := health.NewCheckState("recursive")
+			err := NewRecursiveChecker(a.URL, nil, 10, dependencies)(ctx, state)
+
+			Convey("Then each endpoint is only requested once", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusWarning)
+				So(requestsA, ShouldEqual, 1)
+				So(requestsB, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given an app with an unreachable dependency", t, func() {
+		var requestsA int
+		a := healthServer(health.StatusOK, &requestsA, mutex)
+		defer a.Close()
+		b := httptest.NewServer(http.NotFoundHandler())
+		b.Close()
+
+		dependencies := map[string][]string{
+			a.URL: {b.URL},
+		}
+
+		Convey("When the recursive checker is run", func() {
+			state := health.NewCheckState("recursive")
+			err := NewRecursiveChecker(a.URL, nil, 1, dependencies)(ctx, state)
+
+			Convey("Then the status is critical", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldStartWith, "failed to get health of "+b.URL)
+			})
+		})
+	})
+}
@@ -0,0 +1,22 @@
+package checks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// NewSQLChecker returns a checker that pings the database, establishing a connection if necessary. A failed ping is
+// reported as CRITICAL and its error returned. The name describes the database in the check message.
+func NewSQLChecker(name string, db *sql.DB) health.Checker {
+	return func(ctx context.Context, state *health.CheckState) error {
+		if err := db.PingContext(ctx); err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("%s is unreachable", name), 0)
+			return err
+		}
+
+		return state.Update(health.StatusOK, fmt.Sprintf("%s is reachable", name), 0)
+	}
+}
@@ -0,0 +1,79 @@
+package checks
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// stubDriver opens connections whose ping returns the error registered for the data source name
+type stubDriver struct{}
+
+var pingErrors = map[string]error{
+	"up":   nil,
+	"down": errors.New("connection refused"),
+}
+
+func (stubDriver) Open(name string) (driver.Conn, error) {
+	return stubConn{pingErr: pingErrors[name]}, nil
+}
+
+type stubConn struct {
+	pingErr error
+}
+
+func (c stubConn) Ping(ctx context.Context) error { return c.pingErr }
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c stubConn) Close() error              { return nil }
+func (c stubConn) Begin() (driver.Tx, error) { return nil, errors.New("not implemented") }
+
+func init() {
+	sql.Register("healthcheck-stub", stubDriver{})
+}
+
+func TestSQLChecker(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a database that is up", t, func() {
+		db, err := sql.Open("healthcheck-stub", "up")
+		So(err, ShouldBeNil)
+		defer db.Close()
+		checker := NewSQLChecker("postgres", db)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("postgres")
+			err := checker(ctx, state)
+
+			Convey("Then the database is reported as OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, "postgres is reachable")
+			})
+		})
+	})
+
+	Convey("Given a database that is down", t, func() {
+		db, err := sql.Open("healthcheck-stub", "down")
+		So(err, ShouldBeNil)
+		defer db.Close()
+		checker := NewSQLChecker("postgres", db)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("postgres")
+			err := checker(ctx, state)
+
+			Convey("Then the database is reported as CRITICAL and the error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "postgres is unreachable")
+			})
+		})
+	})
+}
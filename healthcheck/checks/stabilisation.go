@@ -0,0 +1,51 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// WithStabilisation wraps the provided checker so that a dependency is only reported as OK once the checker has
+// consistently reported OK for the stabilisation window. Until then OK results are reported as WARNING, and any
+// result that is not OK, or that is returned with an error, restarts the window, as does a checker that panics.
+// This prevents a dependency that is flapping during its own startup from being reported as healthy the first time
+// it responds successfully.
+func WithStabilisation(checker health.Checker, window time.Duration) health.Checker {
+	var (
+		mutex   sync.Mutex
+		okSince time.Time
+	)
+
+	return func(ctx context.Context, state *health.CheckState) error {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		// a checker that panics restarts the window, leaving the panic to be recovered by the health check
+		completed := false
+		defer func() {
+			if !completed {
+				okSince = time.Time{}
+			}
+		}()
+
+		err := checker(ctx, state)
+		completed = true
+		if err != nil || state.Status() != health.StatusOK {
+			okSince = time.Time{}
+			return err
+		}
+
+		now := time.Now()
+		if okSince.IsZero() {
+			okSince = now
+		}
+		if stable := now.Sub(okSince); stable < window {
+			state.Update(health.StatusWarning, fmt.Sprintf("waiting for dependency to stabilise, OK for %s of %s", stable.Round(time.Millisecond), window), state.StatusCode())
+		}
+		return err
+	}
+}
@@ -0,0 +1,98 @@
+package checks
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWithStabilisation(t *testing.T) {
+	ctx := context.Background()
+	window := 50 * time.Millisecond
+
+	Convey("Given a checker with a stabilisation window whose status can be changed", t, func() {
+		var (
+			status   = health.StatusOK
+			checkErr error
+			panicked bool
+		)
+		checker := func(ctx context.Context, state *health.CheckState) error {
+			if panicked {
+				panic("nil map")
+			}
+			if err := state.Update(status, "dependency responded", 200); err != nil {
+				return err
+			}
+			return checkErr
+		}
+		wrapped := WithStabilisation(checker, window)
+		state := health.NewCheckState("dependency")
+
+		Convey("When the dependency first responds successfully", func() {
+			err := wrapped(ctx, state)
+
+			Convey("Then it is reported as WARNING while it stabilises", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusWarning)
+				So(state.Message(), ShouldStartWith, "waiting for dependency to stabilise")
+				So(state.StatusCode(), ShouldEqual, 200)
+			})
+		})
+
+		Convey("When the dependency has responded successfully for the window", func() {
+			wrapped(ctx, state)
+			time.Sleep(window)
+			wrapped(ctx, state)
+
+			Convey("Then it is reported as OK", func() {
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, "dependency responded")
+			})
+		})
+
+		Convey("When the dependency fails during the window", func() {
+			wrapped(ctx, state)
+			status = health.StatusCritical
+			wrapped(ctx, state)
+			So(state.Status(), ShouldEqual, health.StatusCritical)
+			status = health.StatusOK
+			time.Sleep(window)
+			wrapped(ctx, state)
+
+			Convey("Then the window restarts from its next successful response", func() {
+				So(state.Status(), ShouldEqual, health.StatusWarning)
+			})
+		})
+
+		Convey("When the checker returns an error during the window without changing its status", func() {
+			wrapped(ctx, state)
+			checkErr = errors.New("unexpected response")
+			err := wrapped(ctx, state)
+			So(err == checkErr, ShouldBeTrue)
+			checkErr = nil
+			time.Sleep(window)
+			wrapped(ctx, state)
+
+			Convey("Then the window restarts from its next successful response", func() {
+				So(state.Status(), ShouldEqual, health.StatusWarning)
+			})
+		})
+
+		Convey("When the checker panics during the window", func() {
+			wrapped(ctx, state)
+			panicked = true
+			So(func() { wrapped(ctx, state) }, ShouldPanicWith, "nil map")
+			panicked = false
+			time.Sleep(window)
+			wrapped(ctx, state)
+
+			Convey("Then the panic is passed on and the window restarts from its next successful response", func() {
+				So(state.Status(), ShouldEqual, health.StatusWarning)
+			})
+		})
+	})
+}
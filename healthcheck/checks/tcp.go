@@ -0,0 +1,26 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// NewTCPChecker returns a checker that opens a TCP connection to address, e.g. "localhost:27017", closing it straight
+// away, for dependencies that have no health endpoint. A failed connection is reported as CRITICAL and its error
+// returned. The dial is bounded by the context of the check. The name describes the dependency in the check message.
+func NewTCPChecker(name, address string) health.Checker {
+	return func(ctx context.Context, state *health.CheckState) error {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("%s is unreachable", name), 0)
+			return err
+		}
+		conn.Close()
+
+		return state.Update(health.StatusOK, fmt.Sprintf("%s is reachable", name), 0)
+	}
+}
@@ -0,0 +1,50 @@
+package checks
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTCPChecker(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a dependency that is listening", t, func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer listener.Close()
+		checker := NewTCPChecker("mongoDB", listener.Addr().String())
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("mongoDB")
+			err := checker(ctx, state)
+
+			Convey("Then the dependency is reported as OK", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, "mongoDB is reachable")
+			})
+		})
+	})
+
+	Convey("Given a dependency that is not listening", t, func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		listener.Close()
+		checker := NewTCPChecker("mongoDB", listener.Addr().String())
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("mongoDB")
+			err := checker(ctx, state)
+
+			Convey("Then the dependency is reported as CRITICAL and the error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "mongoDB is unreachable")
+			})
+		})
+	})
+}
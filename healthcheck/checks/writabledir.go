@@ -0,0 +1,35 @@
+package checks
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// NewWritableDirChecker returns a checker that creates and deletes a temporary file in the directory at path on every
+// run, reporting CRITICAL and returning the error if either fails. Unlike checking the directory exists, this detects
+// a read-only remount or a change in permissions. The name describes the directory in the check message.
+func NewWritableDirChecker(name, path string) health.Checker {
+	return func(ctx context.Context, state *health.CheckState) error {
+		f, err := ioutil.TempFile(path, ".healthcheck-")
+		if err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("%s is not writable", name), 0)
+			return err
+		}
+
+		closeErr := f.Close()
+		if err := os.Remove(f.Name()); err != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("failed to delete file from %s", name), 0)
+			return err
+		}
+		if closeErr != nil {
+			state.Update(health.StatusCritical, fmt.Sprintf("failed to write file to %s", name), 0)
+			return closeErr
+		}
+
+		return state.Update(health.StatusOK, fmt.Sprintf("%s is writable", name), 0)
+	}
+}
@@ -0,0 +1,56 @@
+package checks
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWritableDirChecker(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a writable directory", t, func() {
+		dir, err := ioutil.TempDir("", "writabledir")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		checker := NewWritableDirChecker("data dir", dir)
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("data dir")
+			err := checker(ctx, state)
+
+			Convey("Then the status is OK and no file is left behind", func() {
+				So(err, ShouldBeNil)
+				So(state.Status(), ShouldEqual, health.StatusOK)
+				So(state.Message(), ShouldEqual, "data dir is writable")
+
+				files, err := ioutil.ReadDir(dir)
+				So(err, ShouldBeNil)
+				So(files, ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a directory that does not exist", t, func() {
+		dir, err := ioutil.TempDir("", "writabledir")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		checker := NewWritableDirChecker("data dir", filepath.Join(dir, "missing"))
+
+		Convey("When the checker is run", func() {
+			state := health.NewCheckState("data dir")
+			err := checker(ctx, state)
+
+			Convey("Then the status is CRITICAL and the error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(state.Status(), ShouldEqual, health.StatusCritical)
+				So(state.Message(), ShouldEqual, "data dir is not writable")
+			})
+		})
+	})
+}
@@ -0,0 +1,20 @@
+// Package chi registers the health, liveness and readiness handlers of a health check with a chi router. It is a
+// separate package so that apps that do not use chi do not depend on it.
+package chi
+
+import (
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	gochi "github.com/go-chi/chi/v5"
+)
+
+// RegisterRoutes registers the health, liveness and readiness handlers of the provided health check with the provided
+// router, for GET and HEAD requests on the paths of the health check
+func RegisterRoutes(r gochi.Router, hc *health.HealthCheck) {
+	paths := hc.Paths()
+	r.Get(paths.Health, hc.Handler)
+	r.Head(paths.Health, hc.Handler)
+	r.Get(paths.Liveness, hc.LivenessHandler)
+	r.Head(paths.Liveness, hc.LivenessHandler)
+	r.Get(paths.Readiness, hc.ReadinessHandler)
+	r.Head(paths.Readiness, hc.ReadinessHandler)
+}
@@ -0,0 +1,138 @@
+package healthcheck
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and tickers used to schedule the checks, so that tests can control the passing of
+// time, e.g. with hctest.FakeClock. By default the real clock is used.
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker delivers the ticks of a Clock at an interval, as time.Ticker does for the real clock
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is the Clock of the time package
+type realClock struct{}
+
+// Now returns the current time
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTicker returns a time.Ticker with the provided interval
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return &realTicker{ticker: time.NewTicker(d)}
+}
+
+// realTicker is the Ticker of the time package
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+// C returns the channel on which the ticks are delivered
+func (t *realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+// Stop turns off the ticker
+func (t *realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// now returns the current time of the health check clock, in UTC
+func (hc *HealthCheck) now() time.Time {
+	if hc.clock == nil {
+		return time.Now().UTC()
+	}
+	return hc.clock.Now().UTC()
+}
+
+// jitterTicker is a Ticker that ticks at an interval that is randomly offset afresh for each tick, so that checks
+// sharing an interval drift apart rather than keeping the same relative timing for as long as they run
+type jitterTicker struct {
+	c chan time.Time
+	// next is the interval from the previous tick, or from the creation of the ticker, until the next tick
+	next     time.Duration
+	mutex    *sync.Mutex
+	stopping chan bool
+	stopped  chan bool
+	stopOnce *sync.Once
+}
+
+// newJitterTicker returns a jitterTicker that ticks first after the provided delay, or after a jittered interval if
+// the delay is not positive, and then at the provided interval of the provided clock, randomly offset on each tick by
+// up to the provided fraction of the interval
+func newJitterTicker(clock Clock, interval time.Duration, jitter float64, delay time.Duration) *jitterTicker {
+	t := &jitterTicker{
+		c:        make(chan time.Time, 1),
+		stopping: make(chan bool),
+		stopped:  make(chan bool),
+		stopOnce: &sync.Once{},
+		mutex:    &sync.Mutex{},
+	}
+	if delay <= 0 {
+		delay = calcIntervalWithJitter(interval, jitter)
+	}
+	t.next = delay
+
+	go func() {
+		defer close(t.stopped)
+		for {
+			// the ticker of the clock is only used for its first tick, so that the next can be given a new interval
+			clockTicker := clock.NewTicker(delay)
+			select {
+			case <-t.stopping:
+				clockTicker.Stop()
+				return
+			case tick := <-clockTicker.C():
+				clockTicker.Stop()
+				// the interval until the following tick is chosen before the tick is delivered, so that it is known
+				// by the time the tick is received
+				delay = calcIntervalWithJitter(interval, jitter)
+				t.setNextInterval(delay)
+				// as with time.Ticker, the tick is dropped if the previous tick has not yet been received
+				select {
+				case t.c <- tick:
+				default:
+				}
+			}
+		}
+	}()
+	return t
+}
+
+// C returns the channel on which the ticks are delivered
+func (t *jitterTicker) C() <-chan time.Time {
+	return t.c
+}
+
+// nextInterval returns the interval from the previous tick, or from the creation of the ticker, until the next tick
+func (t *jitterTicker) nextInterval() time.Duration {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	return t.next
+}
+
+// setNextInterval records the interval from the previous tick until the next tick
+func (t *jitterTicker) setNextInterval(d time.Duration) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.next = d
+}
+
+// Stop turns off the ticker, waiting for it to stop the ticker of its clock. It is safe to call more than once.
+func (t *jitterTicker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopping)
+	})
+	<-t.stopped
+}
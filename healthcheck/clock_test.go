@@ -0,0 +1,254 @@
+package healthcheck_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck/hctest"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClock(t *testing.T) {
+	Convey("Given a started health check using a fake clock", t, func() {
+		var runs int32
+		checker := func(ctx context.Context, state *health.CheckState) error {
+			atomic.AddInt32(&runs, 1)
+			return state.Update(health.StatusOK, "", 0)
+		}
+		getRuns := func() int32 {
+			return atomic.LoadInt32(&runs)
+		}
+
+		t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock := hctest.NewFakeClock(t0)
+		hc, err := health.New(health.VersionInfo{}, time.Hour, time.Minute, health.WithClock(clock), health.WithJitter(0))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		So(hc.StartTime, ShouldEqual, t0)
+		So(hc.WaitForReady(context.Background()), ShouldBeNil)
+
+		Convey("When real time passes without the clock being advanced", func() {
+			time.Sleep(50 * time.Millisecond)
+
+			Convey("Then the check has only run on start", func() {
+				So(getRuns(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When the clock is advanced by the interval", func() {
+			clock.Advance(time.Minute)
+
+			Convey("Then the check runs again, and is recorded at the time of the clock", func() {
+				lastChecked := func() time.Time {
+					return *hc.GetState().Checks[0].State().LastChecked()
+				}
+				for i := 0; i < 100 && !lastChecked().Equal(t0.Add(time.Minute)); i++ {
+					time.Sleep(5 * time.Millisecond)
+				}
+				So(getRuns(), ShouldEqual, 2)
+				So(lastChecked(), ShouldEqual, t0.Add(time.Minute))
+			})
+		})
+
+		Convey("When the health check is stopped", func() {
+			hc.Stop()
+
+			Convey("Then the tickers of the clock are stopped", func() {
+				So(clock.Tickers(), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestRunDuration(t *testing.T) {
+	Convey("Given a health check using a fake clock, with a check that takes 3 seconds of the clock to run", t, func() {
+		t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock := hctest.NewFakeClock(t0)
+		hc, err := health.New(health.VersionInfo{}, time.Hour, time.Minute, health.WithClock(clock))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *health.CheckState) error {
+			clock.Advance(3 * time.Second)
+			return state.Update(health.StatusOK, "", 0)
+		}), ShouldBeNil)
+
+		Convey("When the check is run", func() {
+			hc.Tick(context.Background())
+
+			Convey("Then the duration of the run is measured by the clock", func() {
+				So(hc.GetState().Checks[0].State().Duration(), ShouldEqual, 3*time.Second)
+			})
+		})
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	Convey("Given a started health check using a fake clock, with a failing check that backs off after 2 failures", t, func() {
+		var runs int32
+		getRuns := func() int32 {
+			return atomic.LoadInt32(&runs)
+		}
+		checker := func(ctx context.Context, state *health.CheckState) error {
+			defer atomic.AddInt32(&runs, 1)
+			return state.Update(health.StatusCritical, "down", 0)
+		}
+
+		t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock := hctest.NewFakeClock(t0)
+		hc, err := health.New(health.VersionInfo{}, time.Hour, time.Minute, health.WithClock(clock), health.WithJitter(0))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker, health.WithBackoff(2, 3*time.Minute)), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		// waitForRuns waits for the check to have run the expected number of times, and for the last run to have been
+		// recorded
+		waitForRuns := func(expected int32) {
+			for i := 0; i < 100 && getRuns() < expected; i++ {
+				time.Sleep(5 * time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		advance := func(expected int32) {
+			clock.Advance(time.Minute)
+			waitForRuns(expected)
+		}
+		nextCheck := func() time.Time {
+			return *hc.GetState().Checks[0].State().NextCheck()
+		}
+		waitForRuns(1)
+		advance(2)
+
+		Convey("Then the check runs at its interval until it has failed twice", func() {
+			So(getRuns(), ShouldEqual, 2)
+			So(nextCheck(), ShouldEqual, t0.Add(3*time.Minute))
+		})
+
+		Convey("When the clock is advanced by the interval", func() {
+			advance(3)
+
+			Convey("Then the check is not run until twice its interval has passed", func() {
+				So(getRuns(), ShouldEqual, 2)
+				advance(3)
+				So(getRuns(), ShouldEqual, 3)
+			})
+		})
+
+		Convey("When the check has failed several more times", func() {
+			for _, expected := range []int32{2, 3, 3, 3, 4, 4, 4, 5} {
+				advance(expected)
+				So(getRuns(), ShouldEqual, expected)
+			}
+
+			Convey("Then its interval is capped at the maximum", func() {
+				So(nextCheck(), ShouldEqual, t0.Add(12*time.Minute))
+			})
+		})
+	})
+}
+
+func TestStaggeredStart(t *testing.T) {
+	Convey("Given a health check using a fake clock, with a check whose first run is staggered across its interval", t, func() {
+		var runs int32
+		getRuns := func() int32 {
+			return atomic.LoadInt32(&runs)
+		}
+		checker := func(ctx context.Context, state *health.CheckState) error {
+			defer atomic.AddInt32(&runs, 1)
+			return state.Update(health.StatusOK, "", 0)
+		}
+
+		t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock := hctest.NewFakeClock(t0)
+		hc, err := health.New(health.VersionInfo{}, time.Hour, time.Minute, health.WithClock(clock), health.WithJitter(0),
+			health.WithStaggeredStart(1))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+
+		Convey("When the health check is started", func() {
+			hc.Start(context.Background())
+			defer hc.Stop()
+			time.Sleep(50 * time.Millisecond)
+
+			Convey("Then the check is not run straight away", func() {
+				So(getRuns(), ShouldEqual, 0)
+			})
+
+			Convey("Then the check is next due to run within its interval", func() {
+				next, ok := hc.NextRun("check 1")
+				So(ok, ShouldBeTrue)
+				So(next, ShouldHappenOnOrBetween, t0, t0.Add(time.Minute))
+			})
+
+			Convey("When the clock is advanced by the interval", func() {
+				first, _ := hc.NextRun("check 1")
+				So(waitUntil(func() bool { return clock.Tickers() == 1 }), ShouldBeTrue)
+				clock.Advance(time.Minute)
+
+				Convey("Then the check has run once, and is next due an interval after its staggered first run", func() {
+					So(waitUntil(func() bool { return getRuns() == 1 }), ShouldBeTrue)
+					So(*hc.GetState().Checks[0].State().LastChecked(), ShouldEqual, t0.Add(time.Minute))
+					next, _ := hc.NextRun("check 1")
+					So(next, ShouldEqual, first.Add(time.Minute))
+				})
+			})
+		})
+	})
+}
+
+func TestJitterOnEachTick(t *testing.T) {
+	Convey("Given a started health check using a fake clock, with jitter", t, func() {
+		var runs int32
+		getRuns := func() int32 {
+			return atomic.LoadInt32(&runs)
+		}
+		checker := func(ctx context.Context, state *health.CheckState) error {
+			defer atomic.AddInt32(&runs, 1)
+			return state.Update(health.StatusOK, "", 0)
+		}
+
+		t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+		clock := hctest.NewFakeClock(t0)
+		hc, err := health.New(health.VersionInfo{}, time.Hour, time.Minute, health.WithClock(clock), health.WithJitter(0.5))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		So(waitUntil(func() bool { return getRuns() == 1 }), ShouldBeTrue)
+
+		Convey("When the clock is advanced to each next run of the check", func() {
+			var intervals []time.Duration
+			previous := t0
+			for i := int32(2); i <= 4; i++ {
+				next, ok := hc.NextRun("check 1")
+				So(ok, ShouldBeTrue)
+				intervals = append(intervals, next.Sub(previous))
+				previous = next
+
+				So(waitUntil(func() bool { return clock.Tickers() == 1 }), ShouldBeTrue)
+				clock.Advance(next.Sub(clock.Now()))
+				So(waitUntil(func() bool { return getRuns() == i }), ShouldBeTrue)
+				So(*hc.GetState().Checks[0].State().LastChecked(), ShouldEqual, next)
+			}
+
+			Convey("Then each interval is jittered afresh", func() {
+				for _, interval := range intervals {
+					So(interval, ShouldBeBetweenOrEqual, 30*time.Second, 90*time.Second)
+				}
+				So(intervals[0] == intervals[1] && intervals[1] == intervals[2], ShouldBeFalse)
+			})
+		})
+	})
+}
+
+// waitUntil polls the provided condition for up to a second, returning whether it became true
+func waitUntil(condition func() bool) bool {
+	for i := 0; i < 200 && !condition(); i++ {
+		time.Sleep(5 * time.Millisecond)
+	}
+	return condition()
+}
@@ -0,0 +1,159 @@
+// Package cloudevents reports transitions of the overall health status as CloudEvents
+// (https://github.com/cloudevents/spec), without adding a CloudEvents dependency to the health check library
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+const (
+	// SpecVersion is the version of the CloudEvents specification the events conform to
+	SpecVersion = "1.0"
+
+	// EventType is the type of the event sent when the overall health status changes
+	EventType = "uk.gov.ons.dp.healthcheck.status.changed"
+
+	// ContentType is the media type of a CloudEvent in the structured JSON format
+	ContentType = "application/cloudevents+json; charset=utf-8"
+)
+
+// Event represents a CloudEvent in the structured JSON format, with the health check as its data
+type Event struct {
+	SpecVersion     string             `json:"specversion"`
+	ID              string             `json:"id"`
+	Source          string             `json:"source"`
+	Type            string             `json:"type"`
+	Time            time.Time          `json:"time"`
+	DataContentType string             `json:"datacontenttype"`
+	Data            health.HealthCheck `json:"data"`
+}
+
+// Sender sends an event to an event bus
+type Sender interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// Option configures optional behaviour of a reporter
+type Option func(*reporter)
+
+// WithLogger configures the logger used for events that fail to be created or sent, which by default are logged with
+// healthcheck.DefaultLogger
+func WithLogger(logger health.Logger) Option {
+	return func(r *reporter) {
+		r.logger = logger
+	}
+}
+
+// reporter sends a CloudEvent for each transition of the overall health status
+type reporter struct {
+	source string
+	sender Sender
+	logger health.Logger
+}
+
+// NewReporter returns a status listener that sends a CloudEvent using the provided sender whenever the overall
+// health status changes. The source identifies the app in the events, e.g. "/dp/dataset-api".
+func NewReporter(source string, sender Sender, opts ...Option) health.StatusListener {
+	r := &reporter{
+		source: source,
+		sender: sender,
+		logger: health.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r.onStatusChange
+}
+
+// onStatusChange sends a CloudEvent for the status change
+func (r *reporter) onStatusChange(ctx context.Context, change health.StatusChange, hc health.HealthCheck) {
+	event, err := NewEvent(r.source, change, hc)
+	if err != nil {
+		r.logger(ctx, "failed to create health status cloudevent", err, nil)
+		return
+	}
+
+	if err := r.sender.Send(ctx, event); err != nil {
+		r.logger(ctx, "failed to send health status cloudevent", err, map[string]interface{}{"event_id": event.ID})
+	}
+}
+
+// NewEvent returns a CloudEvent wrapping the health check for the provided status change
+func NewEvent(source string, change health.StatusChange, hc health.HealthCheck) (Event, error) {
+	id, err := newID()
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		SpecVersion:     SpecVersion,
+		ID:              id,
+		Source:          source,
+		Type:            EventType,
+		Time:            change.Time,
+		DataContentType: "application/json",
+		Data:            hc,
+	}, nil
+}
+
+// newID returns a random identifier for an event
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// HTTPSender sends events to an HTTP endpoint in the structured JSON format
+type HTTPSender struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPSender returns a sender that POSTs events to the provided URL using the provided client,
+// or the default client if nil
+func NewHTTPSender(url string, client *http.Client) *HTTPSender {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPSender{
+		URL:    url,
+		Client: client,
+	}
+}
+
+// Send POSTs the event, returning an error if it is not accepted
+func (s *HTTPSender) Send(ctx context.Context, event Event) error {
+	b, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+
+	resp, err := s.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code sending event: %d", resp.StatusCode)
+	}
+	return nil
+}
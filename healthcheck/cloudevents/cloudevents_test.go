@@ -0,0 +1,136 @@
+package cloudevents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// senderMock records the events it is asked to send
+type senderMock struct {
+	events []Event
+	err    error
+}
+
+func (s *senderMock) Send(ctx context.Context, event Event) error {
+	s.events = append(s.events, event)
+	return s.err
+}
+
+func TestReporter(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC()
+	change := health.StatusChange{Previous: health.StatusOK, Current: health.StatusCritical, Time: now}
+	hc := health.HealthCheck{Status: health.StatusCritical, Checks: []*health.Check{}}
+
+	Convey("Given a reporter with a sender", t, func() {
+		sender := &senderMock{}
+		reporter := NewReporter("/dp/some-app", sender)
+
+		Convey("When the overall status changes", func() {
+			reporter(ctx, change, hc)
+
+			Convey("Then a cloudevent wrapping the health check is sent", func() {
+				So(len(sender.events), ShouldEqual, 1)
+				event := sender.events[0]
+				So(event.SpecVersion, ShouldEqual, SpecVersion)
+				So(event.ID, ShouldNotBeEmpty)
+				So(event.Source, ShouldEqual, "/dp/some-app")
+				So(event.Type, ShouldEqual, EventType)
+				So(event.Time, ShouldEqual, now)
+				So(event.DataContentType, ShouldEqual, "application/json")
+				So(event.Data.Status, ShouldEqual, health.StatusCritical)
+			})
+		})
+	})
+
+	Convey("Given a reporter with a sender that fails", t, func() {
+		sender := &senderMock{err: errors.New("bus unavailable")}
+		reporter := NewReporter("/dp/some-app", sender)
+
+		Convey("Then reporting a status change does not panic", func() {
+			So(func() { reporter(ctx, change, hc) }, ShouldNotPanic)
+			So(len(sender.events), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Given a reporter with a logger and a sender that fails", t, func() {
+		sender := &senderMock{err: errors.New("bus unavailable")}
+		var logged []error
+		logger := func(ctx context.Context, event string, err error, data map[string]interface{}) {
+			logged = append(logged, err)
+		}
+		reporter := NewReporter("/dp/some-app", sender, WithLogger(logger))
+
+		Convey("When the overall status changes", func() {
+			reporter(ctx, change, hc)
+
+			Convey("Then the failure is logged with the logger", func() {
+				So(logged, ShouldHaveLength, 1)
+				So(logged[0] == sender.err, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given two events created for the same change", t, func() {
+		event1, err1 := NewEvent("/dp/some-app", change, hc)
+		event2, err2 := NewEvent("/dp/some-app", change, hc)
+
+		Convey("Then their IDs are unique", func() {
+			So(err1, ShouldBeNil)
+			So(err2, ShouldBeNil)
+			So(event1.ID, ShouldNotEqual, event2.ID)
+		})
+	})
+}
+
+func TestHTTPSender(t *testing.T) {
+	ctx := context.Background()
+	hc := health.HealthCheck{Status: health.StatusOK, Checks: []*health.Check{}}
+	event, _ := NewEvent("/dp/some-app", health.StatusChange{Current: health.StatusOK, Time: time.Now().UTC()}, hc)
+
+	Convey("Given an event bus that accepts events", t, func() {
+		var contentType string
+		var body map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			contentType = req.Header.Get("Content-Type")
+			b, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(b, &body)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+		defer server.Close()
+
+		Convey("When an event is sent", func() {
+			err := NewHTTPSender(server.URL, nil).Send(ctx, event)
+
+			Convey("Then the event is posted in the structured JSON format", func() {
+				So(err, ShouldBeNil)
+				So(contentType, ShouldEqual, ContentType)
+				So(body["specversion"], ShouldEqual, SpecVersion)
+				So(body["type"], ShouldEqual, EventType)
+				So(body["id"], ShouldEqual, event.ID)
+				So(body["data"].(map[string]interface{})["status"], ShouldEqual, health.StatusOK)
+			})
+		})
+	})
+
+	Convey("Given an event bus that rejects events", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		Convey("Then sending an event returns an error", func() {
+			err := NewHTTPSender(server.URL, nil).Send(ctx, event)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,37 @@
+package healthcheck
+
+import "net/http"
+
+// StatusCodes sets the HTTP status code returned by the health handler for each status, for platforms that expect
+// different semantics, e.g. a load balancer that only takes an app out of service on 503. Any status without a code
+// uses the code of DefaultStatusCodes.
+type StatusCodes struct {
+	OK       int
+	Warning  int
+	Critical int
+}
+
+// DefaultStatusCodes are the HTTP status codes returned by the health handler unless configured otherwise
+var DefaultStatusCodes = StatusCodes{
+	OK:       http.StatusOK,
+	Warning:  http.StatusTooManyRequests,
+	Critical: http.StatusInternalServerError,
+}
+
+// code returns the HTTP status code configured for the provided status
+func (c StatusCodes) code(status string) int {
+	var code, defaultCode int
+	switch status {
+	case StatusOK:
+		code, defaultCode = c.OK, DefaultStatusCodes.OK
+	case StatusWarning:
+		code, defaultCode = c.Warning, DefaultStatusCodes.Warning
+	default:
+		code, defaultCode = c.Critical, DefaultStatusCodes.Critical
+	}
+
+	if code == 0 {
+		return defaultCode
+	}
+	return code
+}
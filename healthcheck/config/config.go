@@ -0,0 +1,174 @@
+// Package config constructs a health check from configuration, so that an app can declare its health check, and the
+// built-in checkers it uses, in its environment rather than in code, and so that the intervals and timeouts of health
+// checks can be standardised across apps.
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck/checks"
+)
+
+// The types of built-in checker that can be configured
+const (
+	// TypeHTTP checks that a GET request to the target URL responds with a status code in the configured range
+	TypeHTTP = "http"
+	// TypeTCP checks that a TCP connection can be made to the target address
+	TypeTCP = "tcp"
+	// TypeDiskSpace checks the space available on the filesystem containing the target path
+	TypeDiskSpace = "disk"
+	// TypeWritableDir checks that a file can be written to the target directory
+	TypeWritableDir = "dir"
+	// TypeProxy checks that the outbound proxy at the target URL is up
+	TypeProxy = "proxy"
+)
+
+// Config is the configuration of a health check
+type Config struct {
+	Interval        time.Duration
+	CriticalTimeout time.Duration
+	// Checks configures checks by name. An entry with a type adds a built-in checker of that type to the health check,
+	// while an entry without a type configures a check added in code, via CheckOptions.
+	Checks map[string]CheckConfig
+}
+
+// CheckConfig is the configuration of a single check. Any setting that is not set leaves the default of the health
+// check in place.
+type CheckConfig struct {
+	// Type is the type of built-in checker to add, if any, e.g. TypeHTTP
+	Type string
+	// Target is the URL, address or path checked by a built-in checker
+	Target string
+
+	Interval        time.Duration
+	Timeout         time.Duration
+	CriticalTimeout time.Duration
+	Retries         int
+	RetryBackoff    time.Duration
+	NonCritical     bool
+	Informational   bool
+	Group           string
+
+	// MinStatus and MaxStatus are the inclusive range of response status codes reported as OK by an HTTP checker,
+	// which is 200 to 299 if not set
+	MinStatus int
+	MaxStatus int
+	// WarnBelow and CritBelow are the numbers of bytes available below which a disk space checker reports WARNING and
+	// CRITICAL
+	WarnBelow uint64
+	CritBelow uint64
+}
+
+// NewFromConfig returns a new health check configured by the provided config, with the provided version information
+// and options, as New does, along with a check for each built-in checker in the config. The built-in checks are added
+// in order of name. An error is returned if the health check is invalid, as for New, or if a built-in checker has an
+// unknown type or no target.
+func NewFromConfig(version health.VersionInfo, cfg Config, opts ...health.Option) (health.HealthCheck, error) {
+	hc, err := health.New(version, cfg.CriticalTimeout, cfg.Interval, opts...)
+	if err != nil {
+		return hc, err
+	}
+
+	names := make([]string, 0, len(cfg.Checks))
+	for name, check := range cfg.Checks {
+		if check.Type != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		checker, err := newChecker(name, cfg.Checks[name])
+		if err != nil {
+			return health.HealthCheck{}, err
+		}
+		if err := hc.AddCheck(name, checker, cfg.CheckOptions(name)...); err != nil {
+			return health.HealthCheck{}, err
+		}
+	}
+	return hc, nil
+}
+
+// CheckOptions returns the options configuring the check with the provided name, to be passed to AddCheck for a check
+// added in code, e.g. hc.AddCheck("mongoDB", mongo.Checker, cfg.CheckOptions("mongoDB")...). A check configured in the
+// environment is found by the name used in its environment variables, e.g. MONGODB for "mongoDB".
+func (cfg Config) CheckOptions(name string) []health.CheckOption {
+	check, ok := cfg.Checks[name]
+	if !ok {
+		if check, ok = cfg.Checks[EnvName(name)]; !ok {
+			return nil
+		}
+	}
+	return check.options()
+}
+
+// options returns the options configuring a check according to the config
+func (c CheckConfig) options() []health.CheckOption {
+	var opts []health.CheckOption
+	if c.Interval > 0 {
+		opts = append(opts, health.WithInterval(c.Interval))
+	}
+	if c.Timeout > 0 {
+		opts = append(opts, health.WithTimeout(c.Timeout))
+	}
+	if c.CriticalTimeout > 0 {
+		opts = append(opts, health.WithCriticalTimeout(c.CriticalTimeout))
+	}
+	if c.Retries > 0 {
+		opts = append(opts, health.WithRetries(c.Retries, c.RetryBackoff))
+	}
+	if c.NonCritical {
+		opts = append(opts, health.WithNonCritical())
+	}
+	if c.Informational {
+		opts = append(opts, health.WithInformational())
+	}
+	if c.Group != "" {
+		opts = append(opts, health.WithGroup(c.Group))
+	}
+	return opts
+}
+
+// newChecker returns the built-in checker configured for the check with the provided name
+func newChecker(name string, c CheckConfig) (health.Checker, error) {
+	if c.Target == "" {
+		return nil, fmt.Errorf("no target configured for %s check %s", c.Type, name)
+	}
+
+	switch c.Type {
+	case TypeHTTP:
+		minStatus, maxStatus := c.MinStatus, c.MaxStatus
+		if minStatus == 0 && maxStatus == 0 {
+			minStatus, maxStatus = http.StatusOK, 299
+		}
+		return checks.NewHTTPChecker(name, c.Target, nil, minStatus, maxStatus), nil
+	case TypeTCP:
+		return checks.NewTCPChecker(name, c.Target), nil
+	case TypeDiskSpace:
+		return newDiskSpaceChecker(name, c)
+	case TypeWritableDir:
+		return checks.NewWritableDirChecker(name, c.Target), nil
+	case TypeProxy:
+		return checks.NewProxyChecker(name, c.Target, nil), nil
+	default:
+		return nil, fmt.Errorf("unknown type %s for check %s", c.Type, name)
+	}
+}
+
+// EnvName returns the name used for the check with the provided name in environment variables, which is the name in
+// upper case with each character that is not a letter or digit replaced by an underscore, e.g. ZEBEDEE_API for
+// "zebedee API"
+func EnvName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var version = health.VersionInfo{
+	BuildTime:       time.Unix(0, 0),
+	GitCommit:       "d6cd1e2bd19e03a81132a23b2025920577f84e37",
+	Language:        "go",
+	LanguageVersion: "1.12",
+	Version:         "1.0.0",
+}
+
+func TestNewFromConfig(t *testing.T) {
+	Convey("Given a config with an HTTP, a TCP and a writable directory check", t, func() {
+		endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer endpoint.Close()
+
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer listener.Close()
+
+		dir, err := ioutil.TempDir("", "config")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+
+		cfg := Config{
+			Interval:        time.Hour,
+			CriticalTimeout: time.Hour,
+			Checks: map[string]CheckConfig{
+				"search API": {Type: TypeHTTP, Target: endpoint.URL, Group: "apis"},
+				"kafka":      {Type: TypeTCP, Target: listener.Addr().String(), NonCritical: true},
+				"uploads":    {Type: TypeWritableDir, Target: dir},
+				"mongoDB":    {Timeout: time.Second},
+			},
+		}
+
+		Convey("When a health check is created from the config and its checks are run", func() {
+			hc, err := NewFromConfig(version, cfg)
+			So(err, ShouldBeNil)
+			defer hc.Stop()
+			hc.Tick(context.Background())
+
+			Convey("Then a check is added for each built-in checker in order of name", func() {
+				So(hc.Checks, ShouldHaveLength, 3)
+				So(hc.Checks[0].State().Name(), ShouldEqual, "kafka")
+				So(hc.Checks[1].State().Name(), ShouldEqual, "search API")
+				So(hc.Checks[2].State().Name(), ShouldEqual, "uploads")
+			})
+
+			Convey("Then each check reports the health of its target", func() {
+				for _, check := range hc.Checks {
+					So(check.State().Status(), ShouldEqual, health.StatusOK)
+				}
+			})
+		})
+	})
+
+	Convey("Given a config with a check of an unknown type", t, func() {
+		cfg := Config{
+			Interval:        time.Hour,
+			CriticalTimeout: time.Hour,
+			Checks:          map[string]CheckConfig{"ftp": {Type: "ftp", Target: "ftp://localhost"}},
+		}
+
+		Convey("Then creating a health check from the config returns an error", func() {
+			_, err := NewFromConfig(version, cfg)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "unknown type ftp for check ftp")
+		})
+	})
+
+	Convey("Given a config with a built-in check without a target", t, func() {
+		cfg := Config{
+			Interval:        time.Hour,
+			CriticalTimeout: time.Hour,
+			Checks:          map[string]CheckConfig{"kafka": {Type: TypeTCP}},
+		}
+
+		Convey("Then creating a health check from the config returns an error", func() {
+			_, err := NewFromConfig(version, cfg)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "no target configured for tcp check kafka")
+		})
+	})
+
+	Convey("Given a config with an invalid interval", t, func() {
+		cfg := Config{Interval: 0, CriticalTimeout: time.Hour}
+
+		Convey("Then creating a health check from the config returns the error from New", func() {
+			_, err := NewFromConfig(version, cfg)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestCheckOptions(t *testing.T) {
+	Convey("Given a config with settings for checks added in code", t, func() {
+		cfg := Config{
+			Checks: map[string]CheckConfig{
+				"mongoDB":     {Interval: time.Minute, Timeout: time.Second, Retries: 2, RetryBackoff: time.Second},
+				"ZEBEDEE_API": {Informational: true, Group: "apis"},
+			},
+		}
+
+		Convey("Then the options of a check are found by its name", func() {
+			So(cfg.CheckOptions("mongoDB"), ShouldHaveLength, 3)
+		})
+
+		Convey("Then the options of a check are found by its name in the environment", func() {
+			So(cfg.CheckOptions("zebedee API"), ShouldHaveLength, 2)
+		})
+
+		Convey("Then no options are returned for a check that is not configured", func() {
+			So(cfg.CheckOptions("elasticsearch"), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestEnvName(t *testing.T) {
+	Convey("The name of a check in the environment is upper case with only letters, digits and underscores", t, func() {
+		So(EnvName("zebedee API"), ShouldEqual, "ZEBEDEE_API")
+		So(EnvName("dp-topic-api"), ShouldEqual, "DP_TOPIC_API")
+		So(EnvName("mongoDB2"), ShouldEqual, "MONGODB2")
+	})
+}
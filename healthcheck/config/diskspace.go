@@ -0,0 +1,14 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package config
+
+import (
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck/checks"
+)
+
+// newDiskSpaceChecker returns the disk space checker configured for the check with the provided name
+func newDiskSpaceChecker(name string, c CheckConfig) (health.Checker, error) {
+	return checks.NewDiskSpaceChecker(name, c.Target, c.WarnBelow, c.CritBelow), nil
+}
@@ -0,0 +1,15 @@
+//go:build windows || plan9
+// +build windows plan9
+
+package config
+
+import (
+	"fmt"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// newDiskSpaceChecker returns an error, as the disk space checker is not available on Windows or Plan 9
+func newDiskSpaceChecker(name string, c CheckConfig) (health.Checker, error) {
+	return nil, fmt.Errorf("%s check %s is not available on this platform", c.Type, name)
+}
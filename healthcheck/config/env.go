@@ -0,0 +1,207 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The environment variables configuring a health check
+const (
+	envInterval        = "HEALTHCHECK_INTERVAL"
+	envCriticalTimeout = "HEALTHCHECK_CRITICAL_TIMEOUT"
+	// envCheckPrefix prefixes the environment variables configuring each check, which are named
+	// HEALTHCHECK_CHECK_<name>_<setting>
+	envCheckPrefix = "HEALTHCHECK_CHECK_"
+)
+
+// checkSettings parse the value of each setting of a check from the environment into the config of the check
+var checkSettings = map[string]func(c *CheckConfig, value string) error{
+	"NAME":             func(c *CheckConfig, value string) error { return nil },
+	"TYPE":             func(c *CheckConfig, value string) error { c.Type = value; return nil },
+	"TARGET":           func(c *CheckConfig, value string) error { c.Target = value; return nil },
+	"INTERVAL":         durationSetting(func(c *CheckConfig) *time.Duration { return &c.Interval }),
+	"TIMEOUT":          durationSetting(func(c *CheckConfig) *time.Duration { return &c.Timeout }),
+	"CRITICAL_TIMEOUT": durationSetting(func(c *CheckConfig) *time.Duration { return &c.CriticalTimeout }),
+	"RETRY_BACKOFF":    durationSetting(func(c *CheckConfig) *time.Duration { return &c.RetryBackoff }),
+	"RETRIES":          intSetting(func(c *CheckConfig) *int { return &c.Retries }),
+	"MIN_STATUS":       intSetting(func(c *CheckConfig) *int { return &c.MinStatus }),
+	"MAX_STATUS":       intSetting(func(c *CheckConfig) *int { return &c.MaxStatus }),
+	"WARN_BELOW":       uintSetting(func(c *CheckConfig) *uint64 { return &c.WarnBelow }),
+	"CRIT_BELOW":       uintSetting(func(c *CheckConfig) *uint64 { return &c.CritBelow }),
+	"NON_CRITICAL":     boolSetting(func(c *CheckConfig) *bool { return &c.NonCritical }),
+	"INFORMATIONAL":    boolSetting(func(c *CheckConfig) *bool { return &c.Informational }),
+	"GROUP":            func(c *CheckConfig, value string) error { c.Group = value; return nil },
+}
+
+// FromEnv returns the provided default config overridden by any settings in environment variables, so that an app
+// can standardise its health check in code and adjust it per environment:
+//
+//	HEALTHCHECK_INTERVAL and HEALTHCHECK_CRITICAL_TIMEOUT the interval and critical timeout, e.g. 30s
+//	HEALTHCHECK_CHECK_<name>_<setting> a setting of the check with the provided name, as returned by EnvName
+//
+// The settings of a check are NAME, TYPE, TARGET, INTERVAL, TIMEOUT, CRITICAL_TIMEOUT, RETRIES, RETRY_BACKOFF,
+// NON_CRITICAL, INFORMATIONAL, GROUP, MIN_STATUS, MAX_STATUS, WARN_BELOW and CRIT_BELOW, matching the fields of
+// CheckConfig. The settings of a check in the environment override those of the check in the default config whose
+// name has the same EnvName. A check that is not in the default config is added using NAME as its name, or the name
+// in the environment variables if NAME is not set. An error is returned if a setting is unknown or its value invalid.
+func FromEnv(defaults Config) (Config, error) {
+	return fromEnv(defaults, os.Environ())
+}
+
+// fromEnv returns the provided default config overridden by the settings in the provided environment, which holds
+// variables in the form key=value
+func fromEnv(cfg Config, environ []string) (Config, error) {
+	vars := make(map[string]string)
+	for _, v := range environ {
+		if parts := strings.SplitN(v, "=", 2); len(parts) == 2 && strings.HasPrefix(parts[0], "HEALTHCHECK_") {
+			vars[parts[0]] = parts[1]
+		}
+	}
+
+	var err error
+	if value, ok := vars[envInterval]; ok {
+		if cfg.Interval, err = parseDuration(envInterval, value); err != nil {
+			return Config{}, err
+		}
+	}
+	if value, ok := vars[envCriticalTimeout]; ok {
+		if cfg.CriticalTimeout, err = parseDuration(envCriticalTimeout, value); err != nil {
+			return Config{}, err
+		}
+	}
+
+	// the settings of each check are grouped by the name of the check in the environment variables
+	settings := make(map[string]map[string]string)
+	for key, value := range vars {
+		if !strings.HasPrefix(key, envCheckPrefix) {
+			continue
+		}
+		envName, setting, ok := splitCheckSetting(strings.TrimPrefix(key, envCheckPrefix))
+		if !ok {
+			return Config{}, fmt.Errorf("unknown health check setting %s", key)
+		}
+		if settings[envName] == nil {
+			settings[envName] = make(map[string]string)
+		}
+		settings[envName][setting] = value
+	}
+
+	checks := make(map[string]CheckConfig, len(cfg.Checks)+len(settings))
+	for name, check := range cfg.Checks {
+		checks[name] = check
+	}
+
+	envNames := make([]string, 0, len(settings))
+	for envName := range settings {
+		envNames = append(envNames, envName)
+	}
+	sort.Strings(envNames)
+
+	for _, envName := range envNames {
+		name := checkName(cfg, envName, settings[envName])
+		check := checks[name]
+		for setting, value := range settings[envName] {
+			if err := checkSettings[setting](&check, value); err != nil {
+				return Config{}, fmt.Errorf("invalid value %q for %s%s_%s: %s", value, envCheckPrefix, envName, setting, err)
+			}
+		}
+		checks[name] = check
+	}
+
+	if len(checks) > 0 {
+		cfg.Checks = checks
+	}
+	return cfg, nil
+}
+
+// splitCheckSetting splits the provided environment variable name, without its prefix, into the name of the check and
+// the setting, returning false if it does not end with a known setting
+func splitCheckSetting(key string) (envName, setting string, ok bool) {
+	// the longest setting is matched first, as a setting may end with another, e.g. CRITICAL_TIMEOUT and TIMEOUT
+	var match string
+	for s := range checkSettings {
+		if strings.HasSuffix(key, "_"+s) && len(s) > len(match) {
+			match = s
+		}
+	}
+	if match == "" || len(key) == len(match)+1 {
+		return "", "", false
+	}
+	return strings.TrimSuffix(key, "_"+match), match, true
+}
+
+// checkName returns the name of the check whose settings in the environment are those provided, which is the name of
+// the check in the default config with the same name in the environment, if any, otherwise its NAME setting or its
+// name in the environment
+func checkName(cfg Config, envName string, settings map[string]string) string {
+	for name := range cfg.Checks {
+		if EnvName(name) == envName {
+			return name
+		}
+	}
+	if name, ok := settings["NAME"]; ok && name != "" {
+		return name
+	}
+	return envName
+}
+
+// parseDuration parses the duration held by the environment variable with the provided name
+func parseDuration(key, value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q for %s: %s", value, key, err)
+	}
+	return d, nil
+}
+
+// durationSetting returns a function that parses a duration into the field returned by the provided function
+func durationSetting(field func(c *CheckConfig) *time.Duration) func(c *CheckConfig, value string) error {
+	return func(c *CheckConfig, value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+// intSetting returns a function that parses an integer into the field returned by the provided function
+func intSetting(field func(c *CheckConfig) *int) func(c *CheckConfig, value string) error {
+	return func(c *CheckConfig, value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = i
+		return nil
+	}
+}
+
+// uintSetting returns a function that parses an unsigned integer into the field returned by the provided function
+func uintSetting(field func(c *CheckConfig) *uint64) func(c *CheckConfig, value string) error {
+	return func(c *CheckConfig, value string) error {
+		u, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		*field(c) = u
+		return nil
+	}
+}
+
+// boolSetting returns a function that parses a boolean into the field returned by the provided function
+func boolSetting(field func(c *CheckConfig) *bool) func(c *CheckConfig, value string) error {
+	return func(c *CheckConfig, value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*field(c) = b
+		return nil
+	}
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFromEnv(t *testing.T) {
+	defaults := Config{
+		Interval:        30 * time.Second,
+		CriticalTimeout: 90 * time.Second,
+		Checks: map[string]CheckConfig{
+			"zebedee API": {Type: TypeHTTP, Target: "http://localhost:8082/health", Group: "apis"},
+		},
+	}
+
+	Convey("Given an environment without health check settings", t, func() {
+		environ := []string{"PATH=/usr/bin", "BIND_ADDR=:8080"}
+
+		Convey("Then the default config is returned", func() {
+			cfg, err := fromEnv(defaults, environ)
+			So(err, ShouldBeNil)
+			So(cfg, ShouldResemble, defaults)
+		})
+	})
+
+	Convey("Given an environment with health check settings", t, func() {
+		environ := []string{
+			"HEALTHCHECK_INTERVAL=10s",
+			"HEALTHCHECK_CRITICAL_TIMEOUT=1m",
+			"HEALTHCHECK_CHECK_ZEBEDEE_API_TARGET=http://zebedee:8082/health",
+			"HEALTHCHECK_CHECK_ZEBEDEE_API_CRITICAL_TIMEOUT=2m",
+			"HEALTHCHECK_CHECK_ZEBEDEE_API_TIMEOUT=5s",
+			"HEALTHCHECK_CHECK_KAFKA_TYPE=tcp",
+			"HEALTHCHECK_CHECK_KAFKA_TARGET=kafka:9092",
+			"HEALTHCHECK_CHECK_KAFKA_NON_CRITICAL=true",
+			"HEALTHCHECK_CHECK_KAFKA_RETRIES=2",
+			"HEALTHCHECK_CHECK_DATA_VOLUME_NAME=data volume",
+			"HEALTHCHECK_CHECK_DATA_VOLUME_TYPE=disk",
+			"HEALTHCHECK_CHECK_DATA_VOLUME_TARGET=/data",
+			"HEALTHCHECK_CHECK_DATA_VOLUME_CRIT_BELOW=1048576",
+		}
+
+		Convey("When the config is read from the environment", func() {
+			cfg, err := fromEnv(defaults, environ)
+			So(err, ShouldBeNil)
+
+			Convey("Then the interval and critical timeout are overridden", func() {
+				So(cfg.Interval, ShouldEqual, 10*time.Second)
+				So(cfg.CriticalTimeout, ShouldEqual, time.Minute)
+			})
+
+			Convey("Then the settings of a default check are overridden, leaving its other settings in place", func() {
+				So(cfg.Checks["zebedee API"], ShouldResemble, CheckConfig{
+					Type:            TypeHTTP,
+					Target:          "http://zebedee:8082/health",
+					Timeout:         5 * time.Second,
+					CriticalTimeout: 2 * time.Minute,
+					Group:           "apis",
+				})
+			})
+
+			Convey("Then a check that is not in the default config is added by its name in the environment", func() {
+				So(cfg.Checks["KAFKA"], ShouldResemble, CheckConfig{
+					Type:        TypeTCP,
+					Target:      "kafka:9092",
+					NonCritical: true,
+					Retries:     2,
+				})
+			})
+
+			Convey("Then a check with a name setting is added by that name", func() {
+				So(cfg.Checks["data volume"], ShouldResemble, CheckConfig{
+					Type:      TypeDiskSpace,
+					Target:    "/data",
+					CritBelow: 1048576,
+				})
+				So(cfg.Checks, ShouldNotContainKey, "DATA_VOLUME")
+			})
+
+			Convey("Then the default config is left unchanged", func() {
+				So(defaults.Checks["zebedee API"].Target, ShouldEqual, "http://localhost:8082/health")
+				So(defaults.Checks, ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given an environment with an invalid interval", t, func() {
+		environ := []string{"HEALTHCHECK_INTERVAL=often"}
+
+		Convey("Then an error is returned", func() {
+			_, err := fromEnv(defaults, environ)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given an environment with an invalid check setting", t, func() {
+		environ := []string{"HEALTHCHECK_CHECK_KAFKA_RETRIES=many"}
+
+		Convey("Then an error is returned", func() {
+			_, err := fromEnv(defaults, environ)
+			So(err, ShouldNotBeNil)
+		})
+	})
+
+	Convey("Given an environment with an unknown check setting", t, func() {
+		environ := []string{"HEALTHCHECK_CHECK_KAFKA_COLOUR=blue"}
+
+		Convey("Then an error is returned", func() {
+			_, err := fromEnv(defaults, environ)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "unknown health check setting HEALTHCHECK_CHECK_KAFKA_COLOUR")
+		})
+	})
+}
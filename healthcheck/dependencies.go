@@ -0,0 +1,44 @@
+package healthcheck
+
+import "fmt"
+
+// skippedMessage is the message recorded when a check is skipped as a check it depends on is critical
+const skippedMessage = "skipped as %s is critical"
+
+// isSkipped returns true if the most recent run of the check was skipped as a check it depends on was critical
+func (c *Check) isSkipped() bool {
+	return c.state.Status() == StatusSkipped
+}
+
+// skip records that the check was skipped at the current time, as the provided check it depends on is critical,
+// leaving its last success and last failure unchanged
+func (s *CheckState) skip(dependency string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := s.now()
+	s.status = StatusSkipped
+	s.message = fmt.Sprintf(skippedMessage, dependency)
+	s.statusCode = 0
+	s.lastChecked = &now
+}
+
+// failedDependency returns the name of the first check that the provided check depends on that is critical, or an
+// empty string if there is none. A dependency that is not a check of the health check is ignored.
+func (hc *HealthCheck) failedDependency(check *Check) string {
+	if len(check.dependsOn) == 0 {
+		return ""
+	}
+
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	for _, name := range check.dependsOn {
+		for _, c := range hc.Checks {
+			if c.state.Name() == name && c.state.Status() == StatusCritical {
+				return name
+			}
+		}
+	}
+	return ""
+}
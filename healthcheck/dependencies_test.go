@@ -0,0 +1,124 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDependsOn(t *testing.T) {
+	Convey("Given a health check with a search API check that depends on an elasticsearch check", t, func() {
+		var (
+			elasticsearch = StatusOK
+			searchRuns    int32
+		)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("elasticsearch", func(ctx context.Context, state *CheckState) error {
+			return state.Update(elasticsearch, "", 0)
+		}), ShouldBeNil)
+		So(hc.AddCheck("search API", func(ctx context.Context, state *CheckState) error {
+			atomic.AddInt32(&searchRuns, 1)
+			return state.Update(elasticsearch, "", 0)
+		}, WithDependsOn("elasticsearch"), WithGroup("search")), ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+
+		// each check is run in turn, so that the search API check sees the result of the elasticsearch check
+		run := func() {
+			for _, tkr := range hc.tickers {
+				wg := &sync.WaitGroup{}
+				wg.Add(1)
+				tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+			}
+		}
+		search := hc.Checks[1]
+		run()
+
+		Convey("When elasticsearch is critical", func() {
+			elasticsearch = StatusCritical
+			run()
+
+			Convey("Then the search API check is skipped rather than run", func() {
+				So(atomic.LoadInt32(&searchRuns), ShouldEqual, 1)
+				So(search.state.Status(), ShouldEqual, StatusSkipped)
+				So(search.state.Message(), ShouldEqual, "skipped as elasticsearch is critical")
+				So(search.state.LastChecked(), ShouldNotBeNil)
+			})
+
+			Convey("Then the last success of the search API check is unchanged", func() {
+				So(search.state.LastSuccess(), ShouldNotBeNil)
+				So(search.state.LastFailure(), ShouldBeNil)
+			})
+
+			Convey("Then the skipped check does not contribute to the status of its group", func() {
+				hc.mutex.Lock()
+				groups := hc.groupStatuses()
+				hc.mutex.Unlock()
+				So(groups, ShouldBeNil)
+			})
+
+			Convey("When elasticsearch recovers", func() {
+				elasticsearch = StatusOK
+				run()
+
+				Convey("Then the search API check is run again", func() {
+					So(atomic.LoadInt32(&searchRuns), ShouldEqual, 2)
+					So(search.state.Status(), ShouldEqual, StatusOK)
+				})
+			})
+		})
+
+		Convey("When elasticsearch is warning", func() {
+			elasticsearch = StatusWarning
+			run()
+
+			Convey("Then the search API check is still run", func() {
+				So(atomic.LoadInt32(&searchRuns), ShouldEqual, 2)
+				So(search.state.Status(), ShouldEqual, StatusWarning)
+			})
+		})
+	})
+
+	Convey("Given a health check with an OK check and a check that has been skipped", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("elasticsearch", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "", 0)
+		}), ShouldBeNil)
+		So(hc.AddCheck("search API", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "", 0)
+		}, WithDependsOn("elasticsearch")), ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+		hc.Tick(context.Background())
+		hc.Checks[1].state.skip("elasticsearch")
+
+		Convey("Then the skipped check does not contribute to the overall status", func() {
+			So(hc.GetStatus(context.Background()), ShouldEqual, StatusOK)
+		})
+	})
+
+	Convey("Given a check that depends on a check that does not exist", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("search API", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "", 0)
+		}, WithDependsOn("elasticsearch")), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+
+		Convey("Then the check is run as normal", func() {
+			hc.Tick(context.Background())
+			So(hc.Checks[0].state.Status(), ShouldEqual, StatusOK)
+		})
+	})
+}
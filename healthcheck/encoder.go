@@ -0,0 +1,103 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Encoder encodes the health check for the response of the health handler, allowing the wire format to be
+// changed (e.g. to protobuf) without changing the handler
+type Encoder interface {
+	// ContentType returns the media type of the encoded health check, used as the Content-Type of the response
+	ContentType() string
+	// Encode writes the encoded health check to the provided writer
+	Encode(w io.Writer, hc HealthCheck) error
+}
+
+// JSONEncoder encodes the health check as JSON, and is the default encoder used by the health handler
+type JSONEncoder struct{}
+
+// ContentType returns the JSON media type
+func (e JSONEncoder) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+// Encode writes the JSON representation of the health check to the provided writer
+func (e JSONEncoder) Encode(w io.Writer, hc HealthCheck) error {
+	b, err := json.Marshal(hc)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
+
+// HealthJSONEncoder encodes the health check in the application/health+json format described by the IETF health
+// check response format draft, for tooling that expects the standard representation
+type HealthJSONEncoder struct{}
+
+// healthJSON is the application/health+json representation of a health check
+type healthJSON struct {
+	Status    string                       `json:"status"`
+	Version   string                       `json:"version,omitempty"`
+	ReleaseID string                       `json:"releaseId,omitempty"`
+	Output    string                       `json:"output,omitempty"`
+	Checks    map[string][]healthJSONCheck `json:"checks,omitempty"`
+}
+
+// healthJSONCheck is the application/health+json representation of a single check
+type healthJSONCheck struct {
+	ComponentID   string     `json:"componentId"`
+	ObservedValue int        `json:"observedValue,omitempty"`
+	Status        string     `json:"status"`
+	Time          *time.Time `json:"time,omitempty"`
+	Output        string     `json:"output,omitempty"`
+}
+
+// ContentType returns the application/health+json media type
+func (e HealthJSONEncoder) ContentType() string {
+	return "application/health+json; charset=utf-8"
+}
+
+// Encode writes the application/health+json representation of the health check to the provided writer. Checks are
+// keyed by name, with the status code returned by the check as the observed value
+func (e HealthJSONEncoder) Encode(w io.Writer, hc HealthCheck) error {
+	body := healthJSON{
+		Status:    IETFStatusNames.name(hc.Status),
+		Version:   hc.Version.Version,
+		ReleaseID: hc.Version.GitCommit,
+	}
+	// the reason for a forced status is reported as the output of the app
+	if hc.Override != nil {
+		body.Output = hc.Override.Reason
+	}
+
+	if len(hc.Checks) > 0 {
+		body.Checks = make(map[string][]healthJSONCheck, len(hc.Checks))
+	}
+
+	for _, check := range hc.Checks {
+		state := check.state.clone()
+		c := healthJSONCheck{
+			ComponentID:   state.name,
+			ObservedValue: state.statusCode,
+			Status:        IETFStatusNames.name(state.status),
+			Time:          state.lastChecked,
+		}
+		// the draft recommends omitting the output of passing checks
+		if c.Status != IETFStatusNames.OK {
+			c.Output = state.message
+		}
+		body.Checks[state.name] = append(body.Checks[state.name], c)
+	}
+
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(b)
+	return err
+}
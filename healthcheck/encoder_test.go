@@ -0,0 +1,103 @@
+package healthcheck
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestJSONEncoder(t *testing.T) {
+	Convey("Given a health check", t, func() {
+		t0 := time.Unix(0, 0).UTC()
+		hc := HealthCheck{
+			Status:    StatusOK,
+			Version:   testVersion,
+			StartTime: t0,
+			Checks:    []*Check{},
+		}
+		encoder := JSONEncoder{}
+
+		Convey("When the health check is encoded", func() {
+			var b bytes.Buffer
+			err := encoder.Encode(&b, hc)
+
+			Convey("Then the JSON representation of the health check is written", func() {
+				So(err, ShouldBeNil)
+
+				expected, err := json.Marshal(hc)
+				So(err, ShouldBeNil)
+				So(b.String(), ShouldEqual, string(expected))
+			})
+		})
+
+		Convey("Then the content type is JSON", func() {
+			So(encoder.ContentType(), ShouldEqual, "application/json; charset=utf-8")
+		})
+	})
+}
+
+func TestHealthJSONEncoder(t *testing.T) {
+	Convey("Given a health check with a skipped check", t, func() {
+		t0 := time.Unix(0, 0).UTC()
+		skipped := &Check{state: NewCheckState("skipped")}
+		skipped.state.status = StatusSkipped
+		skipped.state.message = "not run as elasticsearch is critical"
+		skipped.state.lastChecked = &t0
+
+		hc := HealthCheck{
+			Status:  StatusOK,
+			Version: testVersion,
+			Checks:  []*Check{skipped},
+		}
+
+		Convey("When the health check is encoded", func() {
+			var b bytes.Buffer
+			So(HealthJSONEncoder{}.Encode(&b, hc), ShouldBeNil)
+
+			Convey("Then the skipped check is reported with a status of the draft, along with the reason it was skipped", func() {
+				So(b.String(), ShouldContainSubstring,
+					`"skipped":[{"componentId":"skipped","status":"warn","time":"1970-01-01T00:00:00Z","output":"not run as elasticsearch is critical"}]`)
+			})
+		})
+	})
+
+	Convey("Given a health check with a passing and a failing check", t, func() {
+		t0 := time.Unix(0, 0).UTC()
+		passing := &Check{state: NewCheckState("passing")}
+		passing.state.status = StatusOK
+		passing.state.statusCode = 200
+		passing.state.message = "all good"
+		passing.state.lastChecked = &t0
+		failing := &Check{state: NewCheckState("failing")}
+		failing.state.status = StatusCritical
+		failing.state.statusCode = 500
+		failing.state.message = "connection refused"
+		failing.state.lastChecked = &t0
+
+		hc := HealthCheck{
+			Status:  StatusCritical,
+			Version: testVersion,
+			Checks:  []*Check{passing, failing},
+		}
+		encoder := HealthJSONEncoder{}
+
+		Convey("When the health check is encoded", func() {
+			var b bytes.Buffer
+			err := encoder.Encode(&b, hc)
+			So(err, ShouldBeNil)
+
+			Convey("Then the health+json representation of the health check is written", func() {
+				So(b.String(), ShouldEqual, `{"status":"fail","version":"1.0.0","releaseId":"d6cd1e2bd19e03a81132a23b2025920577f84e37",`+
+					`"checks":{"failing":[{"componentId":"failing","observedValue":500,"status":"fail","time":"1970-01-01T00:00:00Z","output":"connection refused"}],`+
+					`"passing":[{"componentId":"passing","observedValue":200,"status":"pass","time":"1970-01-01T00:00:00Z"}]}}`)
+			})
+		})
+
+		Convey("Then the content type is health+json", func() {
+			So(encoder.ContentType(), ShouldEqual, "application/health+json; charset=utf-8")
+		})
+	})
+}
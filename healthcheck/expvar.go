@@ -0,0 +1,37 @@
+package healthcheck
+
+import (
+	"expvar"
+	"fmt"
+)
+
+// PublishExpvar publishes the overall status, uptime in seconds and number of unhealthy checks of the health check
+// with the expvar package under the provided name, e.g. "healthcheck", so that they can be read from /debug/vars
+// without the health handler. An error is returned if the name has already been published.
+func (hc *HealthCheck) PublishExpvar(name string) error {
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("expvar already published: %s", name)
+	}
+
+	expvar.Publish(name, expvar.Func(hc.expvarValues))
+	return nil
+}
+
+// expvarValues returns the values published with the expvar package
+func (hc *HealthCheck) expvarValues() interface{} {
+	unhealthy := len(hc.UnhealthyChecks())
+
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	var uptime float64
+	if !hc.StartTime.IsZero() {
+		uptime = hc.uptime(hc.now()).Seconds()
+	}
+
+	return map[string]interface{}{
+		"status":           hc.Status,
+		"uptime_seconds":   uptime,
+		"unhealthy_checks": unhealthy,
+	}
+}
@@ -0,0 +1,43 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	Convey("Given a started Health Check with a critical check", t, func() {
+		cf := func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusCritical, "", 0)
+		}
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		time.Sleep(2 * interval)
+
+		Convey("When the health check is published with expvar", func() {
+			err := hc.PublishExpvar("healthcheck_test")
+
+			Convey("Then the health values can be read from expvar", func() {
+				So(err, ShouldBeNil)
+
+				var values map[string]interface{}
+				So(json.Unmarshal([]byte(expvar.Get("healthcheck_test").String()), &values), ShouldBeNil)
+				So(values["status"], ShouldEqual, StatusWarning)
+				So(values["uptime_seconds"], ShouldBeGreaterThan, 0)
+				So(values["unhealthy_checks"], ShouldEqual, 1)
+			})
+
+			Convey("Then publishing under the same name again returns an error", func() {
+				So(hc.PublishExpvar("healthcheck_test"), ShouldNotBeNil)
+			})
+		})
+	})
+}
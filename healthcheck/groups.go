@@ -0,0 +1,74 @@
+package healthcheck
+
+import "time"
+
+// statusRanks orders the statuses of checks from least to most severe
+var statusRanks = map[string]int{
+	StatusOK:       1,
+	StatusWarning:  2,
+	StatusCritical: 3,
+}
+
+// groupStatuses returns the status of each group of checks, or nil if no check belongs to a group. The status of a
+// group is the most severe status of its checks, where a check that has not yet run is a warning and a non-critical
+// check is at most a warning. As for the overall status, a critical check is a warning until its critical timeout has
+// passed or it has failed the configured number of runs, and within the soft start window. Informational and skipped
+// checks are ignored. Callers must hold the lock.
+func (hc *HealthCheck) groupStatuses() map[string]string {
+	var groups map[string]string
+	now := hc.now()
+	for _, check := range hc.Checks {
+		group := check.state.Group()
+		if group == "" || check.informational || check.isSkipped() {
+			continue
+		}
+
+		status := hc.subsetStatus(check, now)
+		if groups == nil {
+			groups = make(map[string]string)
+		}
+		if statusRanks[status] > statusRanks[groups[group]] {
+			groups[group] = status
+		}
+	}
+	return groups
+}
+
+// subsetStatus returns the status at the provided time of the check when reporting the status of a subset of the
+// checks, such as a group, where a check that has not yet run is a warning and a non-critical check is at most a
+// warning. A critical check is reported by the same rules as for the overall status.
+func (hc *HealthCheck) subsetStatus(c *Check, now time.Time) string {
+	if !c.hasRun() {
+		return StatusWarning
+	}
+
+	status := c.getSeverity()
+	if status != StatusOK && status != StatusWarning {
+		status = hc.getCriticalCheckStatus(c, now)
+	}
+	if c.nonCritical && status == StatusCritical {
+		status = StatusWarning
+	}
+	return status
+}
+
+// withGroup returns a copy of the health check with only the checks in the provided group, reporting the status of
+// the group as the overall status unless a status has been forced with SetOverride, and whether the group exists
+func (hc HealthCheck) withGroup(group string) (HealthCheck, bool) {
+	status, ok := hc.Groups[group]
+	if !ok {
+		return hc, false
+	}
+
+	checks := make([]*Check, 0, len(hc.Checks))
+	for _, check := range hc.Checks {
+		if check.state.Group() == group {
+			checks = append(checks, check)
+		}
+	}
+
+	hc.Status = hc.overrideStatus(status)
+	hc.Groups = map[string]string{group: status}
+	hc.Checks = checks
+	return hc, true
+}
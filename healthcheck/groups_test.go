@@ -0,0 +1,129 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGroups(t *testing.T) {
+	checker := func(status string) Checker {
+		return func(ctx context.Context, state *CheckState) error {
+			return state.Update(status, "", 0)
+		}
+	}
+
+	Convey("Given a health check with checks in a storage group and a messaging group, and a check in no group", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", checker(StatusOK), WithGroup("storage")), ShouldBeNil)
+		So(hc.AddCheck("s3", checker(StatusWarning), WithGroup("storage")), ShouldBeNil)
+		So(hc.AddCheck("kafka", checker(StatusOK), WithGroup("messaging")), ShouldBeNil)
+		So(hc.AddCheck("cache", checker(StatusCritical), WithGroup("messaging"), WithNonCritical()), ShouldBeNil)
+		So(hc.AddCheck("zebedee", checker(StatusOK)), ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+		hc.Tick(context.Background())
+
+		Convey("Then the status of each group is the most severe status of its checks, with non-critical checks at most a warning", func() {
+			So(hc.Groups, ShouldResemble, map[string]string{"storage": StatusWarning, "messaging": StatusWarning})
+		})
+
+		Convey("When the health handler is called", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+
+			Convey("Then the response includes the status of each group alongside every check, and the group of each check", func() {
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Groups, ShouldResemble, map[string]string{"storage": StatusWarning, "messaging": StatusWarning})
+				So(response.Checks, ShouldHaveLength, 5)
+				So(response.Checks[0].state.Group(), ShouldEqual, "storage")
+				So(response.Checks[4].state.Group(), ShouldEqual, "")
+			})
+		})
+
+		Convey("When the health handler is called for the messaging group, whose critical check has just failed", func() {
+			hc.Checks[3].nonCritical = false
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?group=messaging", nil))
+
+			Convey("Then the response only includes the checks of the group, with the status of the group", func() {
+				So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Status, ShouldEqual, StatusWarning)
+				So(response.Groups, ShouldResemble, map[string]string{"messaging": StatusWarning})
+				So(response.Checks, ShouldHaveLength, 2)
+				So(response.Checks[0].state.Name(), ShouldEqual, "kafka")
+				So(response.Checks[1].state.Name(), ShouldEqual, "cache")
+			})
+
+			Convey("Then the group is no more severe than the overall status within the critical timeout", func() {
+				So(hc.Status, ShouldEqual, StatusWarning)
+			})
+		})
+
+		Convey("When the health handler is called for the messaging group once the critical timeout has passed", func() {
+			hc.Checks[3].nonCritical = false
+			hc.mutex.Lock()
+			hc.timeOfFirstCriticalError = time.Now().UTC().Add(-2 * criticalTimeout)
+			hc.mutex.Unlock()
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?group=messaging", nil))
+
+			Convey("Then the group is critical", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Status, ShouldEqual, StatusCritical)
+				So(response.Groups, ShouldResemble, map[string]string{"messaging": StatusCritical})
+			})
+		})
+
+		Convey("When the health handler is called for a group that does not exist", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?group=compute", nil))
+
+			Convey("Then it responds not found", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+	})
+	// failingGroup returns a ticked health check, critical after one failure, with a failing check in a group
+	failingGroup := func(opts ...Option) HealthCheck {
+		hc, err := New(version, criticalTimeout, interval, append([]Option{WithCriticalFailures(1)}, opts...)...)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("kafka", checker(StatusCritical), WithGroup("messaging")), ShouldBeNil)
+		hc.tickers[0].timeTicker.Stop()
+		hc.StartTime = time.Now().UTC()
+		hc.Tick(context.Background())
+		return hc
+	}
+
+	Convey("Given a health check that is critical after one failure, with a check in a group that has failed once", t, func() {
+		hc := failingGroup()
+
+		Convey("Then the group is critical, as the app is", func() {
+			So(hc.Status, ShouldEqual, StatusCritical)
+			So(hc.Groups, ShouldResemble, map[string]string{"messaging": StatusCritical})
+		})
+	})
+
+	Convey("Given a health check within its soft start window that is critical after one failure, with a check in a group that has failed once", t, func() {
+		hc := failingGroup(WithSoftStart(time.Hour))
+
+		Convey("Then the group is a warning, as the app is", func() {
+			So(hc.Status, ShouldEqual, StatusWarning)
+			So(hc.Groups, ShouldResemble, map[string]string{"messaging": StatusWarning})
+		})
+	})
+}
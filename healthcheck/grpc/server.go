@@ -0,0 +1,115 @@
+// Package grpc serves the health of the app and of each of its checks over the gRPC Health Checking Protocol, for
+// apps that expose gRPC rather than HTTP. It is a separate package so that apps that do not use gRPC do not depend on
+// its libraries.
+package grpc
+
+import (
+	"context"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// servingStatuses are the serving statuses reported for each status
+var servingStatuses = map[string]healthpb.HealthCheckResponse_ServingStatus{
+	health.StatusOK:       healthpb.HealthCheckResponse_SERVING,
+	health.StatusWarning:  healthpb.HealthCheckResponse_SERVING,
+	health.StatusCritical: healthpb.HealthCheckResponse_NOT_SERVING,
+}
+
+type server struct {
+	hc *health.HealthCheck
+}
+
+// NewServer returns an implementation of the grpc.health.v1.Health service backed by the provided health check, to be
+// registered with healthpb.RegisterHealthServer. The empty service name reports the overall status of the app, and
+// the name of a check reports the status most recently recorded by that check. OK and WARNING are reported as SERVING,
+// CRITICAL as NOT_SERVING, and a check that has not yet run as UNKNOWN.
+func NewServer(hc *health.HealthCheck) healthpb.HealthServer {
+	return &server{hc: hc}
+}
+
+// Check returns the serving status of the app or of the requested check, or a NotFound error if there is no check
+// with the requested name
+func (s *server) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	var servingStatus healthpb.HealthCheckResponse_ServingStatus
+	if req.Service == "" {
+		servingStatus = toServingStatus(s.hc.GetStatus(ctx))
+	} else {
+		var ok bool
+		if servingStatus, ok = s.checkServingStatus(req.Service); !ok {
+			return nil, status.Errorf(codes.NotFound, "unknown service: %s", req.Service)
+		}
+	}
+	return &healthpb.HealthCheckResponse{Status: servingStatus}, nil
+}
+
+// Watch sends the serving status of the app or of the requested check, and then sends it again whenever it changes
+// until the stream ends. A check that does not exist is reported as SERVICE_UNKNOWN until it is added.
+func (s *server) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	// changes is signalled on every transition, but only needs to hold one signal as the status is read afresh
+	changes := make(chan string, 1)
+	if req.Service == "" {
+		unsubscribe := s.hc.Subscribe(changes)
+		defer unsubscribe()
+	} else {
+		unsubscribe := s.hc.SubscribeChecks(func(ctx context.Context, change health.CheckStatusChange) {
+			select {
+			case changes <- change.Current:
+			default:
+			}
+		}, req.Service)
+		defer unsubscribe()
+	}
+
+	var last *healthpb.HealthCheckResponse_ServingStatus
+	for {
+		servingStatus := s.watchedServingStatus(req.Service)
+		if last == nil || servingStatus != *last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: servingStatus}); err != nil {
+				return err
+			}
+			last = &servingStatus
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-changes:
+		}
+	}
+}
+
+// watchedServingStatus returns the serving status of the app, or of the check with the provided name, without
+// recalculating the overall status, as the watch is itself notified of its transitions
+func (s *server) watchedServingStatus(service string) healthpb.HealthCheckResponse_ServingStatus {
+	if service == "" {
+		return toServingStatus(s.hc.GetState().Status)
+	}
+	servingStatus, ok := s.checkServingStatus(service)
+	if !ok {
+		return healthpb.HealthCheckResponse_SERVICE_UNKNOWN
+	}
+	return servingStatus
+}
+
+// checkServingStatus returns the serving status of the check with the provided name, and whether the check exists
+func (s *server) checkServingStatus(name string) (healthpb.HealthCheckResponse_ServingStatus, bool) {
+	for _, check := range s.hc.GetState().Checks {
+		if state := check.State(); state.Name() == name {
+			return toServingStatus(state.Status()), true
+		}
+	}
+	return healthpb.HealthCheckResponse_UNKNOWN, false
+}
+
+// toServingStatus returns the serving status for the provided status, which is UNKNOWN if the status has not yet been
+// recorded
+func toServingStatus(s string) healthpb.HealthCheckResponse_ServingStatus {
+	if servingStatus, ok := servingStatuses[s]; ok {
+		return servingStatus
+	}
+	return healthpb.HealthCheckResponse_UNKNOWN
+}
@@ -0,0 +1,157 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	"github.com/ONSdigital/dp-healthcheck/healthcheck/hctest"
+	. "github.com/smartystreets/goconvey/convey"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// watchStream is a Health_WatchServer that forwards each response it is sent to a channel
+type watchStream struct {
+	grpclib.ServerStream
+	ctx       context.Context
+	responses chan healthpb.HealthCheckResponse_ServingStatus
+}
+
+func (s *watchStream) Send(resp *healthpb.HealthCheckResponse) error {
+	s.responses <- resp.Status
+	return nil
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestServer(t *testing.T) {
+	Convey("Given a health check that is critical after one failure, with a check whose status can be changed", t, func() {
+		var (
+			mutex       sync.Mutex
+			checkStatus = health.StatusOK
+		)
+		setStatus := func(s string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			checkStatus = s
+		}
+
+		version := health.VersionInfo{Version: "1.0.0"}
+		clock := hctest.NewFakeClock(time.Now())
+		hc, err := health.New(version, 0, time.Minute, health.WithCriticalFailures(1), health.WithClock(clock))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongodb", func(ctx context.Context, state *health.CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(checkStatus, "", 0)
+		}), ShouldBeNil)
+		server := NewServer(&hc)
+		ctx := context.Background()
+		// tick runs the check as it falls due at its interval
+		tick := func() {
+			clock.Advance(time.Minute)
+			hc.Tick(ctx)
+		}
+
+		check := func(service string) (healthpb.HealthCheckResponse_ServingStatus, error) {
+			resp, err := server.Check(ctx, &healthpb.HealthCheckRequest{Service: service})
+			if err != nil {
+				return healthpb.HealthCheckResponse_UNKNOWN, err
+			}
+			return resp.Status, nil
+		}
+
+		Convey("Then a check that has not yet run is reported as unknown", func() {
+			servingStatus, err := check("mongodb")
+			So(err, ShouldBeNil)
+			So(servingStatus, ShouldEqual, healthpb.HealthCheckResponse_UNKNOWN)
+		})
+
+		Convey("Then checking a service that is not a check returns a not found error", func() {
+			_, err := check("kafka")
+			So(status.Code(err), ShouldEqual, codes.NotFound)
+		})
+
+		Convey("When the check has recorded OK", func() {
+			tick()
+
+			Convey("Then the app and the check are serving", func() {
+				servingStatus, err := check("")
+				So(err, ShouldBeNil)
+				So(servingStatus, ShouldEqual, healthpb.HealthCheckResponse_SERVING)
+
+				servingStatus, err = check("mongodb")
+				So(err, ShouldBeNil)
+				So(servingStatus, ShouldEqual, healthpb.HealthCheckResponse_SERVING)
+			})
+		})
+
+		Convey("When the check has recorded CRITICAL", func() {
+			setStatus(health.StatusCritical)
+			tick()
+
+			Convey("Then the app and the check are not serving", func() {
+				servingStatus, err := check("")
+				So(err, ShouldBeNil)
+				So(servingStatus, ShouldEqual, healthpb.HealthCheckResponse_NOT_SERVING)
+
+				servingStatus, err = check("mongodb")
+				So(err, ShouldBeNil)
+				So(servingStatus, ShouldEqual, healthpb.HealthCheckResponse_NOT_SERVING)
+			})
+		})
+
+		for _, service := range []string{"", "mongodb"} {
+			service := service
+
+			Convey("When the status of service '"+service+"' is watched and the check goes from OK to CRITICAL", func() {
+				tick()
+				watchCtx, cancel := context.WithCancel(ctx)
+				stream := &watchStream{ctx: watchCtx, responses: make(chan healthpb.HealthCheckResponse_ServingStatus, 10)}
+				watched := make(chan error)
+				go func() {
+					watched <- server.Watch(&healthpb.HealthCheckRequest{Service: service}, stream)
+				}()
+
+				first := <-stream.responses
+				tick()
+				setStatus(health.StatusCritical)
+				tick()
+				second := <-stream.responses
+				cancel()
+				err := <-watched
+
+				Convey("Then the current status is sent, followed by each transition, until the stream ends", func() {
+					So(first, ShouldEqual, healthpb.HealthCheckResponse_SERVING)
+					So(second, ShouldEqual, healthpb.HealthCheckResponse_NOT_SERVING)
+					So(stream.responses, ShouldBeEmpty)
+					So(status.Code(err), ShouldEqual, codes.Canceled)
+				})
+			})
+		}
+
+		Convey("When a check that does not exist is watched", func() {
+			watchCtx, cancel := context.WithCancel(ctx)
+			stream := &watchStream{ctx: watchCtx, responses: make(chan healthpb.HealthCheckResponse_ServingStatus, 10)}
+			watched := make(chan error)
+			go func() {
+				watched <- server.Watch(&healthpb.HealthCheckRequest{Service: "kafka"}, stream)
+			}()
+
+			first := <-stream.responses
+			cancel()
+			<-watched
+
+			Convey("Then it is reported as an unknown service", func() {
+				So(first, ShouldEqual, healthpb.HealthCheckResponse_SERVICE_UNKNOWN)
+			})
+		})
+	})
+}
@@ -1,8 +1,8 @@
 package healthcheck
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
 	"net/http"
 	"time"
 
@@ -13,32 +13,78 @@ var minTime = time.Unix(0, 0)
 
 // Handler responds to an http request for the current health status
 func (hc *HealthCheck) Handler(w http.ResponseWriter, req *http.Request) {
-	now := time.Now().UTC()
 	ctx := req.Context()
 
-	hc.Status = hc.getStatus(ctx)
-	hc.Uptime = now.Sub(hc.StartTime) / time.Millisecond
+	encoder := hc.encoder
+	if encoder == nil {
+		encoder = JSONEncoder{}
+	}
 
-	b, err := json.Marshal(hc)
-	if err != nil {
-		log.Event(ctx, "failed to marshal json", log.Error(err), log.Data{"health_check_response": hc})
-		return
+	if hc.refreshOnRequest && req.URL.Query().Get("refresh") == "true" {
+		hc.runChecks(ctx, true)
 	}
 
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	hc.mutex.Lock()
+	change, changed := hc.setStatus(hc.getStatus(ctx, true))
+	snapshot := *hc
+	hc.mutex.Unlock()
 
-	switch hc.Status {
-	case StatusOK:
-		w.WriteHeader(http.StatusOK)
-	case StatusWarning:
-		w.WriteHeader(http.StatusTooManyRequests)
-	default:
-		w.WriteHeader(http.StatusInternalServerError)
+	hc.notifyStaleChecks(ctx)
+	if changed {
+		hc.notifyStatusChange(ctx, change, snapshot)
+	}
+
+	response := snapshot
+	if group := req.URL.Query().Get("group"); group != "" {
+		var ok bool
+		if response, ok = response.withGroup(group); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+	if values := req.URL.Query()["label"]; len(values) > 0 {
+		labels, err := parseLabels(values)
+		if err != nil {
+			logEvent(ctx, hc.logger, levelDefault, "invalid label in health check request", err, nil)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var ok bool
+		if response, ok = response.withLabels(labels); !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+	status := response.Status
+	if hc.omitCheckDetails {
+		response = response.withoutCheckDetails()
+	}
+	if hc.omitVersion {
+		response = response.withoutVersion()
+	}
+	if hc.historySize > 0 && req.URL.Query().Get("history") == "true" {
+		response = response.withHistory()
+	}
+	if hc.statusNames != nil {
+		response = response.withStatusNames(*hc.statusNames)
+	}
+	if hc.relativeTimes {
+		response = response.withRelativeTimes(hc.now())
 	}
 
-	_, err = w.Write(b)
+	var b bytes.Buffer
+	if err := encoder.Encode(&b, response); err != nil {
+		logEvent(ctx, hc.logger, levelDefault, "failed to encode health check", err, log.Data{"health_check_response": snapshot})
+		return
+	}
+
+	w.Header().Set("Content-Type", encoder.ContentType())
+
+	w.WriteHeader(hc.statusCodes.code(status))
+
+	_, err := w.Write(b.Bytes())
 	if err != nil {
-		log.Event(ctx, "failed to write bytes for http response", log.Error(err))
+		logEvent(ctx, hc.logger, levelDefault, "failed to write bytes for http response", err, nil)
 		return
 	}
 }
@@ -46,26 +92,47 @@ func (hc *HealthCheck) Handler(w http.ResponseWriter, req *http.Request) {
 // isAppStartingUp returns false when all clients have completed at least one check
 func (hc *HealthCheck) isAppStartingUp() bool {
 	for _, check := range hc.Checks {
-		if !check.hasRun() {
+		if !check.informational && !check.hasRun() {
 			return true
 		}
 	}
 	return false
 }
 
-// getStatus returns a status as string as to the overall current apps health based on its dependent apps health
-func (hc *HealthCheck) getStatus(ctx context.Context) string {
+// getStatus returns a status as string as to the overall current apps health based on its dependent apps health,
+// logging that a dependency is still starting up if logStartingUp is set, as it is for the health handler. It is the
+// only place the overall status is calculated, so that every caller applies the same rules. Callers must hold the
+// write lock.
+func (hc *HealthCheck) getStatus(ctx context.Context, logStartingUp bool) string {
+	hc.markStaleChecks(hc.now())
 	if hc.isAppStartingUp() {
-		log.Event(ctx, "a dependency is still starting up")
-		return StatusWarning
+		if logStartingUp {
+			logEvent(ctx, hc.logger, levelDefault, "a dependency is still starting up", nil, nil)
+		}
+		return hc.overrideStatus(StatusWarning)
 	}
-	return hc.isAppHealthy()
+	return hc.overrideStatus(hc.isAppHealthy())
 }
 
-// isAppHealthy checks every check for their health then produces and returns a status for this apps health
+// isAppHealthy checks every check for their health then produces and returns a status for this apps health.
+// Once no check is critical, the time of the first critical error is reset.
 func (hc *HealthCheck) isAppHealthy() string {
 	status := StatusOK
+	failing := false
 	for _, check := range hc.Checks {
+		// a skipped check adds nothing to the status of the check it depends on, which is critical
+		if check.informational || check.isSkipped() {
+			continue
+		}
+		if check.nonCritical {
+			if check.getSeverity() != StatusOK {
+				status = StatusWarning
+			}
+			continue
+		}
+		if severity := check.getSeverity(); severity != StatusOK && severity != StatusWarning {
+			failing = true
+		}
 		checkStatus := hc.getCheckStatus(check)
 		if checkStatus == StatusCritical {
 			return StatusCritical
@@ -73,34 +140,36 @@ func (hc *HealthCheck) isAppHealthy() string {
 			status = StatusWarning
 		}
 	}
+
+	if !failing {
+		hc.timeOfFirstCriticalError = time.Time{}
+	}
 	return status
 }
 
-// getCheckStatus returns a string for the status on if an individual check
+// getCheckStatus returns a string for the status on if an individual check, starting the critical error timer if the
+// check is critical
 func (hc *HealthCheck) getCheckStatus(c *Check) string {
-	switch c.state.Status() {
+	switch c.getSeverity() {
 	case StatusOK:
 		return StatusOK
 	case StatusWarning:
 		return StatusWarning
 	default:
+		now := hc.now()
+		status := hc.getCriticalCheckStatus(c, now)
 
-		now := time.Now().UTC()
-		status := StatusWarning
+		// Critical checks are reported as warning while within the soft start window, without starting the critical
+		// error timer, so that the critical timeout is measured from the first critical error after the window.
+		if hc.isSoftStarting(now) {
+			return status
+		}
 
-		// last success or minTime if nil. c should not be muted.
 		lastSuccess := c.state.LastSuccess()
 		if lastSuccess == nil {
 			lastSuccess = &minTime
 		}
 
-		// Global state will be considered critical if check has been critical for longer
-		// than the first critical error since last success and the timeout has expired.
-		criticalTimeThreshold := hc.timeOfFirstCriticalError.Add(hc.criticalErrorTimeout)
-		if lastSuccess.Before(hc.timeOfFirstCriticalError) && now.After(criticalTimeThreshold) {
-			status = StatusCritical
-		}
-
 		// Set timestamp of first critical error to now if there has been a success since the previous value, or if this is the first one.
 		if lastSuccess.After(hc.timeOfFirstCriticalError) || hc.timeOfFirstCriticalError.IsZero() {
 			hc.timeOfFirstCriticalError = now
@@ -109,3 +178,94 @@ func (hc *HealthCheck) getCheckStatus(c *Check) string {
 		return status
 	}
 }
+
+// getCriticalCheckStatus returns the status at the provided time of a check whose severity is critical, without
+// starting the critical error timer, so that the status of a subset of the checks is reported by the same rules as
+// the overall status. The check is critical once it has been critical for longer than its critical timeout since the
+// first critical error, or has failed the configured number of consecutive runs, and is otherwise a warning, as it is
+// within the soft start window.
+func (hc *HealthCheck) getCriticalCheckStatus(c *Check, now time.Time) string {
+	if hc.isSoftStarting(now) {
+		return StatusWarning
+	}
+
+	status := StatusWarning
+
+	// last success or minTime if nil. c should not be muted.
+	lastSuccess := c.state.LastSuccess()
+	if lastSuccess == nil {
+		lastSuccess = &minTime
+	}
+
+	// The check may override the critical error timeout of the health check.
+	criticalTimeout, hasTimeout := hc.criticalErrorTimeout, !hc.isFailureCountOnly()
+	if c.criticalTimeout > 0 {
+		criticalTimeout, hasTimeout = c.criticalTimeout, true
+	}
+
+	// Global state will be considered critical if check has been critical for longer
+	// than the first critical error since last success and the timeout has expired.
+	criticalTimeThreshold := hc.timeOfFirstCriticalError.Add(criticalTimeout)
+	if lastSuccess.Before(hc.timeOfFirstCriticalError) && now.After(criticalTimeThreshold) && hasTimeout {
+		status = StatusCritical
+	}
+
+	// Global state will also be considered critical once the check has failed the configured number of consecutive runs.
+	if hc.criticalFailures > 0 && c.state.ConsecutiveFailures() >= hc.criticalFailures {
+		status = StatusCritical
+	}
+
+	return status
+}
+
+// isFailureCountOnly returns true if critical checks only make the app critical once they have failed the
+// configured number of consecutive runs, as no critical error timeout has been set
+func (hc *HealthCheck) isFailureCountOnly() bool {
+	return hc.criticalFailures > 0 && hc.criticalErrorTimeout <= 0
+}
+
+// isSoftStarting returns true if the provided time is within the soft start window following the start of the health check
+func (hc *HealthCheck) isSoftStarting(t time.Time) bool {
+	return hc.softStartWindow > 0 && t.Before(hc.StartTime.Add(hc.softStartWindow))
+}
+
+// withRelativeTimes returns a copy of the health check, and the states of its checks, that report the age of each
+// check timestamp relative to the provided time alongside the timestamp itself
+func (hc HealthCheck) withRelativeTimes(now time.Time) HealthCheck {
+	checks := make([]*Check, 0, len(hc.Checks))
+	for _, check := range hc.Checks {
+		state := check.state.clone()
+		state.relativeTo = &now
+		checks = append(checks, &Check{state: state})
+	}
+
+	hc.Checks = checks
+	return hc
+}
+
+// withHistory returns a copy of the health check, and the states of its checks, that report the history of each check
+func (hc HealthCheck) withHistory() HealthCheck {
+	checks := make([]*Check, 0, len(hc.Checks))
+	for _, check := range hc.Checks {
+		state := check.state.clone()
+		state.includeHistory = true
+		checks = append(checks, &Check{state: state})
+	}
+
+	hc.Checks = checks
+	return hc
+}
+
+// withoutCheckDetails returns a copy of the health check without its checks, so that only the overall and group
+// statuses are reported
+func (hc HealthCheck) withoutCheckDetails() HealthCheck {
+	hc.Checks = []*Check{}
+	return hc
+}
+
+// withoutVersion returns a copy of the health check without its version information
+func (hc HealthCheck) withoutVersion() HealthCheck {
+	hc.Version = VersionInfo{}
+	hc.omitVersion = true
+	return hc
+}
@@ -3,10 +3,12 @@ package healthcheck
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -15,7 +17,7 @@ import (
 )
 
 var testVersion = VersionInfo{
-	BuildTime:       time.Unix(0, 0),
+	BuildTime:       time.Unix(0, 0).UTC(),
 	GitCommit:       "d6cd1e2bd19e03a81132a23b2025920577f84e37",
 	Language:        "go",
 	LanguageVersion: "1.12",
@@ -42,7 +44,7 @@ func TestGetStatus(t *testing.T) {
 			statuses := []CheckState{CheckState{}}
 			hc.Checks = createChecksSlice(statuses, true)
 
-			state := hc.getStatus(ctx)
+			state := hc.getStatus(ctx, true)
 			So(state, ShouldEqual, StatusWarning)
 		})
 	})
@@ -61,7 +63,7 @@ func TestGetStatus(t *testing.T) {
 			statuses := []CheckState{CheckState{status: StatusOK, lastChecked: &t0, mutex: &sync.RWMutex{}}}
 			hc.Checks = createChecksSlice(statuses, true)
 
-			state := hc.getStatus(ctx)
+			state := hc.getStatus(ctx, true)
 			So(state, ShouldEqual, StatusOK)
 		})
 	})
@@ -282,6 +284,305 @@ func TestGetCheckStatus(t *testing.T) {
 			})
 		})
 	})
+
+	Convey("Given check status is failure and the critical timeout has expired", t, func() {
+		check := &Check{
+			state: &CheckState{
+				status: StatusCritical,
+				mutex:  &sync.RWMutex{},
+			},
+		}
+
+		softStartHC := HealthCheck{
+			Version:                  testVersion,
+			criticalErrorTimeout:     criticalErrTimeout,
+			softStartWindow:          30 * time.Minute,
+			timeOfFirstCriticalError: t20,
+		}
+
+		Convey("When the health check is within its soft start window", func() {
+			softStartHC.StartTime = t10
+
+			Convey("Then the returning status is warning", func() {
+				status := softStartHC.getCheckStatus(check)
+				So(status, ShouldEqual, StatusWarning)
+				So(softStartHC.timeOfFirstCriticalError, ShouldEqual, t20)
+			})
+		})
+
+		Convey("When the health check is within its soft start window and no critical error has been seen", func() {
+			softStartHC.StartTime = t10
+			softStartHC.timeOfFirstCriticalError = time.Time{}
+
+			Convey("Then the returning status is warning and the critical error timer is not started", func() {
+				status := softStartHC.getCheckStatus(check)
+				So(status, ShouldEqual, StatusWarning)
+				So(softStartHC.timeOfFirstCriticalError.IsZero(), ShouldBeTrue)
+			})
+		})
+
+		Convey("When the soft start window has just passed and no critical error has been seen", func() {
+			softStartHC.StartTime = t0.Add(-31 * time.Minute)
+			softStartHC.timeOfFirstCriticalError = time.Time{}
+
+			Convey("Then the returning status is warning and the critical error timer is started", func() {
+				status := softStartHC.getCheckStatus(check)
+				So(status, ShouldEqual, StatusWarning)
+				So(softStartHC.timeOfFirstCriticalError, ShouldHappenOnOrBetween, t0, time.Now().UTC())
+			})
+		})
+
+		Convey("When the soft start window has passed", func() {
+			softStartHC.StartTime = t0.Add(-40 * time.Minute)
+
+			Convey("Then the returning status is critical", func() {
+				status := softStartHC.getCheckStatus(check)
+				So(status, ShouldEqual, StatusCritical)
+			})
+		})
+	})
+}
+
+// Test getCheckStatus() function with checks that determine their own severity
+func TestGetCheckStatusWithSeverity(t *testing.T) {
+	t0 := time.Now().UTC()
+	t20 := t0.Add(-20 * time.Minute)
+
+	hc := HealthCheck{
+		Version:                  testVersion,
+		StartTime:                t20,
+		criticalErrorTimeout:     10 * time.Minute,
+		timeOfFirstCriticalError: t20,
+	}
+
+	// critical only if the check message reports that both replica lag is high and disk space is low
+	severity := func(state *CheckState) string {
+		if state.Status() == StatusCritical && state.Message() != "replica lag high and disk low" {
+			return StatusWarning
+		}
+		return state.Status()
+	}
+
+	Convey("Given a critical check whose severity function downgrades it to warning", t, func() {
+		check, _ := NewCheck("mongo", func(ctx context.Context, state *CheckState) error { return nil }, WithSeverity(severity))
+		check.state.status = StatusCritical
+		check.state.message = "replica lag high"
+
+		Convey("Then the returning status is warning", func() {
+			So(hc.getCheckStatus(check), ShouldEqual, StatusWarning)
+		})
+
+		Convey("Then the recorded status of the check is unchanged", func() {
+			So(check.state.Status(), ShouldEqual, StatusCritical)
+		})
+	})
+
+	Convey("Given a critical check whose severity function keeps it critical", t, func() {
+		check, _ := NewCheck("mongo", func(ctx context.Context, state *CheckState) error { return nil }, WithSeverity(severity))
+		check.state.status = StatusCritical
+		check.state.message = "replica lag high and disk low"
+
+		Convey("Then the critical timeout applies as normal and the returning status is critical", func() {
+			So(hc.getCheckStatus(check), ShouldEqual, StatusCritical)
+		})
+	})
+}
+
+func TestGetCheckStatusWithCriticalTimeout(t *testing.T) {
+	t0 := time.Now().UTC()
+	t20 := t0.Add(-20 * time.Minute)
+
+	criticalCheck := func(opts ...CheckOption) *Check {
+		check, _ := NewCheck("mongo", func(ctx context.Context, state *CheckState) error { return nil }, opts...)
+		check.state.status = StatusCritical
+		return check
+	}
+
+	Convey("Given a health check with a critical timeout of 10 minutes whose first critical error was 20 minutes ago", t, func() {
+		hc := HealthCheck{
+			StartTime:                t20,
+			criticalErrorTimeout:     10 * time.Minute,
+			timeOfFirstCriticalError: t20,
+		}
+
+		Convey("Then a critical check without its own critical timeout is critical", func() {
+			So(hc.getCheckStatus(criticalCheck()), ShouldEqual, StatusCritical)
+		})
+
+		Convey("Then a critical check with a critical timeout of 30 minutes is warning", func() {
+			So(hc.getCheckStatus(criticalCheck(WithCriticalTimeout(30*time.Minute))), ShouldEqual, StatusWarning)
+		})
+
+		Convey("Then a critical check with a critical timeout of 5 minutes is critical", func() {
+			So(hc.getCheckStatus(criticalCheck(WithCriticalTimeout(5*time.Minute))), ShouldEqual, StatusCritical)
+		})
+	})
+
+	Convey("Given a health check configured to go critical after 3 failures without a critical timeout", t, func() {
+		hc := HealthCheck{
+			StartTime:                t20,
+			criticalFailures:         3,
+			timeOfFirstCriticalError: t20,
+		}
+
+		Convey("Then a critical check with its own critical timeout that has expired is critical", func() {
+			So(hc.getCheckStatus(criticalCheck(WithCriticalTimeout(10*time.Minute))), ShouldEqual, StatusCritical)
+		})
+	})
+}
+
+func TestGetCheckStatusWithCriticalFailures(t *testing.T) {
+	t0 := time.Now().UTC()
+	t20 := t0.Add(-20 * time.Minute)
+
+	criticalCheck := func(failures int) *Check {
+		check, _ := NewCheck("mongo", func(ctx context.Context, state *CheckState) error { return nil })
+		check.state.status = StatusCritical
+		check.state.consecutiveFailures = failures
+		return check
+	}
+
+	Convey("Given a health check configured to go critical after 3 failures alongside a critical timeout", t, func() {
+		hc := HealthCheck{
+			StartTime:                t20,
+			criticalErrorTimeout:     10 * time.Minute,
+			criticalFailures:         3,
+			timeOfFirstCriticalError: t0,
+		}
+
+		Convey("Then a check that has failed fewer runs within the timeout is warning", func() {
+			So(hc.getCheckStatus(criticalCheck(2)), ShouldEqual, StatusWarning)
+		})
+
+		Convey("Then a check that has failed 3 runs within the timeout is critical", func() {
+			So(hc.getCheckStatus(criticalCheck(3)), ShouldEqual, StatusCritical)
+		})
+
+		Convey("Then a check that has failed fewer runs beyond the timeout is critical", func() {
+			hc.timeOfFirstCriticalError = t20
+			So(hc.getCheckStatus(criticalCheck(1)), ShouldEqual, StatusCritical)
+		})
+	})
+
+	Convey("Given a health check configured to go critical after 3 failures without a critical timeout", t, func() {
+		hc := HealthCheck{
+			StartTime:                t20,
+			criticalFailures:         3,
+			timeOfFirstCriticalError: t20,
+		}
+
+		Convey("Then a check that has failed fewer runs is warning however long it has been failing", func() {
+			So(hc.getCheckStatus(criticalCheck(2)), ShouldEqual, StatusWarning)
+		})
+
+		Convey("Then a check that has failed 3 runs is critical", func() {
+			So(hc.getCheckStatus(criticalCheck(3)), ShouldEqual, StatusCritical)
+		})
+	})
+}
+
+func TestGetStatusWithInformationalChecks(t *testing.T) {
+	t0 := time.Now().UTC()
+	t20 := t0.Add(-20 * time.Minute)
+
+	newCheck := func(name, status string, opts ...CheckOption) *Check {
+		check, _ := NewCheck(name, func(ctx context.Context, state *CheckState) error { return nil }, opts...)
+		check.state.status = status
+		check.state.lastChecked = &t0
+		return check
+	}
+
+	Convey("Given a health check with an OK gating check and a critical informational check", t, func() {
+		hc := HealthCheck{
+			StartTime:                t20,
+			criticalErrorTimeout:     10 * time.Minute,
+			timeOfFirstCriticalError: t20,
+			Checks: []*Check{
+				newCheck("mongo", StatusOK),
+				newCheck("queue depth", StatusCritical, WithInformational()),
+			},
+		}
+
+		Convey("Then the informational check does not contribute to the overall status", func() {
+			So(hc.getStatus(context.Background(), true), ShouldEqual, StatusOK)
+		})
+	})
+
+	Convey("Given a health check with an OK gating check and an informational check that has not run", t, func() {
+		informational := newCheck("queue depth", "", WithInformational())
+		informational.state.lastChecked = nil
+		hc := HealthCheck{
+			StartTime: t20,
+			Checks:    []*Check{newCheck("mongo", StatusOK), informational},
+		}
+
+		Convey("Then the app is not considered to be starting up", func() {
+			So(hc.getStatus(context.Background(), true), ShouldEqual, StatusOK)
+		})
+	})
+}
+
+func TestGetStatusWithNonCriticalChecks(t *testing.T) {
+	t0 := time.Now().UTC()
+	t20 := t0.Add(-20 * time.Minute)
+
+	newCheck := func(name, status string, opts ...CheckOption) *Check {
+		check, _ := NewCheck(name, func(ctx context.Context, state *CheckState) error { return nil }, opts...)
+		check.state.status = status
+		check.state.lastChecked = &t0
+		check.state.lastFailure = &t20
+		return check
+	}
+
+	Convey("Given a health check with an OK check and a non-critical check that has been critical beyond the critical timeout", t, func() {
+		nonCritical := newCheck("cache", StatusCritical, WithNonCritical())
+		hc := HealthCheck{
+			StartTime:                t20,
+			criticalErrorTimeout:     10 * time.Minute,
+			timeOfFirstCriticalError: t20,
+			Checks:                   []*Check{newCheck("mongo", StatusOK), nonCritical},
+		}
+
+		Convey("Then the overall status is warning", func() {
+			So(hc.getStatus(context.Background(), true), ShouldEqual, StatusWarning)
+		})
+
+		Convey("Then the non-critical check still reports its own status", func() {
+			So(nonCritical.state.Status(), ShouldEqual, StatusCritical)
+		})
+
+		Convey("Then the critical error timer is not started by the non-critical check", func() {
+			hc.getStatus(context.Background(), true)
+			So(hc.timeOfFirstCriticalError.IsZero(), ShouldBeTrue)
+		})
+	})
+
+	Convey("Given a health check with a non-critical check that is OK and a critical check beyond the critical timeout", t, func() {
+		hc := HealthCheck{
+			StartTime:                t20,
+			criticalErrorTimeout:     10 * time.Minute,
+			timeOfFirstCriticalError: t20,
+			Checks: []*Check{
+				newCheck("cache", StatusOK, WithNonCritical()),
+				newCheck("mongo", StatusCritical),
+			},
+		}
+
+		Convey("Then the overall status is critical", func() {
+			So(hc.getStatus(context.Background(), true), ShouldEqual, StatusCritical)
+		})
+	})
+
+	Convey("Given a health check with a check added with AddNonCriticalCheck", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddNonCriticalCheck("cache", func(ctx context.Context, state *CheckState) error { return nil }), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+
+		Convey("Then the check is non-critical", func() {
+			So(hc.Checks[0].nonCritical, ShouldBeTrue)
+		})
+	})
 }
 
 // Testing isAppHealthy() function that inherits logic from getCheckStatus()
@@ -527,6 +828,7 @@ func TestHandlerSingleCheck(t *testing.T) {
 	Convey("Given a healthcheck with no past failures or successes", t, func() {
 
 		hc := HealthCheck{
+			mutex:                &sync.RWMutex{},
 			Version:              testVersion,
 			StartTime:            t0,
 			criticalErrorTimeout: criticalErrTimeout,
@@ -588,6 +890,7 @@ func TestHandlerSingleCheck(t *testing.T) {
 	Convey("Given a healthcheck with a recent past critical check (timeout not expired), and no success received since", t, func() {
 
 		hc := HealthCheck{
+			mutex:                    &sync.RWMutex{},
 			Version:                  testVersion,
 			StartTime:                t0,
 			criticalErrorTimeout:     criticalErrTimeout,
@@ -599,8 +902,8 @@ func TestHandlerSingleCheck(t *testing.T) {
 			statuses := []CheckState{healthyNeverUnhealthyStatus}
 			hc.Checks = createChecksSlice(statuses, true)
 			runHealthHandlerAndTest(t, &hc, StatusOK, testVersion, t0, statuses, http.StatusOK)
-			// timeOfFirstCriticalError not updated
-			So(hc.timeOfFirstCriticalError, ShouldResemble, t10)
+			// timeOfFirstCriticalError reset as no check is critical
+			So(hc.timeOfFirstCriticalError, ShouldBeZeroValue)
 		})
 		Convey("Then a recent critical check happening before the timeout expires should result in the app reporting back as warning", func() {
 			statuses := []CheckState{freshCriticalStatus}
@@ -622,6 +925,7 @@ func TestHandlerSingleCheck(t *testing.T) {
 	Convey("Given a healthcheck with an old past critical check (timeout expired), and no success received since", t, func() {
 
 		hc := HealthCheck{
+			mutex:                    &sync.RWMutex{},
 			Version:                  testVersion,
 			StartTime:                t0,
 			criticalErrorTimeout:     criticalErrTimeout,
@@ -633,8 +937,8 @@ func TestHandlerSingleCheck(t *testing.T) {
 			statuses := []CheckState{healthyNeverUnhealthyStatus}
 			hc.Checks = createChecksSlice(statuses, true)
 			runHealthHandlerAndTest(t, &hc, StatusOK, testVersion, t0, statuses, http.StatusOK)
-			// timeOfFirstCriticalError not set
-			So(hc.timeOfFirstCriticalError, ShouldResemble, t20)
+			// timeOfFirstCriticalError reset as no check is critical
+			So(hc.timeOfFirstCriticalError, ShouldBeZeroValue)
 		})
 		Convey("Then a recent critical check (last success more recent than first critical) should result in the app reporting back as warning "+
 			"and refresh timestamp for first critical error", func() {
@@ -761,6 +1065,7 @@ func TestHandlerMultipleChecks(t *testing.T) {
 		var checks []*Check
 		var statuses []CheckState
 		hc := HealthCheck{
+			mutex:                    &sync.RWMutex{},
 			Checks:                   checks,
 			Version:                  testVersion,
 			StartTime:                testStartTime,
@@ -795,14 +1100,14 @@ func createATestCheck(stateToReturn CheckState, hasPreviousCheck bool) *Check {
 }
 
 func createHealthCheck(statuses []CheckState, startTime time.Time, critErrTimeout time.Duration, hasPreviousCheck bool) HealthCheck {
-	hc := HealthCheck{
+	return HealthCheck{
 		Checks:               createChecksSlice(statuses, hasPreviousCheck),
+		mutex:                &sync.RWMutex{},
 		Version:              testVersion,
 		StartTime:            startTime,
 		criticalErrorTimeout: critErrTimeout,
 		tickers:              nil,
 	}
-	return hc
 }
 
 func createChecksSlice(statuses []CheckState, hasPreviousCheck bool) []*Check {
@@ -850,3 +1155,406 @@ func runHealthHandlerAndTest(t *testing.T, hc *HealthCheck, desiredStatus string
 		}
 	}
 }
+
+// statusEncoder is a test encoder that writes the overall status as plain text
+type statusEncoder struct{}
+
+func (e statusEncoder) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+func (e statusEncoder) Encode(w io.Writer, hc HealthCheck) error {
+	_, err := io.WriteString(w, hc.Status)
+	return err
+}
+
+func TestHandlerEncoder(t *testing.T) {
+	Convey("Given a healthy health check configured with a custom encoder", t, func() {
+		hc, err := New(testVersion, 10*time.Minute, time.Minute, WithEncoder(statusEncoder{}))
+		So(err, ShouldBeNil)
+
+		Convey("When the health handler is called", func() {
+			req := httptest.NewRequest("GET", "/health", nil)
+			w := httptest.NewRecorder()
+			hc.Handler(w, req)
+
+			Convey("Then the response is written by the custom encoder", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(w.Header().Get("Content-Type"), ShouldEqual, "text/plain; charset=utf-8")
+				So(w.Body.String(), ShouldEqual, StatusOK)
+			})
+		})
+	})
+}
+
+func TestHandlerStatusNames(t *testing.T) {
+	Convey("Given a health check with a warning check configured with IETF status names", t, func() {
+		statuses := []CheckState{
+			{name: "Some App 1", status: StatusWarning, message: "Something is slow", statusCode: 200},
+		}
+		hc := createHealthCheck(statuses, time.Now().UTC().Add(-time.Hour), time.Minute, true)
+		hc.statusNames = &IETFStatusNames
+
+		Convey("When the health handler is called", func() {
+			req := httptest.NewRequest("GET", "/health", nil)
+			w := httptest.NewRecorder()
+			hc.Handler(w, req)
+
+			var response HealthCheck
+			So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+
+			Convey("Then the response uses the configured status names", func() {
+				So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+				So(response.Status, ShouldEqual, "warn")
+				So(response.Checks, ShouldHaveLength, 1)
+				So(response.Checks[0].state.Status(), ShouldEqual, "warn")
+			})
+
+			Convey("And the health check state still uses the internal statuses", func() {
+				So(hc.Status, ShouldEqual, StatusWarning)
+				So(hc.Checks[0].state.Status(), ShouldEqual, StatusWarning)
+			})
+		})
+	})
+}
+
+func TestStatusNames(t *testing.T) {
+	Convey("Given a set of status names with no name for warning", t, func() {
+		names := StatusNames{OK: "UP", Critical: "DOWN"}
+
+		Convey("Then configured statuses are renamed and others are unchanged", func() {
+			So(names.name(StatusOK), ShouldEqual, "UP")
+			So(names.name(StatusCritical), ShouldEqual, "DOWN")
+			So(names.name(StatusWarning), ShouldEqual, StatusWarning)
+			So(names.name(StatusSkipped), ShouldEqual, StatusSkipped)
+		})
+	})
+
+	Convey("Given the IETF status names", t, func() {
+		Convey("Then every status, including a skipped check, has a name", func() {
+			So(IETFStatusNames.name(StatusOK), ShouldEqual, "pass")
+			So(IETFStatusNames.name(StatusWarning), ShouldEqual, "warn")
+			So(IETFStatusNames.name(StatusCritical), ShouldEqual, "fail")
+			So(IETFStatusNames.name(StatusSkipped), ShouldEqual, "warn")
+		})
+	})
+}
+
+func TestHandlerRelativeTimes(t *testing.T) {
+	Convey("Given a health check configured to report relative times with a check last checked 90 seconds ago", t, func() {
+		lastChecked := time.Now().UTC().Add(-90 * time.Second)
+		statuses := []CheckState{
+			{name: "Some App 1", status: StatusOK, message: "Everything is ok", statusCode: 200, lastChecked: &lastChecked, lastSuccess: &lastChecked},
+		}
+		hc := createHealthCheck(statuses, time.Now().UTC().Add(-time.Hour), time.Minute, true)
+		hc.relativeTimes = true
+
+		Convey("When the health handler is called", func() {
+			req := httptest.NewRequest("GET", "/health", nil)
+			w := httptest.NewRecorder()
+			hc.Handler(w, req)
+
+			var response struct {
+				Checks []map[string]interface{} `json:"checks"`
+			}
+			So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+
+			Convey("Then the age of each timestamp is included alongside the timestamp", func() {
+				So(response.Checks, ShouldHaveLength, 1)
+				So(response.Checks[0]["last_checked"], ShouldNotBeNil)
+				So(response.Checks[0]["last_checked_ago"], ShouldEqual, "1m30s")
+				So(response.Checks[0]["last_success_ago"], ShouldEqual, "1m30s")
+				So(response.Checks[0], ShouldNotContainKey, "last_failure_ago")
+			})
+		})
+	})
+}
+
+func TestHandlerRefresh(t *testing.T) {
+	var runs int32
+	checker := func(ctx context.Context, state *CheckState) error {
+		atomic.AddInt32(&runs, 1)
+		return state.Update(StatusOK, "", 0)
+	}
+	request := func(hc *HealthCheck, url string) {
+		hc.Handler(httptest.NewRecorder(), httptest.NewRequest("GET", url, nil))
+	}
+
+	Convey("Given a health check that allows refreshing on request, with a check that has not run", t, func() {
+		atomic.StoreInt32(&runs, 0)
+		hc, err := New(version, criticalTimeout, interval, WithRefreshOnRequest())
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+
+		Convey("When the health handler is called without refresh", func() {
+			request(&hc, "/health")
+
+			Convey("Then the check is not run", func() {
+				So(atomic.LoadInt32(&runs), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When the health handler is called with refresh=true", func() {
+			request(&hc, "/health?refresh=true")
+
+			Convey("Then the check is run before responding", func() {
+				So(atomic.LoadInt32(&runs), ShouldEqual, 1)
+				So(hc.Status, ShouldEqual, StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a health check that does not allow refreshing on request", t, func() {
+		atomic.StoreInt32(&runs, 0)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+
+		Convey("When the health handler is called with refresh=true", func() {
+			request(&hc, "/health?refresh=true")
+
+			Convey("Then the check is not run", func() {
+				So(atomic.LoadInt32(&runs), ShouldEqual, 0)
+			})
+		})
+	})
+}
+
+func TestHandlerConcurrentWithTickers(t *testing.T) {
+	Convey("Given a started health check whose checks are updating frequently", t, func() {
+		cf := func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "I'm OK", 200)
+		}
+		hc, err := New(testVersion, time.Minute, 10*time.Millisecond)
+		So(err, ShouldBeNil)
+		for _, name := range []string{"check 1", "check 2", "check 3"} {
+			So(hc.AddCheck(name, cf), ShouldBeNil)
+		}
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		Convey("When the health handler is called concurrently while the checks run", func() {
+			var wg sync.WaitGroup
+			codes := make(chan int, 50)
+			bodies := make(chan []byte, 50)
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					w := httptest.NewRecorder()
+					hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+					codes <- w.Code
+					bodies <- w.Body.Bytes()
+				}()
+				time.Sleep(time.Millisecond)
+			}
+			wg.Wait()
+			close(codes)
+			close(bodies)
+
+			Convey("Then every response is a complete health check with every check", func() {
+				for body := range bodies {
+					var response HealthCheck
+					So(json.Unmarshal(body, &response), ShouldBeNil)
+					So(response.Checks, ShouldHaveLength, 3)
+				}
+				for code := range codes {
+					So(code, ShouldBeIn, []int{http.StatusOK, http.StatusTooManyRequests})
+				}
+			})
+		})
+	})
+}
+
+func TestHandlerHistory(t *testing.T) {
+	statuses := []string{StatusOK, StatusCritical, StatusOK, StatusWarning}
+	var runs int32
+	checker := func(ctx context.Context, state *CheckState) error {
+		status := statuses[int(atomic.AddInt32(&runs, 1)-1)%len(statuses)]
+		return state.Update(status, "run "+status, 0)
+	}
+	history := func(b []byte) []CheckResult {
+		var body struct {
+			Checks []struct {
+				History []CheckResult `json:"history"`
+			} `json:"checks"`
+		}
+		So(json.Unmarshal(b, &body), ShouldBeNil)
+		So(body.Checks, ShouldHaveLength, 1)
+		return body.Checks[0].History
+	}
+
+	Convey("Given a health check keeping the last 3 results of a check that has run 4 times", t, func() {
+		atomic.StoreInt32(&runs, 0)
+		hc, err := New(version, criticalTimeout, interval, WithHistory(3))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+		for i := 0; i < len(statuses); i++ {
+			hc.runChecks(context.Background(), true)
+		}
+
+		Convey("Then the history of the check holds the last 3 results, oldest first", func() {
+			results := hc.Checks[0].History()
+			So(results, ShouldHaveLength, 3)
+			So(results[0].Status, ShouldEqual, StatusCritical)
+			So(results[0].Message, ShouldEqual, "run CRITICAL")
+			So(results[1].Status, ShouldEqual, StatusOK)
+			So(results[2].Status, ShouldEqual, StatusWarning)
+			So(results[2].Time, ShouldEqual, *hc.Checks[0].state.LastChecked())
+			So(results[0].Time.Before(results[2].Time), ShouldBeTrue)
+		})
+
+		Convey("When the health handler is called without history", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+
+			Convey("Then the history is not included in the response", func() {
+				So(history(w.Body.Bytes()), ShouldBeNil)
+			})
+		})
+
+		Convey("When the health handler is called with history=true", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?history=true", nil))
+
+			Convey("Then the history of each check is included in the response", func() {
+				So(history(w.Body.Bytes()), ShouldResemble, hc.Checks[0].History())
+			})
+
+			Convey("Then the history is not included in later responses without history", func() {
+				w := httptest.NewRecorder()
+				hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+				So(history(w.Body.Bytes()), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a health check that keeps no history", t, func() {
+		atomic.StoreInt32(&runs, 0)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+		hc.Tick(context.Background())
+
+		Convey("Then the history of the check is empty, and not included in the response when requested", func() {
+			So(hc.Checks[0].History(), ShouldBeEmpty)
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?history=true", nil))
+			So(history(w.Body.Bytes()), ShouldBeNil)
+		})
+	})
+}
+
+func TestHandlerStatusCodes(t *testing.T) {
+	Convey("Given a health check configured to return 200 for warning and 503 for critical", t, func() {
+		now := time.Now().UTC()
+		handle := func(status string) int {
+			statuses := []CheckState{
+				{name: "Some App 1", status: status, message: "message", statusCode: 200, lastChecked: &now},
+			}
+			hc := createHealthCheck(statuses, now.Add(-time.Hour), time.Minute, true)
+			hc.timeOfFirstCriticalError = now.Add(-time.Hour)
+			hc.statusCodes = StatusCodes{Warning: http.StatusOK, Critical: http.StatusServiceUnavailable}
+
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+			return w.Code
+		}
+
+		Convey("Then the default code is returned while the app is OK", func() {
+			So(handle(StatusOK), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Then the configured code is returned while the app is warning", func() {
+			So(handle(StatusWarning), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Then the configured code is returned while the app is critical", func() {
+			So(handle(StatusCritical), ShouldEqual, http.StatusServiceUnavailable)
+		})
+	})
+
+	Convey("Given a set of status codes with no code for warning", t, func() {
+		codes := StatusCodes{Critical: http.StatusServiceUnavailable}
+
+		Convey("Then the default code is used for warning", func() {
+			So(codes.code(StatusOK), ShouldEqual, http.StatusOK)
+			So(codes.code(StatusWarning), ShouldEqual, http.StatusTooManyRequests)
+			So(codes.code(StatusCritical), ShouldEqual, http.StatusServiceUnavailable)
+		})
+	})
+}
+
+func TestHandlerResponseShape(t *testing.T) {
+	now := time.Now().UTC()
+	statuses := []CheckState{
+		{name: "Some App 1", status: StatusOK, message: "Everything is ok", statusCode: 200, lastChecked: &now},
+	}
+	body := func(w *httptest.ResponseRecorder) map[string]json.RawMessage {
+		var fields map[string]json.RawMessage
+		So(json.Unmarshal(w.Body.Bytes(), &fields), ShouldBeNil)
+		return fields
+	}
+
+	Convey("Given a health check configured without check details", t, func() {
+		hc, err := New(testVersion, criticalTimeout, interval, WithoutCheckDetails())
+		So(err, ShouldBeNil)
+		hc.Checks = createChecksSlice(statuses, true)
+
+		Convey("When the health handler is called", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+
+			Convey("Then the response reports the overall status without the checks", func() {
+				fields := body(w)
+				So(w.Code, ShouldEqual, http.StatusOK)
+				So(string(fields["status"]), ShouldEqual, `"OK"`)
+				So(string(fields["checks"]), ShouldEqual, "[]")
+				So(fields, ShouldContainKey, "version")
+			})
+
+			Convey("Then the checks of the health check are unchanged", func() {
+				So(hc.Checks, ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("Given a health check configured without version information", t, func() {
+		hc, err := New(testVersion, criticalTimeout, interval, WithoutVersion())
+		So(err, ShouldBeNil)
+		hc.Checks = createChecksSlice(statuses, true)
+
+		Convey("When the health handler is called", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+
+			Convey("Then the response omits the version but reports the checks", func() {
+				fields := body(w)
+				So(fields, ShouldNotContainKey, "version")
+				So(string(fields["status"]), ShouldEqual, `"OK"`)
+				So(fields, ShouldContainKey, "checks")
+				So(string(fields["checks"]), ShouldNotEqual, "[]")
+			})
+
+			Convey("Then the version of the health check is unchanged", func() {
+				So(hc.Version, ShouldResemble, testVersion)
+			})
+		})
+
+		Convey("When the health handler encodes the application/health+json format", func() {
+			hc.encoder = HealthJSONEncoder{}
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+
+			Convey("Then the response omits the version and release", func() {
+				fields := body(w)
+				So(fields, ShouldNotContainKey, "version")
+				So(fields, ShouldNotContainKey, "releaseId")
+			})
+		})
+	})
+}
@@ -0,0 +1,50 @@
+package hctest
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// AlwaysOK is a checker that always records an OK status
+func AlwaysOK(ctx context.Context, state *health.CheckState) error {
+	return state.Update(health.StatusOK, "ok", 0)
+}
+
+// AlwaysCritical is a checker that always records a CRITICAL status
+func AlwaysCritical(ctx context.Context, state *health.CheckState) error {
+	return state.Update(health.StatusCritical, "critical", 0)
+}
+
+// FlakyEveryN returns a checker that records a CRITICAL status on every nth run, and an OK status on every other run,
+// e.g. for testing retries or a record filter. It is safe to run concurrently.
+func FlakyEveryN(n int) health.Checker {
+	var runs int64
+	return func(ctx context.Context, state *health.CheckState) error {
+		run := atomic.AddInt64(&runs, 1)
+		if n > 0 && run%int64(n) == 0 {
+			return state.Update(health.StatusCritical, fmt.Sprintf("failed on run %d", run), 0)
+		}
+		return state.Update(health.StatusOK, "ok", 0)
+	}
+}
+
+// SlowChecker returns a checker that takes the provided duration to record an OK status, e.g. for testing the timeout
+// of a check or shutting down with checks in flight. If its context is done first, it returns the error of the context
+// without recording a status.
+func SlowChecker(d time.Duration) health.Checker {
+	return func(ctx context.Context, state *health.CheckState) error {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		return state.Update(health.StatusOK, "ok", 0)
+	}
+}
@@ -0,0 +1,55 @@
+package hctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCheckers(t *testing.T) {
+	Convey("Given a check state", t, func() {
+		ctx := context.Background()
+		state := health.NewCheckState("check")
+
+		Convey("Then AlwaysOK records an OK status", func() {
+			So(AlwaysOK(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, health.StatusOK)
+		})
+
+		Convey("Then AlwaysCritical records a CRITICAL status", func() {
+			So(AlwaysCritical(ctx, state), ShouldBeNil)
+			So(state.Status(), ShouldEqual, health.StatusCritical)
+		})
+
+		Convey("Then a checker that is flaky every 3 runs records a CRITICAL status on every third run", func() {
+			checker := FlakyEveryN(3)
+			var statuses []string
+			for i := 0; i < 6; i++ {
+				So(checker(ctx, state), ShouldBeNil)
+				statuses = append(statuses, state.Status())
+			}
+			So(statuses, ShouldResemble, []string{
+				health.StatusOK, health.StatusOK, health.StatusCritical,
+				health.StatusOK, health.StatusOK, health.StatusCritical,
+			})
+		})
+
+		Convey("Then a slow checker records an OK status once its duration has passed", func() {
+			start := time.Now()
+			So(SlowChecker(20*time.Millisecond)(ctx, state), ShouldBeNil)
+			So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 20*time.Millisecond)
+			So(state.Status(), ShouldEqual, health.StatusOK)
+		})
+
+		Convey("Then a slow checker whose context is done first returns the error of the context without recording a status", func() {
+			ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+			defer cancel()
+			err := SlowChecker(time.Minute)(ctx, state)
+			So(err == context.DeadlineExceeded, ShouldBeTrue)
+			So(state.Status(), ShouldBeEmpty)
+		})
+	})
+}
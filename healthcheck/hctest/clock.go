@@ -0,0 +1,106 @@
+package hctest
+
+import (
+	"sync"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// FakeClock is a health.Clock whose time only passes when it is advanced, for testing the critical timeout and the
+// scheduling of checks without waiting for real time to pass. Configure a health check to use it with health.WithClock.
+type FakeClock struct {
+	mutex   *sync.Mutex
+	now     time.Time
+	tickers []*fakeTicker
+}
+
+// fakeTicker is a health.Ticker that ticks as its FakeClock is advanced
+type fakeTicker struct {
+	clock    *FakeClock
+	interval time.Duration
+	next     time.Time
+	c        chan time.Time
+}
+
+// NewFakeClock returns a FakeClock whose current time is the provided time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{
+		mutex: &sync.Mutex{},
+		now:   now,
+	}
+}
+
+// Now returns the current time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.now
+}
+
+// NewTicker returns a ticker that ticks each time the clock is advanced past the provided interval since its last
+// tick. As with time.NewTicker, it panics if the interval is not positive.
+func (c *FakeClock) NewTicker(d time.Duration) health.Ticker {
+	if d <= 0 {
+		panic("non-positive interval for FakeClock.NewTicker")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	ticker := &fakeTicker{
+		clock:    c,
+		interval: d,
+		next:     c.now.Add(d),
+		c:        make(chan time.Time, 1),
+	}
+	c.tickers = append(c.tickers, ticker)
+	return ticker
+}
+
+// Advance moves the time of the clock on by the provided duration, ticking each ticker whose interval has passed.
+// As with time.Ticker, a tick is dropped if the previous tick has not yet been received, so a ticker ticks at most
+// once for each call, however many of its intervals have passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, ticker := range c.tickers {
+		for !ticker.next.After(c.now) {
+			select {
+			case ticker.c <- ticker.next:
+			default:
+			}
+			ticker.next = ticker.next.Add(ticker.interval)
+		}
+	}
+}
+
+// Tickers returns the number of tickers of the clock that have not been stopped, e.g. to wait for a health check
+// to start or stop the tickers of its checks
+func (c *FakeClock) Tickers() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return len(c.tickers)
+}
+
+// C returns the channel on which the ticks are delivered
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.c
+}
+
+// Stop turns off the ticker, so that it no longer ticks as its clock is advanced
+func (t *fakeTicker) Stop() {
+	t.clock.mutex.Lock()
+	defer t.clock.mutex.Unlock()
+
+	for i, ticker := range t.clock.tickers {
+		if ticker == t {
+			t.clock.tickers = append(t.clock.tickers[:i:i], t.clock.tickers[i+1:]...)
+			return
+		}
+	}
+}
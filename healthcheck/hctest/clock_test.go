@@ -0,0 +1,80 @@
+package hctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFakeClock(t *testing.T) {
+	t0 := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	Convey("Given a fake clock with a ticker", t, func() {
+		clock := NewFakeClock(t0)
+		ticker := clock.NewTicker(time.Minute)
+		So(clock.Tickers(), ShouldEqual, 1)
+
+		Convey("Then time does not pass until the clock is advanced", func() {
+			So(clock.Now(), ShouldEqual, t0)
+			clock.Advance(30 * time.Second)
+			So(clock.Now(), ShouldEqual, t0.Add(30*time.Second))
+		})
+
+		Convey("When the clock is advanced by less than the interval", func() {
+			clock.Advance(59 * time.Second)
+
+			Convey("Then the ticker does not tick", func() {
+				So(ticker.C(), ShouldBeEmpty)
+			})
+		})
+
+		Convey("When the clock is advanced by several intervals without the ticks being received", func() {
+			clock.Advance(3 * time.Minute)
+
+			Convey("Then the ticker ticks once, at the first interval", func() {
+				So(ticker.C(), ShouldHaveLength, 1)
+				So(<-ticker.C(), ShouldEqual, t0.Add(time.Minute))
+			})
+		})
+
+		Convey("When the ticker is stopped", func() {
+			ticker.Stop()
+			clock.Advance(time.Minute)
+
+			Convey("Then it no longer ticks", func() {
+				So(clock.Tickers(), ShouldEqual, 0)
+				So(ticker.C(), ShouldBeEmpty)
+			})
+		})
+	})
+
+	Convey("Given a health check using a fake clock with a failing check", t, func() {
+		ctx := context.Background()
+		clock := NewFakeClock(t0)
+		hc, err := health.New(health.VersionInfo{}, 10*time.Minute, time.Minute, health.WithClock(clock))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", func(ctx context.Context, state *health.CheckState) error {
+			return state.Update(health.StatusCritical, "unreachable", 0)
+		}), ShouldBeNil)
+		hc.Tick(ctx)
+
+		Convey("Then the check is recorded at the time of the clock", func() {
+			So(*hc.GetState().Checks[0].State().LastChecked(), ShouldEqual, t0)
+		})
+
+		Convey("Then the app is not critical within the critical timeout", func() {
+			clock.Advance(9 * time.Minute)
+			hc.Tick(ctx)
+			So(hc.GetStatus(ctx), ShouldEqual, health.StatusWarning)
+		})
+
+		Convey("Then the app is critical once the critical timeout has passed", func() {
+			clock.Advance(11 * time.Minute)
+			hc.Tick(ctx)
+			So(hc.GetStatus(ctx), ShouldEqual, health.StatusCritical)
+		})
+	})
+}
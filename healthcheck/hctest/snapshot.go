@@ -0,0 +1,88 @@
+// Package hctest provides helpers for testing apps that use the health check library
+package hctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// Snapshot is the overall status of a health check and the states of its checks at a point in time, for making
+// assertions in tests
+type Snapshot struct {
+	Status string
+	Checks map[string]*health.CheckState
+}
+
+// TakeSnapshot captures the current state of the provided health check
+func TakeSnapshot(hc *health.HealthCheck) Snapshot {
+	state := hc.GetState()
+
+	snap := Snapshot{
+		Status: state.Status,
+		Checks: make(map[string]*health.CheckState, len(state.Checks)),
+	}
+	for _, check := range state.Checks {
+		snap.Checks[check.State().Name()] = check.State()
+	}
+	return snap
+}
+
+// AssertOverall fails the test if the overall status in the snapshot is not the provided status
+func AssertOverall(t testing.TB, snap Snapshot, status string) {
+	t.Helper()
+
+	if snap.Status != status {
+		t.Errorf("expected overall status %s but was %s", status, snap.Status)
+	}
+}
+
+// AssertCheckStatus fails the test if the check with the provided name is not in the snapshot, or its status in the
+// snapshot is not the provided status
+func AssertCheckStatus(t testing.TB, snap Snapshot, name, status string) {
+	t.Helper()
+
+	state, ok := snap.Checks[name]
+	if !ok {
+		t.Errorf("expected check %q to have status %s but there is no such check", name, status)
+		return
+	}
+	if state.Status() != status {
+		t.Errorf("expected check %q to have status %s but was %s with message %q", name, status, state.Status(), state.Message())
+	}
+}
+
+// AssertCheckTransition fails the test if the check with the provided name did not go from the from status in the
+// before snapshot to the to status in the after snapshot
+func AssertCheckTransition(t testing.TB, before, after Snapshot, name, from, to string) {
+	t.Helper()
+
+	AssertCheckStatus(t, before, name, from)
+	AssertCheckStatus(t, after, name, to)
+}
+
+// waitPollInterval is how often WaitForStatus recalculates the overall status
+const waitPollInterval = 10 * time.Millisecond
+
+// WaitForStatus waits for the overall status of the provided health check to be the provided status, failing the test
+// if it is not within the provided timeout. The status is recalculated as it is polled, as by GetStatus, so that the
+// critical timeout is applied.
+func WaitForStatus(t testing.TB, hc *health.HealthCheck, status string, timeout time.Duration) {
+	t.Helper()
+
+	ctx := context.Background()
+	deadline := time.Now().Add(timeout)
+	for {
+		current := hc.GetStatus(ctx)
+		if current == status {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Errorf("expected overall status %s within %s but was %s", status, timeout, current)
+			return
+		}
+		time.Sleep(waitPollInterval)
+	}
+}
@@ -0,0 +1,95 @@
+package hctest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// recorder records test failures instead of failing the test
+type recorder struct {
+	*testing.T
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestSnapshot(t *testing.T) {
+	Convey("Given a started health check with a check whose status can be changed", t, func() {
+		var mutex sync.Mutex
+		status := health.StatusOK
+		checker := func(ctx context.Context, state *health.CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(status, "", 0)
+		}
+
+		hc, err := health.New(health.VersionInfo{}, time.Minute, 10*time.Millisecond)
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", checker), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		time.Sleep(50 * time.Millisecond)
+		before := TakeSnapshot(&hc)
+
+		Convey("When the status of the check changes", func() {
+			mutex.Lock()
+			status = health.StatusWarning
+			mutex.Unlock()
+			time.Sleep(50 * time.Millisecond)
+			after := TakeSnapshot(&hc)
+
+			Convey("Then assertions matching the snapshots pass", func() {
+				r := &recorder{T: t}
+				AssertOverall(r, before, health.StatusOK)
+				AssertCheckStatus(r, after, "mongo", health.StatusWarning)
+				AssertCheckTransition(r, before, after, "mongo", health.StatusOK, health.StatusWarning)
+				So(r.failures, ShouldBeEmpty)
+			})
+
+			Convey("Then assertions that do not match the snapshots fail with a clear message", func() {
+				r := &recorder{T: t}
+				AssertOverall(r, after, health.StatusCritical)
+				AssertCheckStatus(r, after, "mongo", health.StatusOK)
+				AssertCheckStatus(r, after, "kafka", health.StatusOK)
+				So(r.failures, ShouldResemble, []string{
+					"expected overall status CRITICAL but was WARNING",
+					`expected check "mongo" to have status OK but was WARNING with message ""`,
+					`expected check "kafka" to have status OK but there is no such check`,
+				})
+			})
+		})
+	})
+}
+
+func TestWaitForStatus(t *testing.T) {
+	Convey("Given a started health check with a check that is OK after a delay", t, func() {
+		hc, err := health.New(health.VersionInfo{}, time.Minute, time.Second)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", SlowChecker(20*time.Millisecond)), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		Convey("Then waiting for the status it reaches within the timeout passes", func() {
+			r := &recorder{T: t}
+			WaitForStatus(r, &hc, health.StatusOK, time.Second)
+			So(r.failures, ShouldBeEmpty)
+		})
+
+		Convey("Then waiting for a status it does not reach within the timeout fails with a clear message", func() {
+			r := &recorder{T: t}
+			WaitForStatus(r, &hc, health.StatusCritical, 50*time.Millisecond)
+			So(r.failures, ShouldResemble, []string{"expected overall status CRITICAL within 50ms but was OK"})
+		})
+	})
+}
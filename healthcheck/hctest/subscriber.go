@@ -0,0 +1,56 @@
+package hctest
+
+import (
+	"context"
+	"sync"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// StubSubscriber records the changes of the overall health status and of the status of checks that it is notified of,
+// for asserting on the notifications sent by a health check. Register it with health.WithStatusListener using its
+// StatusListener method, and with SubscribeChecks using its CheckStatusListener method.
+type StubSubscriber struct {
+	mutex        *sync.Mutex
+	changes      []health.StatusChange
+	checkChanges []health.CheckStatusChange
+}
+
+// NewStubSubscriber returns a StubSubscriber that has not been notified of any changes
+func NewStubSubscriber() *StubSubscriber {
+	return &StubSubscriber{
+		mutex: &sync.Mutex{},
+	}
+}
+
+// StatusListener records the provided change of the overall health status. It is a health.StatusListener.
+func (s *StubSubscriber) StatusListener(ctx context.Context, change health.StatusChange, hc health.HealthCheck) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.changes = append(s.changes, change)
+}
+
+// CheckStatusListener records the provided change of the status of a check. It is a health.CheckStatusListener.
+func (s *StubSubscriber) CheckStatusListener(ctx context.Context, change health.CheckStatusChange) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.checkChanges = append(s.checkChanges, change)
+}
+
+// Changes returns the changes of the overall health status recorded so far, in the order they were notified
+func (s *StubSubscriber) Changes() []health.StatusChange {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]health.StatusChange{}, s.changes...)
+}
+
+// CheckChanges returns the changes of the status of checks recorded so far, in the order they were notified
+func (s *StubSubscriber) CheckChanges() []health.CheckStatusChange {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]health.CheckStatusChange{}, s.checkChanges...)
+}
@@ -0,0 +1,45 @@
+package hctest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStubSubscriber(t *testing.T) {
+	Convey("Given a health check with a stub subscriber and a check that fails on every second run", t, func() {
+		ctx := context.Background()
+		sub := NewStubSubscriber()
+		clock := NewFakeClock(time.Now())
+		hc, err := health.New(health.VersionInfo{}, time.Minute, time.Minute, health.WithStatusListener(sub.StatusListener),
+			health.WithClock(clock))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", FlakyEveryN(2)), ShouldBeNil)
+		hc.SubscribeChecks(sub.CheckStatusListener)
+
+		Convey("When the check runs twice", func() {
+			hc.Tick(ctx)
+			clock.Advance(time.Minute)
+			hc.Tick(ctx)
+
+			Convey("Then the subscriber has recorded the changes of the status of the check", func() {
+				changes := sub.CheckChanges()
+				So(changes, ShouldHaveLength, 2)
+				So(changes[0].Check, ShouldEqual, "mongo")
+				So(changes[0].Current, ShouldEqual, health.StatusOK)
+				So(changes[1].Previous, ShouldEqual, health.StatusOK)
+				So(changes[1].Current, ShouldEqual, health.StatusCritical)
+			})
+
+			Convey("Then the subscriber has recorded the changes of the overall status", func() {
+				changes := sub.Changes()
+				So(changes, ShouldHaveLength, 2)
+				So(changes[0].Current, ShouldEqual, health.StatusOK)
+				So(changes[1].Current, ShouldEqual, health.StatusWarning)
+			})
+		})
+	})
+}
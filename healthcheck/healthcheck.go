@@ -3,7 +3,9 @@ package healthcheck
 import (
 	"context"
 	"errors"
+	"fmt"
 	"runtime"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -13,13 +15,49 @@ const language = "go"
 
 // HealthCheck represents the app's health check, including its component checks
 type HealthCheck struct {
-	Status                   string        `json:"status"`
-	Version                  VersionInfo   `json:"version"`
-	Uptime                   time.Duration `json:"uptime"`
-	StartTime                time.Time     `json:"start_time"`
-	Checks                   []*Check      `json:"checks"`
-	interval                 time.Duration
-	criticalErrorTimeout     time.Duration
+	Status                  string            `json:"status"`
+	Version                 VersionInfo       `json:"version"`
+	Uptime                  time.Duration     `json:"uptime"`
+	StartTime               time.Time         `json:"start_time"`
+	StopTime                *time.Time        `json:"stop_time,omitempty"`
+	Override                *Override         `json:"override,omitempty"`
+	Groups                  map[string]string `json:"groups,omitempty"`
+	Checks                  []*Check          `json:"checks"`
+	mutex                   *sync.RWMutex
+	interval                time.Duration
+	jitter                  float64
+	staggeredStart          float64
+	criticalErrorTimeout    time.Duration
+	criticalFailures        int
+	softStartWindow         time.Duration
+	encoder                 Encoder
+	statusNames             *StatusNames
+	relativeTimes           bool
+	statusCodes             StatusCodes
+	omitCheckDetails        bool
+	omitVersion             bool
+	legacyDurations         bool
+	paths                   Paths
+	refreshOnRequest        bool
+	historySize             int
+	runHook                 RunHook
+	clock                   Clock
+	panicPolicy             PanicPolicy
+	probeBudget             *probeBudget
+	workerPool              *workerPool
+	logger                  Logger
+	watchdogMissedIntervals int
+	staleIntervals          int
+	// staleChanges are the transitions of checks marked as stale that are yet to be notified
+	staleChanges             []CheckStatusChange
+	stateStore               StateStore
+	stateSaver               *stateSaver
+	watchdogClosing          chan bool
+	statusListeners          []StatusListener
+	statusSubscriptions      []*statusSubscription
+	listenerQueue            *listenerQueue
+	tickerListeners          []TickerListener
+	checkSubscriptions       []*checkSubscription
 	timeOfFirstCriticalError time.Time
 	tickers                  []*ticker
 	context                  context.Context
@@ -33,21 +71,79 @@ type VersionInfo struct {
 	Language        string    `json:"language"`
 	LanguageVersion string    `json:"language_version"`
 	Version         string    `json:"version"`
+	GitDirty        bool      `json:"git_dirty,omitempty"`
+	Module          string    `json:"module,omitempty"`
 }
 
 // New returns a new instantiated HealthCheck object. Caller to provide:
 // version information of the app,
 // criticalTimeout for how long to wait until an unhealthy dependent propagates its state to make this app unhealthy
 // interval in which to check health of dependencies
-func New(version VersionInfo, criticalTimeout, interval time.Duration) HealthCheck {
-	return HealthCheck{
+// opts to optionally configure further behaviour of the health check
+// An error is returned if the interval or critical timeout is not positive, or if the critical timeout is less than
+// the interval. The critical timeout may be 0 if WithCriticalFailures is used, so that only the number of failures
+// makes the app critical.
+func New(version VersionInfo, criticalTimeout, interval time.Duration, opts ...Option) (HealthCheck, error) {
+	hc := HealthCheck{
 		Checks:               []*Check{},
 		Version:              version,
+		mutex:                &sync.RWMutex{},
 		criticalErrorTimeout: criticalTimeout,
 		interval:             interval,
+		jitter:               defaultJitter,
 		tickers:              []*ticker{},
 		tickersWaitgroup:     &sync.WaitGroup{},
+		encoder:              JSONEncoder{},
+		clock:                realClock{},
 	}
+
+	for _, opt := range opts {
+		opt(&hc)
+	}
+	if hc.listenerQueue != nil {
+		hc.listenerQueue.logger = hc.logger
+	}
+	if hc.stateSaver != nil {
+		hc.stateSaver.logger = hc.logger
+	}
+
+	if err := hc.validateConfig(); err != nil {
+		return HealthCheck{}, err
+	}
+	return hc, nil
+}
+
+// validateConfig returns an error if the interval, jitter, listener queue, probe budget, maximum concurrent checks or
+// critical timeout of the health check are invalid
+func (hc *HealthCheck) validateConfig() error {
+	if hc.interval <= 0 {
+		return fmt.Errorf("invalid interval %s, must be positive", hc.interval)
+	}
+	if hc.jitter < 0 || hc.jitter >= 1 {
+		return fmt.Errorf("invalid jitter %v, must be at least 0 and less than 1", hc.jitter)
+	}
+	if hc.listenerQueue != nil && hc.listenerQueue.size < 1 {
+		return fmt.Errorf("invalid listener queue size %d, must be positive", hc.listenerQueue.size)
+	}
+	if hc.probeBudget != nil && hc.probeBudget.capacity < 1 {
+		return fmt.Errorf("invalid probe budget of %v probes, must be positive", hc.probeBudget.capacity)
+	}
+	if hc.probeBudget != nil && hc.probeBudget.per <= 0 {
+		return fmt.Errorf("invalid probe budget window %s, must be positive", hc.probeBudget.per)
+	}
+	if hc.workerPool != nil && hc.workerPool.size < 1 {
+		return fmt.Errorf("invalid maximum concurrent checks %d, must be positive", hc.workerPool.size)
+	}
+	if hc.isFailureCountOnly() {
+		return nil
+	}
+	if hc.criticalErrorTimeout <= 0 {
+		return fmt.Errorf("invalid critical timeout %s, must be positive", hc.criticalErrorTimeout)
+	}
+	if hc.criticalErrorTimeout < hc.interval {
+		return fmt.Errorf("invalid critical timeout %s, must not be less than the interval %s", hc.criticalErrorTimeout, hc.interval)
+	}
+	return nil
 }
 
 // NewVersionInfo returns a health check version info object. Caller to provide:
@@ -71,40 +167,399 @@ func NewVersionInfo(buildTime, gitCommit, version string) (VersionInfo, error) {
 	return versionInfo, nil
 }
 
-// AddCheck adds a provided checker to the health check
-func (hc *HealthCheck) AddCheck(name string, checker Checker) (err error) {
-	check, err := NewCheck(name, checker)
+// AddCheck adds a provided checker to the health check, with optional configuration of the check
+func (hc *HealthCheck) AddCheck(name string, checker Checker, opts ...CheckOption) (err error) {
+	check, err := NewCheck(name, checker, opts...)
 	if err != nil {
 		return err
 	}
 
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
 	hc.Checks = append(hc.Checks, check)
+	hc.tickers = append(hc.tickers, hc.newTicker(check))
+	if hc.isStarted() {
+		hc.notifyTickerEvent(TickerStarted, check)
+	}
 
-	ticker := createTicker(hc.interval, check)
-	hc.tickers = append(hc.tickers, ticker)
+	return nil
+}
 
-	if hc.context != nil {
-		ticker.start(hc.context, hc.tickersWaitgroup)
+// AddNonCriticalCheck adds a check that never makes the overall health status critical, as a failing check added
+// with WithNonCritical
+func (hc *HealthCheck) AddNonCriticalCheck(name string, checker Checker, opts ...CheckOption) (err error) {
+	return hc.AddCheck(name, checker, append(opts, WithNonCritical())...)
+}
+
+// RemoveCheck stops running the check with the provided name and removes it from the health check, e.g. when the
+// client of an optional dependency is closed, so that it no longer contributes to the overall health status. It is
+// safe to call while the health check is running, and waits for any run of the check in progress to finish, whose
+// result is discarded. An error is returned if there is no check with the provided name.
+func (hc *HealthCheck) RemoveCheck(name string) error {
+	hc.mutex.Lock()
+
+	index := -1
+	for i, check := range hc.Checks {
+		if check.state.Name() == name {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		hc.mutex.Unlock()
+		return fmt.Errorf("no check with name: %s", name)
 	}
 
+	var removed *ticker
+	for i, ticker := range hc.tickers {
+		if ticker.check == hc.Checks[index] {
+			removed = ticker
+			hc.tickers = append(hc.tickers[:i:i], hc.tickers[i+1:]...)
+			break
+		}
+	}
+	hc.Checks = append(hc.Checks[:index:index], hc.Checks[index+1:]...)
+
+	started, ctx := hc.isStarted(), hc.context
+	if removed != nil {
+		if started {
+			hc.stopTicker(removed)
+		} else {
+			removed.timeTicker.Stop()
+		}
+	}
+	hc.mutex.Unlock()
+
+	if removed != nil {
+		removed.checksInFlight.Wait()
+	}
+	if started {
+		hc.updateStatus(ctx, "")
+	}
+
+	return nil
+}
+
+// ReplaceChecks atomically swaps the registered checks for the provided ones. Tickers are stopped for checks
+// that are no longer present and started for new checks. Checks that persist across the swap (matched by name)
+// keep their current state, so their status and timestamps are not reset by a config reload.
+func (hc *HealthCheck) ReplaceChecks(checks []*Check) error {
+	names := make(map[string]bool, len(checks))
+	for _, check := range checks {
+		if check == nil || check.checker == nil {
+			return errors.New("expected checker but none provided")
+		}
+		name := check.state.Name()
+		if names[name] {
+			return fmt.Errorf("duplicate check name: %s", name)
+		}
+		names[name] = true
+	}
+
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	existing := make(map[string]*Check, len(hc.Checks))
+	for _, check := range hc.Checks {
+		existing[check.state.Name()] = check
+	}
+
+	for _, ticker := range hc.tickers {
+		if hc.isStarted() {
+			hc.stopTicker(ticker)
+		} else {
+			ticker.timeTicker.Stop()
+		}
+	}
+
+	newChecks := make([]*Check, 0, len(checks))
+	newTickers := make([]*ticker, 0, len(checks))
+	for _, check := range checks {
+		if previous, ok := existing[check.state.Name()]; ok {
+			// the group and labels of the check may have been changed by the config reload
+			previous.state.setGroup(check.state.Group())
+			previous.state.setLabels(check.state.Labels())
+			check.state = previous.state
+		}
+		newChecks = append(newChecks, check)
+		newTickers = append(newTickers, hc.newTicker(check))
+		if hc.isStarted() {
+			hc.notifyTickerEvent(TickerStarted, check)
+		}
+	}
+
+	hc.Checks = newChecks
+	hc.tickers = newTickers
+
 	return nil
 }
 
+// NextRun returns the time at which the check with the provided name is next due to run.
+// False is returned if there is no such check or if its ticker is not running.
+func (hc *HealthCheck) NextRun(name string) (time.Time, bool) {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	for _, ticker := range hc.tickers {
+		if ticker.check.state.Name() == name {
+			return ticker.nextRun()
+		}
+	}
+	return time.Time{}, false
+}
+
+// LastChanged returns a copy of the state of the check whose status most recently changed, and the time at which
+// it changed. False is returned if no check has changed status since it was first recorded.
+func (hc *HealthCheck) LastChanged() (CheckState, time.Time, bool) {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	var latest *CheckState
+	var changed time.Time
+	for _, check := range hc.Checks {
+		state := check.state.clone()
+		if state.lastStatusChange != nil && state.lastStatusChange.After(changed) {
+			latest = state
+			changed = *state.lastStatusChange
+		}
+	}
+
+	if latest == nil {
+		return CheckState{}, time.Time{}, false
+	}
+	return *latest, changed, true
+}
+
+// GetState returns a consistent copy of the health check and the states of its checks, that is safe to read and
+// marshal while the checks continue to run. The copy is for reading only and cannot be started or stopped.
+func (hc *HealthCheck) GetState() HealthCheck {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	state := *hc
+	state.Checks = make([]*Check, 0, len(hc.Checks))
+	for _, check := range hc.Checks {
+		c := check.clone()
+		state.Checks = append(state.Checks, &c)
+	}
+	state.mutex = &sync.RWMutex{}
+	state.tickers = nil
+	state.watchdogClosing = nil
+	return state
+}
+
+// statusOrder is the order in which statuses are sorted, from most to least severe
+var statusOrder = map[string]int{
+	StatusCritical: 0,
+	StatusWarning:  1,
+}
+
+// UnhealthyChecks returns copies of the checks whose most recently recorded status is not OK, with critical checks
+// before warning checks and otherwise in the order they were registered. Checks that have not yet run are not included.
+func (hc *HealthCheck) UnhealthyChecks() []Check {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	unhealthy := make([]Check, 0)
+	for _, check := range hc.Checks {
+		c := check.clone()
+		if c.state.status == StatusOK || c.state.lastChecked == nil {
+			continue
+		}
+		unhealthy = append(unhealthy, c)
+	}
+
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return statusOrder[unhealthy[i].state.status] < statusOrder[unhealthy[j].state.status]
+	})
+	return unhealthy
+}
+
+// SetCheckDebug sets whether every run of the check with the provided name is logged, including successful
+// runs and their durations, to help diagnose a single problem dependency without logging every check
+func (hc *HealthCheck) SetCheckDebug(name string, enabled bool) {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	for _, check := range hc.Checks {
+		if check.state.Name() == name {
+			check.setDebug(enabled)
+		}
+	}
+}
+
+// newTicker creates a ticker for the provided check, at the interval of the check if it has one or the interval of
+// the health check otherwise, starting it if the health check has already been started.
+// Callers must hold the write lock.
+func (hc *HealthCheck) newTicker(check *Check) *ticker {
+	interval := hc.interval
+	if check.interval > 0 {
+		interval = check.interval
+	}
+
+	check.state.setClock(hc.clock)
+	ticker := createTicker(interval, hc.jitter, check, hc.clock)
+	ticker.onUpdate = hc.updateStatus
+	ticker.onStatusChange = hc.notifyCheckStatusChange
+	ticker.panicPolicy = hc.panicPolicy
+	ticker.budget = hc.probeBudget
+	ticker.workers = hc.workerPool
+	ticker.gitCommit = hc.Version.GitCommit
+	ticker.logger = hc.logger
+	ticker.historySize = hc.historySize
+	ticker.runHook = hc.runHook
+	ticker.failedDependency = hc.failedDependency
+	ticker.staggeredStart = hc.staggeredStart
+	if hc.isStarted() {
+		ticker.start(hc.context, hc.tickersWaitgroup)
+	}
+	return ticker
+}
+
 // Start begins each ticker, this is used to run the health checks on dependent apps
 // takes argument context and should utilise contextWithCancel
 // Passing a nil context will cause errors during stop/app shutdown
+// Calling Start when already started does nothing. A health check that has been stopped can be started again, which
+// resumes its checks with new tickers.
 func (hc *HealthCheck) Start(ctx context.Context) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	if hc.isStarted() {
+		return
+	}
+
 	hc.context = ctx
-	hc.StartTime = time.Now().UTC()
-	for _, ticker := range hc.tickers {
-		ticker.start(ctx, hc.tickersWaitgroup)
+	hc.StartTime = hc.now()
+	hc.StopTime = nil
+	// the saved state is restored before the tickers run the checks, so that fresh results are recorded over it
+	hc.restoreState(ctx)
+	// checks left in flight by a previous shutdown that timed out are not waited for again
+	hc.tickersWaitgroup = &sync.WaitGroup{}
+	for i, ticker := range hc.tickers {
+		if ticker.isStopping() {
+			hc.tickers[i] = hc.newTicker(ticker.check)
+		} else {
+			ticker.start(ctx, hc.tickersWaitgroup)
+		}
+		hc.notifyTickerEvent(TickerStarted, ticker.check)
+	}
+
+	if hc.watchdogMissedIntervals > 0 {
+		hc.startWatchdog(ctx)
 	}
 }
 
-// Stop will cancel all tickers and thus stop all health checks
+// Stop will cancel all tickers and thus stop all health checks, waiting for any checks in flight to complete. The
+// uptime is frozen at the time the health check was stopped. Calling Stop when the health check has not been started,
+// or has already been stopped, does nothing.
 func (hc *HealthCheck) Stop() {
+	hc.Shutdown(context.Background())
+}
+
+// Shutdown stops all health checks as Stop does, but only waits for the checks in flight to complete until the
+// provided context is done, returning the error of the context if they have not. Checks still in flight are left to
+// complete in the background and their results are discarded. The health check can be started again once Shutdown
+// has returned, whether or not the checks in flight have completed.
+func (hc *HealthCheck) Shutdown(ctx context.Context) error {
+	hc.mutex.Lock()
+	if !hc.isStarted() {
+		hc.mutex.Unlock()
+		return nil
+	}
+
+	now := hc.now()
+	hc.StopTime = &now
+	hc.Uptime = hc.uptime(now) / time.Millisecond
+	hc.stopWatchdog()
+
+	// every ticker is signalled to stop before waiting for any of them, so that the time taken to stop is bounded by
+	// the slowest check in flight rather than the sum of them
+	stopping := make([]*ticker, 0, len(hc.tickers))
 	for _, ticker := range hc.tickers {
-		ticker.stop()
+		if ticker.isStopping() {
+			continue
+		}
+		ticker.signalStop()
+		hc.notifyTickerEvent(TickerStopped, ticker.check)
+		stopping = append(stopping, ticker)
+	}
+	wg := hc.tickersWaitgroup
+	hc.mutex.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		for _, ticker := range stopping {
+			<-ticker.closed
+		}
+		wg.Wait()
+		// the results recorded by the last runs of the checks are saved before the health check is stopped
+		if hc.stateSaver != nil {
+			hc.stateSaver.wait()
+		}
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isStarted returns true if the health check has been started and not since stopped. Callers must hold the lock.
+func (hc *HealthCheck) isStarted() bool {
+	return hc.context != nil && hc.StopTime == nil
+}
+
+// Tick runs each registered check that is due once, waiting for them to complete, so that the health check can be
+// driven by an external scheduler instead of its own tickers. A check is due if it has not yet run, or if its
+// interval, allowing for any backoff, has passed since it was last run. A check whose previous run is still in flight
+// is skipped. When using Tick, do not call Start.
+func (hc *HealthCheck) Tick(ctx context.Context) {
+	hc.runChecks(ctx, false)
+}
+
+// runChecks runs each check that is due once, or every check if forced, waiting for them to complete. A check whose
+// previous run, whether started by its ticker or by a previous call, is still in flight is skipped, so that two runs
+// of a check never record their results at the same time.
+func (hc *HealthCheck) runChecks(ctx context.Context, force bool) {
+	hc.mutex.RLock()
+	tickers := make([]*ticker, len(hc.tickers))
+	copy(tickers, hc.tickers)
+	hc.mutex.RUnlock()
+
+	now := hc.now()
+	wg := &sync.WaitGroup{}
+	done := make(chan bool, len(tickers))
+	for _, ticker := range tickers {
+		if !force && !ticker.isDueAt(now) {
+			continue
+		}
+		// a checker abandoned at its timeout that has still not returned is also skipped
+		if ticker.check.isRunning() || !ticker.claimRun() {
+			ticker.logEvent(ctx, levelWarn, "skipping check as its previous run is still in flight", nil, ticker.logData())
+			continue
+		}
+		if !ticker.takeBudget(ctx, now) {
+			ticker.releaseRun()
+			continue
+		}
+		ticker.setRun()
+		wg.Add(1)
+		go ticker.runCheck(ctx, wg, done)
+	}
+	wg.Wait()
+}
+
+// stopTicker stops the provided ticker, notifying the ticker listeners if it was running.
+// Callers must hold the write lock.
+func (hc *HealthCheck) stopTicker(ticker *ticker) {
+	if ticker.isStopping() {
+		return
 	}
-	hc.tickersWaitgroup.Wait()
+	ticker.stop()
+	hc.notifyTickerEvent(TickerStopped, ticker.check)
 }
@@ -2,8 +2,12 @@ package healthcheck
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"net/http/httptest"
 	"runtime"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -56,7 +60,8 @@ func TestNew(t *testing.T) {
 	Convey("Create a new Health Check", t, func() {
 		ctx := context.Background()
 		timeBeforeCreation := time.Now().UTC()
-		hc := New(version, criticalTimeout, interval)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
 		hc.Start(ctx)
 		defer hc.Stop()
 
@@ -74,8 +79,9 @@ func TestNew(t *testing.T) {
 	Convey("Create a new Health Check and add one good working check function", t, func() {
 		ctx := context.Background()
 		timeBeforeCreation := time.Now().UTC()
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck("check 1", checkFunc)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("check 1", checkFunc)
 		hc.Start(ctx)
 		defer hc.Stop()
 
@@ -101,7 +107,8 @@ func TestNew(t *testing.T) {
 	Convey("Create a new Health Check and add two good working check functions", t, func() {
 		ctx := context.Background()
 		timeBeforeCreation := time.Now().UTC()
-		hc := New(version, criticalTimeout, interval)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
 		err1 := hc.AddCheck("check 1", checkFunc)
 		err2 := hc.AddCheck("check 2", checkFunc)
 		hc.Start(ctx)
@@ -134,8 +141,9 @@ func TestNew(t *testing.T) {
 
 	Convey("Create a new Health Check and add a broken check function", t, func() {
 		ctx := context.Background()
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck("failing check", cfFail)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("failing check", cfFail)
 		hc.Start(ctx)
 		defer hc.Stop()
 
@@ -147,15 +155,18 @@ func TestNew(t *testing.T) {
 			s := *hc.tickers[0].check.state
 			hc.tickers[0].check.state.mutex.RUnlock()
 
-			s.mutex = nil
-			So(s, ShouldResemble, CheckState{name: "failing check"})
+			So(s.duration, ShouldBeGreaterThan, 0)
+			So(s.nextCheck, ShouldNotBeNil)
+			s.mutex, s.duration, s.nextCheck = nil, 0, nil
+			So(s, ShouldResemble, CheckState{name: "failing check", lastError: "checker failed to run for cfFail", clock: realClock{}})
 		})
 	})
 
 	Convey("Given a Health Check with a cancellable context", t, func() {
 		ctx, cancel := context.WithCancel(context.Background())
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck("cancellable", cfFail)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("cancellable", cfFail)
 		hc.Start(ctx)
 		// no `defer hc.Stop()` because of `cancel()`
 
@@ -166,7 +177,9 @@ func TestNew(t *testing.T) {
 			time.Sleep(2 * interval)
 
 			So(len(hc.tickers), ShouldEqual, 1)
+			hc.Checks[0].state.mutex.RLock()
 			So(hc.tickers[0].check.state, ShouldPointTo, hc.Checks[0].state)
+			hc.Checks[0].state.mutex.RUnlock()
 			So(hc.tickers[0].isStopping(), ShouldBeFalse)
 
 			cancel()
@@ -186,8 +199,9 @@ func TestNew(t *testing.T) {
 		statusCode := 200
 
 		ctx := context.Background()
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck(name, cfFail)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		err = hc.AddCheck(name, cfFail)
 		hc.Checks[0].state.status = status
 		hc.Checks[0].state.message = message
 		hc.Checks[0].state.statusCode = statusCode
@@ -208,6 +222,7 @@ func TestNew(t *testing.T) {
 			So(hc.Checks[0].state.statusCode, ShouldEqual, statusCode)
 			So(hc.Checks[0].state.lastChecked, ShouldEqual, &now)
 			So(hc.Checks[0].state.lastSuccess, ShouldEqual, &now)
+			So(hc.Checks[0].state.lastError, ShouldEqual, "checker failed to run for cfFail")
 			hc.tickers[0].check.state.mutex.RUnlock()
 		})
 	})
@@ -220,7 +235,8 @@ func TestAddCheck(t *testing.T) {
 
 	Convey("Given a Health Check without any registered checks", t, func() {
 		ctx := context.Background()
-		hc := New(version, criticalTimeout, interval)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
 
 		Convey("After adding a check there should be one timer on start", func() {
 			err := hc.AddCheck("check 1", cf)
@@ -236,8 +252,9 @@ func TestAddCheck(t *testing.T) {
 
 	Convey("Given a Health Check with 1 registered check", t, func() {
 		ctx := context.Background()
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck("check 1", cf)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("check 1", cf)
 
 		So(err, ShouldBeNil)
 
@@ -254,8 +271,9 @@ func TestAddCheck(t *testing.T) {
 	})
 
 	Convey("Given a Health Check with 1 check that is started", t, func() {
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck("check 1", cf)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("check 1", cf)
 		hc.Start(context.Background())
 		defer hc.Stop()
 
@@ -273,7 +291,8 @@ func TestAddCheck(t *testing.T) {
 
 	Convey("Given a Health Check without any registered checks", t, func() {
 		ctx := context.Background()
-		hc := New(version, criticalTimeout, interval)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
 
 		Convey("Then adding a check with a nil checker function should fail", func() {
 			err := hc.AddCheck("nil check", nil)
@@ -288,6 +307,104 @@ func TestAddCheck(t *testing.T) {
 	})
 }
 
+func TestAddCheckThatPanics(t *testing.T) {
+	panicking := func(ctx context.Context, state *CheckState) error {
+		panic("nil map")
+	}
+	ok := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "ok", 0)
+	}
+
+	Convey("Given a started Health Check with a check that panics", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("panicking", panicking), ShouldBeNil)
+		So(hc.AddCheck("ok", ok), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		Convey("When the checks have run", func() {
+			time.Sleep(2 * interval)
+			state := hc.GetState()
+
+			Convey("Then the panicking check is recorded as critical", func() {
+				panicked := state.Checks[0].state
+				So(panicked.Status(), ShouldEqual, StatusCritical)
+				So(panicked.Message(), ShouldEqual, "checker panicked: nil map")
+				So(panicked.LastChecked(), ShouldNotBeNil)
+				So(panicked.LastFailure(), ShouldNotBeNil)
+			})
+
+			Convey("And the other checks keep running", func() {
+				So(state.Checks[1].state.Status(), ShouldEqual, StatusOK)
+			})
+		})
+	})
+}
+
+func TestRemoveCheck(t *testing.T) {
+	ok := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "ok", 0)
+	}
+	var finished int32
+	started := make(chan struct{}, 10)
+	slowCritical := func(ctx context.Context, state *CheckState) error {
+		started <- struct{}{}
+		time.Sleep(interval / 2)
+		atomic.StoreInt32(&finished, 1)
+		return state.Update(StatusCritical, "down", 0)
+	}
+
+	Convey("Given a Health Check with a registered check", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", ok), ShouldBeNil)
+
+		Convey("Then removing a check that does not exist returns an error", func() {
+			So(hc.RemoveCheck("check 2"), ShouldNotBeNil)
+			So(hc.Checks, ShouldHaveLength, 1)
+		})
+
+		Convey("Then removing the check before start removes it and its ticker", func() {
+			So(hc.RemoveCheck("check 1"), ShouldBeNil)
+			So(hc.Checks, ShouldHaveLength, 0)
+			So(hc.tickers, ShouldHaveLength, 0)
+		})
+	})
+
+	Convey("Given a started Health Check with a slow check that is critical", t, func() {
+		hc, err := New(version, 0, interval, WithCriticalFailures(1))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", ok), ShouldBeNil)
+		So(hc.AddCheck("check 2", slowCritical), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		time.Sleep(2 * interval)
+
+		Convey("When the critical check is removed while it is running", func() {
+			for len(started) > 0 {
+				<-started
+			}
+			<-started
+			atomic.StoreInt32(&finished, 0)
+			removed := hc.tickers[1]
+			So(hc.RemoveCheck("check 2"), ShouldBeNil)
+
+			Convey("Then its ticker is stopped once its run has finished", func() {
+				So(removed.isStopping(), ShouldBeTrue)
+				So(atomic.LoadInt32(&finished), ShouldEqual, 1)
+			})
+
+			Convey("Then the check no longer contributes to the overall status", func() {
+				state := hc.GetState()
+				So(state.Checks, ShouldHaveLength, 1)
+				So(state.Checks[0].State().Name(), ShouldEqual, "check 1")
+				So(state.Status, ShouldEqual, StatusOK)
+			})
+		})
+	})
+}
+
 func TestNewVersionInfo(t *testing.T) {
 	Convey("Create a new versionInfo object", t, func() {
 		buildTime := "0"
@@ -345,8 +462,10 @@ func TestStop(t *testing.T) {
 
 	Convey("Given a Health Check with a long running checker", t, func() {
 
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck("check 1", longRunningChecker)
+		hc, err := New(version, criticalTimeout, interval)
+
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("check 1", longRunningChecker)
 		So(err, ShouldBeNil)
 		hc.Start(context.Background())
 
@@ -363,8 +482,10 @@ func TestStop(t *testing.T) {
 
 	Convey("Given a Health Check with multiple long running checkers", t, func() {
 
-		hc := New(version, criticalTimeout, interval)
-		err := hc.AddCheck("check 1", emptyChecker)
+		hc, err := New(version, criticalTimeout, interval)
+
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("check 1", emptyChecker)
 		So(err, ShouldBeNil)
 		err = hc.AddCheck("check 2", emptyChecker)
 		So(err, ShouldBeNil)
@@ -383,3 +504,836 @@ func TestStop(t *testing.T) {
 		})
 	})
 }
+
+func TestStopWithSlowChecks(t *testing.T) {
+	const checkerDuration = 300 * time.Millisecond
+
+	slowChecker := func(ctx context.Context, state *CheckState) error {
+		time.Sleep(checkerDuration)
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a started Health Check with several slow checks in flight", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		for _, name := range []string{"check 1", "check 2", "check 3", "check 4"} {
+			So(hc.AddCheck(name, slowChecker, WithTimeout(time.Second)), ShouldBeNil)
+		}
+		hc.Start(context.Background())
+		time.Sleep(interval / 4)
+
+		Convey("When it is stopped", func() {
+			start := time.Now()
+			hc.Stop()
+			elapsed := time.Since(start)
+
+			Convey("Then the checks are waited for concurrently", func() {
+				So(elapsed, ShouldBeLessThan, 2*checkerDuration)
+				for _, tkr := range hc.tickers {
+					So(tkr.isStopping(), ShouldBeTrue)
+				}
+			})
+		})
+	})
+}
+
+func TestShutdown(t *testing.T) {
+	const checkerDuration = 300 * time.Millisecond
+
+	var runs int32
+	slowChecker := func(ctx context.Context, state *CheckState) error {
+		atomic.AddInt32(&runs, 1)
+		time.Sleep(checkerDuration)
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with a slow check", t, func() {
+		atomic.StoreInt32(&runs, 0)
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", slowChecker, WithTimeout(time.Second)), ShouldBeNil)
+
+		Convey("Then shutting it down before it is started returns straight away", func() {
+			So(hc.Shutdown(context.Background()), ShouldBeNil)
+		})
+
+		Convey("When it is shut down while the check is in flight with a context that expires first", func() {
+			hc.Start(context.Background())
+			time.Sleep(interval / 4)
+			ctx, cancel := context.WithTimeout(context.Background(), interval/2)
+			defer cancel()
+
+			start := time.Now()
+			err := hc.Shutdown(ctx)
+			elapsed := time.Since(start)
+
+			Convey("Then the error of the context is returned without waiting for the check", func() {
+				So(err == context.DeadlineExceeded, ShouldBeTrue)
+				So(elapsed, ShouldBeLessThan, checkerDuration)
+				So(hc.StopTime, ShouldNotBeNil)
+			})
+
+			Convey("Then it can be started again", func() {
+				hc.Start(context.Background())
+				time.Sleep(interval / 4)
+				So(atomic.LoadInt32(&runs), ShouldEqual, 2)
+				So(hc.Shutdown(context.Background()), ShouldBeNil)
+			})
+		})
+
+		Convey("When it is shut down while the check is in flight with a context that outlasts the check", func() {
+			hc.Start(context.Background())
+			time.Sleep(interval / 4)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*checkerDuration)
+			defer cancel()
+			err := hc.Shutdown(ctx)
+
+			Convey("Then it waits for the check and returns no error", func() {
+				So(err, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestStartAndStopLifecycle(t *testing.T) {
+	var runs int32
+	cf := func(ctx context.Context, state *CheckState) error {
+		atomic.AddInt32(&runs, 1)
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with a registered check", t, func() {
+		atomic.StoreInt32(&runs, 0)
+		hc, err := New(version, criticalTimeout, interval, WithJitter(0))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+
+		Convey("When it is stopped before being started", func() {
+			stopped := make(chan bool)
+			go func() {
+				hc.Stop()
+				close(stopped)
+			}()
+
+			Convey("Then stop returns without having frozen the uptime", func() {
+				select {
+				case <-stopped:
+				case <-time.After(interval):
+					t.Fatal("stop did not return")
+				}
+				So(hc.StopTime, ShouldBeNil)
+				So(atomic.LoadInt32(&runs), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When it is started twice", func() {
+			hc.Start(context.Background())
+			startTime := hc.StartTime
+			tkr := hc.tickers[0]
+			hc.Start(context.Background())
+			defer hc.Stop()
+			time.Sleep(interval / 2)
+
+			Convey("Then the second start does nothing and the check has only run once", func() {
+				So(hc.StartTime, ShouldEqual, startTime)
+				So(hc.tickers, ShouldHaveLength, 1)
+				So(hc.tickers[0] == tkr, ShouldBeTrue)
+				So(atomic.LoadInt32(&runs), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When it is started and stopped twice", func() {
+			hc.Start(context.Background())
+			time.Sleep(interval / 2)
+			hc.Stop()
+			stopTime := *hc.StopTime
+			hc.Stop()
+
+			Convey("Then the second stop does nothing", func() {
+				So(*hc.StopTime, ShouldEqual, stopTime)
+			})
+		})
+
+		Convey("When it is started again after being stopped", func() {
+			hc.Start(context.Background())
+			time.Sleep(interval / 2)
+			hc.Stop()
+			stoppedTkr := hc.tickers[0]
+			lastChecked := *hc.Checks[0].state.LastChecked()
+
+			hc.Start(context.Background())
+			defer hc.Stop()
+			time.Sleep(interval / 2)
+
+			Convey("Then the checks resume with a new ticker", func() {
+				So(hc.StopTime, ShouldBeNil)
+				So(hc.tickers[0] == stoppedTkr, ShouldBeFalse)
+				So(hc.tickers[0].isStopping(), ShouldBeFalse)
+				So(*hc.Checks[0].state.LastChecked(), ShouldHappenAfter, lastChecked)
+				So(atomic.LoadInt32(&runs), ShouldEqual, 2)
+			})
+		})
+
+		Convey("When a check is added after it has been stopped", func() {
+			hc.Start(context.Background())
+			hc.Stop()
+			So(hc.AddCheck("check 2", cf), ShouldBeNil)
+			time.Sleep(interval / 2)
+
+			Convey("Then the new check is not run until it is started again", func() {
+				So(hc.Checks[1].state.LastChecked(), ShouldBeNil)
+
+				hc.Start(context.Background())
+				defer hc.Stop()
+				time.Sleep(interval / 2)
+				So(hc.Checks[1].state.LastChecked(), ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestReplaceChecks(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a started Health Check with 2 registered checks", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		So(hc.AddCheck("check 2", cf), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		time.Sleep(2 * interval)
+		persistingState := hc.Checks[1].state
+		lastChecked := persistingState.LastChecked()
+		So(lastChecked, ShouldNotBeNil)
+
+		Convey("When the checks are replaced by one persisting check and one new check", func() {
+			check2, _ := NewCheck("check 2", cf)
+			check3, _ := NewCheck("check 3", cf)
+			err := hc.ReplaceChecks([]*Check{check2, check3})
+
+			Convey("Then the check set and tickers are swapped", func() {
+				So(err, ShouldBeNil)
+				So(len(hc.Checks), ShouldEqual, 2)
+				So(len(hc.tickers), ShouldEqual, 2)
+				So(hc.Checks[0].state.Name(), ShouldEqual, "check 2")
+				So(hc.Checks[1].state.Name(), ShouldEqual, "check 3")
+			})
+
+			Convey("Then the state of the persisting check is preserved", func() {
+				So(hc.Checks[0].state == persistingState, ShouldBeTrue)
+				So(*hc.Checks[0].state.LastChecked(), ShouldHappenOnOrAfter, *lastChecked)
+			})
+
+			Convey("Then the new check is run by its ticker", func() {
+				time.Sleep(2 * interval)
+				So(hc.Checks[1].state.LastChecked(), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given a Health Check that has not been started", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+
+		Convey("When the checks are replaced", func() {
+			check2, _ := NewCheck("check 2", cf)
+			err := hc.ReplaceChecks([]*Check{check2})
+
+			Convey("Then the checks are replaced without starting any tickers", func() {
+				So(err, ShouldBeNil)
+				So(len(hc.Checks), ShouldEqual, 1)
+				So(len(hc.tickers), ShouldEqual, 1)
+				So(hc.tickers[0].check == check2, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a Health Check with 1 registered check", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		original := hc.Checks[0]
+
+		Convey("Then replacing the checks with duplicate names fails and leaves the checks unchanged", func() {
+			check2a, _ := NewCheck("check 2", cf)
+			check2b, _ := NewCheck("check 2", cf)
+			err := hc.ReplaceChecks([]*Check{check2a, check2b})
+			So(err, ShouldNotBeNil)
+			So(len(hc.Checks), ShouldEqual, 1)
+			So(hc.Checks[0] == original, ShouldBeTrue)
+		})
+
+		Convey("Then replacing the checks with a nil check fails", func() {
+			err := hc.ReplaceChecks([]*Check{nil})
+			So(err, ShouldNotBeNil)
+			So(hc.Checks[0] == original, ShouldBeTrue)
+		})
+	})
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	Convey("Creating a Health Check with a non-positive interval returns an error", t, func() {
+		_, err := New(version, criticalTimeout, 0)
+		So(err, ShouldResemble, errors.New("invalid interval 0s, must be positive"))
+
+		_, err = New(version, criticalTimeout, -interval)
+		So(err, ShouldResemble, errors.New("invalid interval -100ms, must be positive"))
+	})
+
+	Convey("Creating a Health Check with a non-positive critical timeout returns an error", t, func() {
+		_, err := New(version, 0, interval)
+		So(err, ShouldResemble, errors.New("invalid critical timeout 0s, must be positive"))
+	})
+
+	Convey("Creating a Health Check with a critical timeout less than the interval returns an error", t, func() {
+		_, err := New(version, interval/2, interval)
+		So(err, ShouldResemble, errors.New("invalid critical timeout 50ms, must not be less than the interval 100ms"))
+	})
+
+	Convey("Creating a Health Check with a jitter outside of [0, 1) returns an error", t, func() {
+		_, err := New(version, criticalTimeout, interval, WithJitter(-0.1))
+		So(err, ShouldResemble, errors.New("invalid jitter -0.1, must be at least 0 and less than 1"))
+
+		_, err = New(version, criticalTimeout, interval, WithJitter(1))
+		So(err, ShouldResemble, errors.New("invalid jitter 1, must be at least 0 and less than 1"))
+	})
+
+	Convey("Creating a Health Check with a listener queue that cannot hold a status change returns an error", t, func() {
+		_, err := New(version, criticalTimeout, interval, WithListenerQueue(0, BlockOnOverflow))
+		So(err, ShouldResemble, errors.New("invalid listener queue size 0, must be positive"))
+	})
+
+	Convey("Creating a Health Check with a probe budget that allows no probes returns an error", t, func() {
+		_, err := New(version, criticalTimeout, interval, WithProbeBudget(0, time.Minute))
+		So(err, ShouldResemble, errors.New("invalid probe budget of 0 probes, must be positive"))
+
+		_, err = New(version, criticalTimeout, interval, WithProbeBudget(-1, time.Minute))
+		So(err, ShouldResemble, errors.New("invalid probe budget of -1 probes, must be positive"))
+	})
+
+	Convey("Creating a Health Check with a probe budget over a non-positive window returns an error", t, func() {
+		_, err := New(version, criticalTimeout, interval, WithProbeBudget(10, 0))
+		So(err, ShouldResemble, errors.New("invalid probe budget window 0s, must be positive"))
+	})
+
+	Convey("Creating a Health Check with a maximum of concurrent checks that lets no check run returns an error", t, func() {
+		_, err := New(version, criticalTimeout, interval, WithMaxConcurrentChecks(0))
+		So(err, ShouldResemble, errors.New("invalid maximum concurrent checks 0, must be positive"))
+
+		So(func() { _, err = New(version, criticalTimeout, interval, WithMaxConcurrentChecks(-1)) }, ShouldNotPanic)
+		So(err, ShouldResemble, errors.New("invalid maximum concurrent checks -1, must be positive"))
+	})
+
+	Convey("Creating a Health Check with no critical timeout and a number of critical failures succeeds", t, func() {
+		hc, err := New(version, 0, interval, WithCriticalFailures(3))
+		So(err, ShouldBeNil)
+		So(hc.criticalFailures, ShouldEqual, 3)
+	})
+}
+
+func TestNewWithOptions(t *testing.T) {
+	Convey("Create a new Health Check with a soft start window", t, func() {
+		hc, err := New(version, criticalTimeout, interval, WithSoftStart(time.Minute))
+		So(err, ShouldBeNil)
+
+		So(hc.softStartWindow, ShouldEqual, time.Minute)
+		So(hc.criticalErrorTimeout, ShouldEqual, criticalTimeout)
+		So(hc.interval, ShouldEqual, interval)
+	})
+}
+
+func TestStartRunsChecksImmediately(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with a registered check", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+
+		Convey("When it is started", func() {
+			hc.Start(context.Background())
+			defer hc.Stop()
+			time.Sleep(interval / 4)
+
+			Convey("Then the check has run without waiting for the first tick", func() {
+				state := hc.GetState()
+				So(state.Checks[0].State().LastChecked(), ShouldNotBeNil)
+				So(state.Status, ShouldEqual, StatusOK)
+			})
+		})
+
+		Convey("When it is started with a context that is already done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			hc.Start(ctx)
+			defer hc.Stop()
+			time.Sleep(interval / 4)
+
+			Convey("Then the check has not run", func() {
+				So(hc.GetState().Checks[0].State().LastChecked(), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestWithJitter(t *testing.T) {
+	Convey("Given a started Health Check with jitter disabled and a check that records when it runs", t, func() {
+		var (
+			mutex sync.Mutex
+			runs  []time.Time
+		)
+		cf := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			runs = append(runs, time.Now())
+			return state.Update(StatusOK, "", 0)
+		}
+
+		hc, err := New(version, criticalTimeout, interval, WithJitter(0))
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		Convey("Then the check runs at exactly the configured interval", func() {
+			So(hc.tickers[0].interval, ShouldEqual, interval)
+			So(hc.tickers[0].timeout, ShouldEqual, interval)
+
+			time.Sleep(4*interval + interval/2)
+			mutex.Lock()
+			defer mutex.Unlock()
+			So(runs, ShouldHaveLength, 5)
+			for i := 1; i < len(runs); i++ {
+				So(runs[i].Sub(runs[i-1]), ShouldAlmostEqual, interval, interval/5)
+			}
+		})
+	})
+}
+
+func TestNextRun(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return nil
+	}
+
+	Convey("Given a Health Check with 1 registered check", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+
+		Convey("When the health check has not been started", func() {
+			Convey("Then there is no next run", func() {
+				_, ok := hc.NextRun("check 1")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When the health check has been started", func() {
+			before := time.Now().UTC()
+			hc.Start(context.Background())
+			defer hc.Stop()
+			after := time.Now().UTC()
+
+			Convey("Then the next run is one jittered interval after the start", func() {
+				maxJitter := time.Duration(getMaxJitter(interval, defaultJitter))
+				nextRun, ok := hc.NextRun("check 1")
+				So(ok, ShouldBeTrue)
+				So(nextRun, ShouldHappenOnOrBetween, before.Add(interval-maxJitter), after.Add(interval+maxJitter))
+			})
+
+			Convey("Then the next run moves on after the check has run", func() {
+				first, _ := hc.NextRun("check 1")
+				time.Sleep(2 * interval)
+				nextRun, ok := hc.NextRun("check 1")
+				So(ok, ShouldBeTrue)
+				So(nextRun, ShouldHappenAfter, first)
+			})
+
+			Convey("Then there is no next run for an unknown check", func() {
+				_, ok := hc.NextRun("unknown")
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When the health check has been stopped", func() {
+			hc.Start(context.Background())
+			hc.Stop()
+
+			Convey("Then there is no next run", func() {
+				_, ok := hc.NextRun("check 1")
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestSetCheckDebug(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with 2 registered checks", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		So(hc.AddCheck("check 2", cf), ShouldBeNil)
+
+		Convey("When debug is enabled for one check", func() {
+			hc.SetCheckDebug("check 2", true)
+
+			Convey("Then only that check is in debug", func() {
+				So(hc.Checks[0].isDebug(), ShouldBeFalse)
+				So(hc.Checks[1].isDebug(), ShouldBeTrue)
+			})
+
+			Convey("Then the check still runs normally while in debug", func() {
+				hc.Start(context.Background())
+				defer hc.Stop()
+				time.Sleep(2 * interval)
+				So(hc.Checks[1].state.Status(), ShouldEqual, StatusOK)
+			})
+
+			Convey("When debug is disabled again", func() {
+				hc.SetCheckDebug("check 2", false)
+
+				Convey("Then the check is no longer in debug", func() {
+					So(hc.Checks[1].isDebug(), ShouldBeFalse)
+				})
+			})
+		})
+	})
+}
+
+func TestLastChanged(t *testing.T) {
+	Convey("Given a started Health Check with 2 checks whose statuses can be changed", t, func() {
+		var mutex sync.Mutex
+		statuses := map[string]string{"kafka": StatusOK, "mongo": StatusOK}
+		checker := func(name string) Checker {
+			return func(ctx context.Context, state *CheckState) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				return state.Update(statuses[name], "", 0)
+			}
+		}
+		setStatus := func(name, status string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			statuses[name] = status
+		}
+
+		hc, err := New(version, criticalTimeout, interval)
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("kafka", checker("kafka")), ShouldBeNil)
+		So(hc.AddCheck("mongo", checker("mongo")), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		time.Sleep(2 * interval)
+
+		Convey("When no check has changed status since it was first recorded", func() {
+			Convey("Then there is no last changed check", func() {
+				_, _, ok := hc.LastChanged()
+				So(ok, ShouldBeFalse)
+			})
+		})
+
+		Convey("When one check changes status and then another recovers", func() {
+			setStatus("mongo", StatusCritical)
+			setStatus("kafka", StatusCritical)
+			time.Sleep(2 * interval)
+			setStatus("kafka", StatusOK)
+			before := time.Now().UTC()
+			time.Sleep(2 * interval)
+
+			Convey("Then the check that most recently changed status is returned", func() {
+				state, changed, ok := hc.LastChanged()
+				So(ok, ShouldBeTrue)
+				So(state.Name(), ShouldEqual, "kafka")
+				So(state.Status(), ShouldEqual, StatusOK)
+				So(changed, ShouldHappenOnOrBetween, before, time.Now().UTC())
+			})
+		})
+	})
+}
+
+func TestUnhealthyChecks(t *testing.T) {
+	Convey("Given a started Health Check with OK, warning, critical and not yet run checks", t, func() {
+		checker := func(status string) Checker {
+			return func(ctx context.Context, state *CheckState) error {
+				return state.Update(status, "", 0)
+			}
+		}
+		notRun := func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusCritical, "", 0)
+		}
+
+		hc, err := New(version, criticalTimeout, interval)
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("kafka", checker(StatusWarning)), ShouldBeNil)
+		So(hc.AddCheck("mongo", checker(StatusOK)), ShouldBeNil)
+		So(hc.AddCheck("vault", checker(StatusCritical)), ShouldBeNil)
+		So(hc.AddCheck("zebedee", checker(StatusWarning)), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		time.Sleep(2 * interval)
+		So(hc.AddCheck("elasticsearch", notRun), ShouldBeNil)
+
+		Convey("When the unhealthy checks are requested", func() {
+			unhealthy := hc.UnhealthyChecks()
+
+			Convey("Then copies of the checks that have run and are not OK are returned, most severe first", func() {
+				So(unhealthy, ShouldHaveLength, 3)
+				So(unhealthy[0].State().Name(), ShouldEqual, "vault")
+				So(unhealthy[0].State().Status(), ShouldEqual, StatusCritical)
+				So(unhealthy[1].State().Name(), ShouldEqual, "kafka")
+				So(unhealthy[2].State().Name(), ShouldEqual, "zebedee")
+				So(unhealthy[0].State() == hc.Checks[2].state, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a Health Check without checks", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+
+		Convey("Then there are no unhealthy checks", func() {
+			So(hc.UnhealthyChecks(), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestTick(t *testing.T) {
+	Convey("Given a Health Check with 2 registered checks that has not been started", t, func() {
+		var mutex sync.Mutex
+		runs := map[string]int{}
+		checker := func(name string) Checker {
+			return func(ctx context.Context, state *CheckState) error {
+				mutex.Lock()
+				defer mutex.Unlock()
+				runs[name]++
+				return state.Update(StatusOK, "I'm OK", 0)
+			}
+		}
+
+		hc, err := New(version, criticalTimeout, interval)
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker("check 1")), ShouldBeNil)
+		So(hc.AddCheck("check 2", checker("check 2")), ShouldBeNil)
+
+		Convey("When the health check is ticked twice by an external scheduler", func() {
+			hc.Tick(context.Background())
+			hc.Tick(context.Background())
+
+			Convey("Then each check has run once, as it is not due again until its interval has passed", func() {
+				mutex.Lock()
+				So(runs, ShouldResemble, map[string]int{"check 1": 1, "check 2": 1})
+				mutex.Unlock()
+			})
+
+			Convey("Then the overall status is updated", func() {
+				hc.mutex.RLock()
+				So(hc.Status, ShouldEqual, StatusOK)
+				hc.mutex.RUnlock()
+			})
+
+			Convey("Then each check runs again when ticked after its interval has passed", func() {
+				time.Sleep(interval)
+				hc.Tick(context.Background())
+				mutex.Lock()
+				So(runs, ShouldResemble, map[string]int{"check 1": 2, "check 2": 2})
+				mutex.Unlock()
+			})
+		})
+	})
+
+	Convey("Given a started Health Check whose check is in flight", t, func() {
+		var runs int32
+		release := make(chan struct{})
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return state.Update(StatusOK, "I'm OK", 0)
+		}), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		So(waitFor(hc.Checks[0].isRunning), ShouldBeTrue)
+
+		Convey("When the health check is ticked after the interval of the check has passed", func() {
+			time.Sleep(interval)
+			ticked := make(chan struct{})
+			go func() {
+				hc.Tick(context.Background())
+				close(ticked)
+			}()
+
+			Convey("Then the check is skipped rather than run a second time alongside the run in flight", func() {
+				select {
+				case <-ticked:
+				case <-time.After(time.Second):
+					t.Error("expected Tick to skip the check in flight")
+				}
+				So(atomic.LoadInt32(&runs), ShouldEqual, 1)
+				close(release)
+			})
+		})
+	})
+}
+
+func TestGetState(t *testing.T) {
+	Convey("Given a started Health Check with a registered check", t, func() {
+		cf := func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "I'm OK", 0)
+		}
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		time.Sleep(2 * interval)
+
+		Convey("When the state is copied", func() {
+			state := hc.GetState()
+
+			Convey("Then the copy has the status and check states of the health check", func() {
+				So(state.Status, ShouldEqual, StatusOK)
+				So(state.Checks, ShouldHaveLength, 1)
+				So(state.Checks[0].State().Status(), ShouldEqual, StatusOK)
+			})
+
+			Convey("Then the check states are copies that are not updated by later runs", func() {
+				So(state.Checks[0].state == hc.Checks[0].state, ShouldBeFalse)
+				lastChecked := *state.Checks[0].State().LastChecked()
+				time.Sleep(2 * interval)
+				So(*state.Checks[0].State().LastChecked(), ShouldEqual, lastChecked)
+			})
+		})
+	})
+}
+
+func TestGetStateConcurrentWithTickers(t *testing.T) {
+	Convey("Given a started Health Check with several checks that change status on every run", t, func() {
+		statuses := []string{StatusOK, StatusWarning, StatusCritical}
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		for i := 0; i < 5; i++ {
+			run := i
+			cf := func(ctx context.Context, state *CheckState) error {
+				run++
+				return state.Update(statuses[run%len(statuses)], "changing", 0)
+			}
+			So(hc.AddCheck("check "+string(rune('a'+i)), cf), ShouldBeNil)
+		}
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		Convey("When the state is read and marshalled in a loop while the checks run", func() {
+			var marshalErr error
+			deadline := time.Now().Add(5 * interval)
+			for time.Now().Before(deadline) && marshalErr == nil {
+				state := hc.GetState()
+				_, marshalErr = json.Marshal(state)
+				hc.UnhealthyChecks()
+			}
+
+			Convey("Then every copy can be marshalled", func() {
+				So(marshalErr, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestUptimeAfterStop(t *testing.T) {
+	Convey("Given a Health Check that has been started and stopped", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		hc.Start(context.Background())
+		time.Sleep(interval)
+		hc.Stop()
+
+		Convey("Then the stop time is recorded and the uptime is frozen at it", func() {
+			So(hc.StopTime, ShouldNotBeNil)
+			So(hc.Uptime, ShouldEqual, hc.StopTime.Sub(hc.StartTime)/time.Millisecond)
+		})
+
+		Convey("When the health handler is called later", func() {
+			uptime := hc.Uptime
+			time.Sleep(interval)
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+
+			var response map[string]interface{}
+			So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+
+			Convey("Then the reported uptime has not grown and the stop time is included", func() {
+				So(hc.Uptime, ShouldEqual, uptime)
+				So(response["uptime"], ShouldResemble, map[string]interface{}{
+					"seconds": (uptime * time.Millisecond).Seconds(),
+					"text":    (uptime * time.Millisecond).String(),
+				})
+				So(response["stop_time"], ShouldNotBeNil)
+			})
+		})
+
+		Convey("When the Health Check is started again", func() {
+			hc.Start(context.Background())
+			defer hc.Stop()
+
+			Convey("Then the stop time is cleared", func() {
+				So(hc.StopTime, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestAddCheckWithInterval(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with a check using the default interval and a check with its own interval", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("database", cf), ShouldBeNil)
+		So(hc.AddCheck("config", cf, WithInterval(10*interval)), ShouldBeNil)
+		defer func() {
+			for _, ticker := range hc.tickers {
+				ticker.timeTicker.Stop()
+			}
+		}()
+
+		Convey("Then each ticker runs at the interval of its check, with jitter applied on each tick", func() {
+			So(hc.tickers[0].interval, ShouldEqual, interval)
+			So(hc.tickers[1].interval, ShouldEqual, 10*interval)
+		})
+	})
+}
+
+func TestUnstartedHealthCheckDoesNotLeakGoroutines(t *testing.T) {
+	Convey("Given health checks with jitter that are ticked but never started", t, func() {
+		before := runtime.NumGoroutine()
+		for i := 0; i < 50; i++ {
+			hc, err := New(version, criticalTimeout, interval)
+			So(err, ShouldBeNil)
+			So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+				return state.Update(StatusOK, "I'm OK", 0)
+			}), ShouldBeNil)
+			hc.Tick(context.Background())
+			hc.Stop()
+		}
+
+		Convey("Then no goroutine is left running for their tickers", func() {
+			So(waitFor(func() bool { return runtime.NumGoroutine() <= before+5 }), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// durationJSON is the JSON representation of a duration, as both a number of seconds, for machines, and a string such
+// as 1h23m45s, for people
+type durationJSON struct {
+	Seconds float64 `json:"seconds"`
+	Text    string  `json:"text"`
+}
+
+// newDurationJSON returns the JSON representation of the provided duration
+func newDurationJSON(d time.Duration) durationJSON {
+	return durationJSON{
+		Seconds: d.Seconds(),
+		Text:    d.String(),
+	}
+}
+
+// duration returns the duration represented, preferring the exact text to the number of seconds
+func (d durationJSON) duration() time.Duration {
+	if duration, err := time.ParseDuration(d.Text); err == nil {
+		return duration
+	}
+	return time.Duration(math.Round(d.Seconds * float64(time.Second)))
+}
+
+// healthCheckJSON is a health check without its custom JSON encoding, so that it can be embedded in the types used to
+// encode it
+type healthCheckJSON HealthCheck
+
+// MarshalJSON returns the JSON representation of the health check, with its uptime as a duration in both seconds and
+// text, or as a number of milliseconds if legacy durations are configured. Its version is omitted if configured.
+func (hc HealthCheck) MarshalJSON() ([]byte, error) {
+	// the fields of the embedded health check are hidden by the shallower fields of the same name
+	body := struct {
+		healthCheckJSON
+		Version *VersionInfo `json:"version,omitempty"`
+		Uptime  interface{}  `json:"uptime"`
+	}{
+		healthCheckJSON: healthCheckJSON(hc),
+		Uptime:          newDurationJSON(hc.Uptime * time.Millisecond),
+	}
+	if !hc.omitVersion {
+		body.Version = &hc.Version
+	}
+	if hc.legacyDurations {
+		body.Uptime = int64(hc.Uptime)
+	}
+	return json.Marshal(body)
+}
+
+// UnmarshalJSON populates the health check from its JSON representation, with its uptime as either a duration or a
+// legacy number of milliseconds
+func (hc *HealthCheck) UnmarshalJSON(b []byte) error {
+	body := struct {
+		*healthCheckJSON
+		Uptime json.RawMessage `json:"uptime"`
+	}{
+		healthCheckJSON: (*healthCheckJSON)(hc),
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return err
+	}
+
+	hc.Uptime = 0
+	if len(body.Uptime) == 0 || string(body.Uptime) == "null" {
+		return nil
+	}
+	var uptime durationJSON
+	if err := json.Unmarshal(body.Uptime, &uptime); err == nil {
+		hc.Uptime = uptime.duration() / time.Millisecond
+		return nil
+	}
+	var milliseconds int64
+	if err := json.Unmarshal(body.Uptime, &milliseconds); err != nil {
+		return err
+	}
+	hc.Uptime = time.Duration(milliseconds)
+	return nil
+}
+
+// checkResultJSON is a check result without its custom JSON encoding, so that it can be embedded in the types used to
+// encode it
+type checkResultJSON CheckResult
+
+// MarshalJSON returns the JSON representation of the check result, with its duration in both seconds and text
+func (r CheckResult) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		checkResultJSON
+		Duration durationJSON `json:"duration"`
+	}{
+		checkResultJSON: checkResultJSON(r),
+		Duration:        newDurationJSON(r.Duration),
+	})
+}
+
+// UnmarshalJSON populates the check result from its JSON representation
+func (r *CheckResult) UnmarshalJSON(b []byte) error {
+	body := struct {
+		*checkResultJSON
+		Duration durationJSON `json:"duration"`
+	}{
+		checkResultJSON: (*checkResultJSON)(r),
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return err
+	}
+
+	r.Duration = body.Duration.duration()
+	return nil
+}
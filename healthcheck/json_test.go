@@ -0,0 +1,89 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHealthCheckJSON(t *testing.T) {
+	Convey("Given a health check that has been up for 1h23m45.5s", t, func() {
+		hc, err := New(testVersion, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		hc.Status = StatusOK
+		hc.Uptime = (time.Hour + 23*time.Minute + 45500*time.Millisecond) / time.Millisecond
+
+		Convey("When it is encoded as JSON", func() {
+			b, err := json.Marshal(hc)
+			So(err, ShouldBeNil)
+
+			var fields map[string]json.RawMessage
+			So(json.Unmarshal(b, &fields), ShouldBeNil)
+
+			Convey("Then the uptime is encoded as both a number of seconds and a string", func() {
+				So(string(fields["uptime"]), ShouldEqual, `{"seconds":5025.5,"text":"1h23m45.5s"}`)
+			})
+
+			Convey("Then the other fields are encoded as before", func() {
+				So(string(fields["status"]), ShouldEqual, `"OK"`)
+				So(fields, ShouldContainKey, "version")
+				So(fields, ShouldContainKey, "start_time")
+			})
+
+			Convey("Then it can be decoded with the same uptime", func() {
+				var decoded HealthCheck
+				So(json.Unmarshal(b, &decoded), ShouldBeNil)
+				So(decoded.Uptime, ShouldEqual, hc.Uptime)
+				So(decoded.Status, ShouldEqual, StatusOK)
+				So(decoded.Version, ShouldResemble, testVersion)
+			})
+		})
+
+		Convey("When it is encoded as JSON with legacy durations", func() {
+			WithLegacyDurations()(&hc)
+			b, err := json.Marshal(hc)
+			So(err, ShouldBeNil)
+
+			var fields map[string]json.RawMessage
+			So(json.Unmarshal(b, &fields), ShouldBeNil)
+
+			Convey("Then the uptime is encoded as a number of milliseconds", func() {
+				So(string(fields["uptime"]), ShouldEqual, "5025500")
+			})
+
+			Convey("Then it can be decoded with the same uptime", func() {
+				var decoded HealthCheck
+				So(json.Unmarshal(b, &decoded), ShouldBeNil)
+				So(decoded.Uptime, ShouldEqual, hc.Uptime)
+			})
+		})
+	})
+}
+
+func TestCheckResultJSON(t *testing.T) {
+	Convey("Given the result of a run of a check", t, func() {
+		result := CheckResult{
+			Time:     time.Unix(1500000000, 0).UTC(),
+			Status:   StatusWarning,
+			Duration: 1234567 * time.Microsecond,
+			Message:  "slow",
+		}
+
+		Convey("When it is encoded as JSON", func() {
+			b, err := json.Marshal(result)
+			So(err, ShouldBeNil)
+
+			Convey("Then the duration is encoded as both a number of seconds and a string", func() {
+				So(string(b), ShouldEqual, `{"time":"2017-07-14T02:40:00Z","status":"WARNING","message":"slow","duration":{"seconds":1.234567,"text":"1.234567s"}}`)
+			})
+
+			Convey("Then it can be decoded to the same result", func() {
+				var decoded CheckResult
+				So(json.Unmarshal(b, &decoded), ShouldBeNil)
+				So(decoded, ShouldResemble, result)
+			})
+		})
+	})
+}
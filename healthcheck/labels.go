@@ -0,0 +1,50 @@
+package healthcheck
+
+import (
+	"fmt"
+	"strings"
+)
+
+// parseLabels parses labels in the form key:value, as used by the label query parameter of the health handler
+func parseLabels(values []string) (map[string]string, error) {
+	labels := make(map[string]string, len(values))
+	for _, value := range values {
+		parts := strings.SplitN(value, ":", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid label %q, must be in the form key:value", value)
+		}
+		labels[parts[0]] = parts[1]
+	}
+	return labels, nil
+}
+
+// withLabels returns a copy of the health check with only the checks that have all of the provided labels, and
+// whether there are any. The overall status reported is the most severe status of those checks, as for a group, unless
+// a status has been forced with SetOverride, and the status of each group is that of its checks with the labels. As
+// for a group, a critical check is a warning until its critical timeout has passed or it has failed the configured
+// number of runs, and within the soft start window, so that a probe of the labels does not fail on a single failure.
+func (hc HealthCheck) withLabels(labels map[string]string) (HealthCheck, bool) {
+	checks := make([]*Check, 0, len(hc.Checks))
+	status := StatusOK
+	now := hc.now()
+	for _, check := range hc.Checks {
+		if !check.state.hasLabels(labels) {
+			continue
+		}
+		checks = append(checks, check)
+		if check.informational || check.isSkipped() {
+			continue
+		}
+		if checkStatus := hc.subsetStatus(check, now); statusRanks[checkStatus] > statusRanks[status] {
+			status = checkStatus
+		}
+	}
+	if len(checks) == 0 {
+		return hc, false
+	}
+
+	hc.Checks = checks
+	hc.Status = hc.overrideStatus(status)
+	hc.Groups = hc.groupStatuses()
+	return hc, true
+}
@@ -0,0 +1,137 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLabels(t *testing.T) {
+	checker := func(status string) Checker {
+		return func(ctx context.Context, state *CheckState) error {
+			return state.Update(status, "", 0)
+		}
+	}
+
+	Convey("Given a health check with critical tier checks in two regions and a diagnostic check", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", checker(StatusOK), WithLabel("tier", "critical"), WithLabel("region", "eu-west-1"), WithGroup("storage")), ShouldBeNil)
+		So(hc.AddCheck("kafka", checker(StatusWarning), WithLabel("tier", "critical"), WithLabel("region", "eu-west-2")), ShouldBeNil)
+		So(hc.AddCheck("s3", checker(StatusCritical), WithLabel("tier", "diagnostic"), WithGroup("storage")), ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+		hc.Tick(context.Background())
+
+		Convey("Then the labels of each check can be got", func() {
+			So(hc.Checks[0].state.Labels(), ShouldResemble, map[string]string{"tier": "critical", "region": "eu-west-1"})
+			So(hc.Checks[2].state.Labels(), ShouldResemble, map[string]string{"tier": "diagnostic"})
+		})
+
+		Convey("When the health handler is called", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+
+			Convey("Then the response includes every check with its labels", func() {
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Checks, ShouldHaveLength, 3)
+				So(response.Checks[1].state.Labels(), ShouldResemble, map[string]string{"tier": "critical", "region": "eu-west-2"})
+			})
+		})
+
+		Convey("When the health handler is called for the critical tier", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?label=tier:critical", nil))
+
+			Convey("Then the response only includes the checks with the label, with their most severe status", func() {
+				So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Status, ShouldEqual, StatusWarning)
+				So(response.Checks, ShouldHaveLength, 2)
+				So(response.Checks[0].state.Name(), ShouldEqual, "mongo")
+				So(response.Checks[1].state.Name(), ShouldEqual, "kafka")
+			})
+
+			Convey("Then the status of each group only takes the checks with the label into account", func() {
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Groups, ShouldResemble, map[string]string{"storage": StatusOK})
+			})
+		})
+
+		Convey("When the health handler is called for several labels", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?label=tier:critical&label=region:eu-west-1", nil))
+
+			Convey("Then the response only includes the checks with every label", func() {
+				So(w.Code, ShouldEqual, http.StatusOK)
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Checks, ShouldHaveLength, 1)
+				So(response.Checks[0].state.Name(), ShouldEqual, "mongo")
+			})
+		})
+
+		Convey("When the health handler is called for a label no check has", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?label=tier:gold", nil))
+
+			Convey("Then it responds not found", func() {
+				So(w.Code, ShouldEqual, http.StatusNotFound)
+			})
+		})
+
+		Convey("When the health handler is called with a label that is not in the form key:value", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?label=critical", nil))
+
+			Convey("Then it responds bad request", func() {
+				So(w.Code, ShouldEqual, http.StatusBadRequest)
+			})
+		})
+	})
+	Convey("Given a health check with a check labelled as critical tier that has just failed", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("mongo", checker(StatusCritical), WithLabel("tier", "critical")), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+		hc.Tick(context.Background())
+
+		Convey("When the health handler is called for the critical tier within the critical timeout", func() {
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?label=tier:critical", nil))
+
+			Convey("Then the checks with the label are a warning, as the app is, so a load balancer keeps the instance", func() {
+				So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Status, ShouldEqual, StatusWarning)
+			})
+		})
+
+		Convey("When the health handler is called for the critical tier once the critical timeout has passed", func() {
+			hc.mutex.Lock()
+			hc.timeOfFirstCriticalError = time.Now().UTC().Add(-2 * criticalTimeout)
+			hc.mutex.Unlock()
+			w := httptest.NewRecorder()
+			hc.Handler(w, httptest.NewRequest("GET", "/health?label=tier:critical", nil))
+
+			Convey("Then the checks with the label are critical", func() {
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Status, ShouldEqual, StatusCritical)
+			})
+		})
+	})
+}
@@ -0,0 +1,41 @@
+package healthcheck
+
+import "time"
+
+// TickerEventType is the type of a change in the lifecycle of the ticker that runs a check
+type TickerEventType string
+
+// List of ticker lifecycle event types
+const (
+	TickerStarted   TickerEventType = "started"
+	TickerStopped   TickerEventType = "stopped"
+	TickerRestarted TickerEventType = "restarted"
+)
+
+// TickerEvent represents a change in the lifecycle of the ticker that runs a check
+type TickerEvent struct {
+	Check string
+	Type  TickerEventType
+	Time  time.Time
+}
+
+// TickerListener is called with each change in the lifecycle of the tickers that run the checks, e.g. to count
+// tickers started, stopped and restarted by the watchdog
+type TickerListener func(event TickerEvent)
+
+// notifyTickerEvent calls each ticker listener with an event of the provided type for the check.
+// Callers must hold the write lock.
+func (hc *HealthCheck) notifyTickerEvent(eventType TickerEventType, check *Check) {
+	if len(hc.tickerListeners) == 0 {
+		return
+	}
+
+	event := TickerEvent{
+		Check: check.state.Name(),
+		Type:  eventType,
+		Time:  hc.now(),
+	}
+	for _, listener := range hc.tickerListeners {
+		listener(event)
+	}
+}
@@ -0,0 +1,80 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// tickerEventRecorder records the ticker lifecycle events it is called with
+type tickerEventRecorder struct {
+	mutex  sync.Mutex
+	events []TickerEvent
+}
+
+func (r *tickerEventRecorder) listener(event TickerEvent) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *tickerEventRecorder) types() []TickerEventType {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	types := make([]TickerEventType, 0, len(r.events))
+	for _, event := range r.events {
+		types = append(types, event.Type)
+	}
+	return types
+}
+
+func TestTickerListener(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with a ticker listener and a registered check", t, func() {
+		recorder := &tickerEventRecorder{}
+		hc, err := New(version, criticalTimeout, interval, WithTickerListener(recorder.listener))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+
+		Convey("Then no events are emitted before the health check is started", func() {
+			So(recorder.types(), ShouldBeEmpty)
+		})
+
+		Convey("When the health check is started", func() {
+			hc.Start(context.Background())
+
+			Convey("Then a started event is emitted for the check", func() {
+				So(recorder.types(), ShouldResemble, []TickerEventType{TickerStarted})
+				So(recorder.events[0].Check, ShouldEqual, "check 1")
+				So(recorder.events[0].Time, ShouldHappenWithin, time.Second, time.Now().UTC())
+			})
+
+			Convey("And a check is added and the health check is stopped", func() {
+				So(hc.AddCheck("check 2", cf), ShouldBeNil)
+				hc.Stop()
+
+				Convey("Then the new ticker is started and both tickers are stopped", func() {
+					So(recorder.types(), ShouldResemble, []TickerEventType{TickerStarted, TickerStarted, TickerStopped, TickerStopped})
+				})
+			})
+
+			Convey("And a stale ticker is restarted by the watchdog", func() {
+				hc.watchdogMissedIntervals = 1
+				hc.restartStaleTickers(time.Now().UTC().Add(time.Minute))
+				hc.Stop()
+
+				Convey("Then a restarted event is emitted for the check", func() {
+					So(recorder.types(), ShouldResemble, []TickerEventType{TickerStarted, TickerRestarted, TickerStopped})
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,60 @@
+package healthcheck
+
+import (
+	"context"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+// Logger logs an event from running the checks, along with the error that caused it, if any, and data about the
+// check, e.g. to route the events through the structured logger of the app or to silence them in tests
+type Logger func(ctx context.Context, event string, err error, data map[string]interface{})
+
+// DefaultLogger is the logger used when none has been configured, which logs each event with log.Event. It is the
+// default logger of the reporters and hooks in the subpackages, so that they log as the health check does.
+var DefaultLogger Logger = func(ctx context.Context, event string, err error, data map[string]interface{}) {
+	logEvent(ctx, nil, levelDefault, event, err, data)
+}
+
+// level is the severity at which an event is logged when no logger has been configured
+type level int
+
+const (
+	levelDefault level = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// logEvent logs an event with the provided logger, or with log.Event at the provided severity if it is nil
+func logEvent(ctx context.Context, logger Logger, lvl level, event string, err error, data log.Data) {
+	if logger != nil {
+		logger(ctx, event, err, data)
+		return
+	}
+
+	if err != nil {
+		switch lvl {
+		case levelInfo:
+			log.Event(ctx, event, log.INFO, log.Error(err), data)
+		case levelWarn:
+			log.Event(ctx, event, log.WARN, log.Error(err), data)
+		case levelError:
+			log.Event(ctx, event, log.ERROR, log.Error(err), data)
+		default:
+			log.Event(ctx, event, log.Error(err), data)
+		}
+		return
+	}
+
+	switch lvl {
+	case levelInfo:
+		log.Event(ctx, event, log.INFO, data)
+	case levelWarn:
+		log.Event(ctx, event, log.WARN, data)
+	case levelError:
+		log.Event(ctx, event, log.ERROR, data)
+	default:
+		log.Event(ctx, event, data)
+	}
+}
@@ -0,0 +1,74 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type ctxKey string
+
+func TestWithLogger(t *testing.T) {
+	Convey("Given a Health Check with a logger and a check whose checker fails", t, func() {
+		type event struct {
+			ctx   context.Context
+			event string
+			err   error
+			data  map[string]interface{}
+		}
+		var events []event
+		logger := func(ctx context.Context, e string, err error, data map[string]interface{}) {
+			events = append(events, event{ctx, e, err, data})
+		}
+		checker := func(ctx context.Context, state *CheckState) error {
+			return errors.New("connection refused")
+		}
+
+		hc, err := New(version, criticalTimeout, interval, WithLogger(logger))
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		tkr := hc.tickers[0]
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run with a context", func() {
+			ctx := context.WithValue(context.Background(), ctxKey("trace"), "abc123")
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(ctx, wg, make(chan bool, 1))
+
+			Convey("Then the failure is logged with the logger and the context of the run", func() {
+				So(events, ShouldHaveLength, 1)
+				So(events[0].event, ShouldEqual, "failed")
+				So(events[0].err.Error(), ShouldEqual, "connection refused")
+				So(events[0].data["external_service"], ShouldEqual, "check 1")
+				So(events[0].ctx.Value(ctxKey("trace")), ShouldEqual, "abc123")
+			})
+		})
+
+		Convey("When the health handler is called before the check has run", func() {
+			ctx := context.WithValue(context.Background(), ctxKey("trace"), "def456")
+			req := httptest.NewRequest("GET", "/health", nil).WithContext(ctx)
+			hc.Handler(httptest.NewRecorder(), req)
+
+			Convey("Then the app starting up is logged with the logger and the context of the request", func() {
+				So(events, ShouldHaveLength, 1)
+				So(events[0].event, ShouldEqual, "a dependency is still starting up")
+				So(events[0].ctx.Value(ctxKey("trace")), ShouldEqual, "def456")
+			})
+		})
+
+		Convey("When the status is recalculated other than by the health handler before the check has run", func() {
+			status := hc.GetStatus(context.Background())
+
+			Convey("Then the app is starting up, without it being logged", func() {
+				So(status, ShouldEqual, StatusWarning)
+				So(events, ShouldBeEmpty)
+			})
+		})
+	})
+}
@@ -0,0 +1,17 @@
+// Package mux registers the health, liveness and readiness handlers of a health check with a gorilla/mux router. It
+// is a separate package so that apps that do not use gorilla/mux do not depend on it.
+package mux
+
+import (
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	gorillamux "github.com/gorilla/mux"
+)
+
+// RegisterRoutes registers the health, liveness and readiness handlers of the provided health check with the provided
+// router, for GET and HEAD requests on the paths of the health check
+func RegisterRoutes(r *gorillamux.Router, hc *health.HealthCheck) {
+	paths := hc.Paths()
+	r.HandleFunc(paths.Health, hc.Handler).Methods("GET", "HEAD")
+	r.HandleFunc(paths.Liveness, hc.LivenessHandler).Methods("GET", "HEAD")
+	r.HandleFunc(paths.Readiness, hc.ReadinessHandler).Methods("GET", "HEAD")
+}
@@ -0,0 +1,54 @@
+package mux
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	gorillamux "github.com/gorilla/mux"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var version = health.VersionInfo{
+	BuildTime:       time.Unix(0, 0),
+	GitCommit:       "d6cd1e2bd19e03a81132a23b2025920577f84e37",
+	Language:        "go",
+	LanguageVersion: "1.12",
+	Version:         "1.0.0",
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	Convey("Given a running health check with a check that has failed, registered with a gorilla/mux router", t, func() {
+		hc, err := health.New(version, time.Hour, time.Hour, health.WithPaths(health.Paths{Readiness: "/readyz"}))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *health.CheckState) error {
+			return state.Update(health.StatusCritical, "", 0)
+		}), ShouldBeNil)
+		ctx := context.Background()
+		hc.Start(ctx)
+		defer hc.Stop()
+		hc.Tick(ctx)
+
+		r := gorillamux.NewRouter()
+		RegisterRoutes(r, &hc)
+		serve := func(method, path string) int {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+			return w.Code
+		}
+
+		Convey("Then each handler is served on its path", func() {
+			So(serve("GET", "/health"), ShouldEqual, http.StatusTooManyRequests)
+			So(serve("GET", "/health/live"), ShouldEqual, http.StatusOK)
+			So(serve("HEAD", "/health/live"), ShouldEqual, http.StatusOK)
+			So(serve("GET", "/readyz"), ShouldEqual, http.StatusServiceUnavailable)
+		})
+
+		Convey("Then the handlers are not served for other methods", func() {
+			So(serve("POST", "/health"), ShouldEqual, http.StatusMethodNotAllowed)
+		})
+	})
+}
@@ -0,0 +1,69 @@
+package healthcheck
+
+// StatusNames sets the values used for each status in the health handler response, for consumers that expect a
+// different vocabulary. Internally the health check always uses StatusOK, StatusWarning, StatusCritical and
+// StatusSkipped. Any status without a name is output unchanged.
+type StatusNames struct {
+	OK       string
+	Warning  string
+	Critical string
+	Skipped  string
+}
+
+// IETFStatusNames are the status values used by the IETF health check response format draft. The draft has no value
+// for a skipped check, which is reported as warn, as it has not failed but its dependency is unavailable.
+var IETFStatusNames = StatusNames{
+	OK:       "pass",
+	Warning:  "warn",
+	Critical: "fail",
+	Skipped:  "warn",
+}
+
+// name returns the name configured for the provided status
+func (n StatusNames) name(status string) string {
+	var name string
+	switch status {
+	case StatusOK:
+		name = n.OK
+	case StatusWarning:
+		name = n.Warning
+	case StatusCritical:
+		name = n.Critical
+	case StatusSkipped:
+		name = n.Skipped
+	}
+
+	if name == "" {
+		return status
+	}
+	return name
+}
+
+// withStatusNames returns a copy of the health check, and the states of its checks, using the provided status names
+func (hc HealthCheck) withStatusNames(names StatusNames) HealthCheck {
+	checks := make([]*Check, 0, len(hc.Checks))
+	for _, check := range hc.Checks {
+		state := check.state.clone()
+		state.status = names.name(state.status)
+		for i := range state.history {
+			state.history[i].Status = names.name(state.history[i].Status)
+		}
+		checks = append(checks, &Check{state: state})
+	}
+
+	if hc.Override != nil {
+		override := *hc.Override
+		override.Status = names.name(override.Status)
+		hc.Override = &override
+	}
+	if hc.Groups != nil {
+		groups := make(map[string]string, len(hc.Groups))
+		for group, status := range hc.Groups {
+			groups[group] = names.name(status)
+		}
+		hc.Groups = groups
+	}
+	hc.Status = names.name(hc.Status)
+	hc.Checks = checks
+	return hc
+}
@@ -0,0 +1,369 @@
+package healthcheck
+
+import "time"
+
+// Option configures optional behaviour of a HealthCheck
+type Option func(*HealthCheck)
+
+// CheckOption configures optional behaviour of a Check
+type CheckOption func(*Check)
+
+// WithSoftStart configures a window following Start during which critical checks are reported as WARNING,
+// so that dependencies still warming up after a deploy do not make the app critical. Failures during the window
+// are still recorded, but do not start the critical error timeout, which is measured from the first critical error
+// once the window has passed.
+func WithSoftStart(window time.Duration) Option {
+	return func(hc *HealthCheck) {
+		hc.softStartWindow = window
+	}
+}
+
+// WithStaleAfter configures the number of its intervals after which a check that has not completed a run, e.g. as
+// its ticker is wedged, is recorded as CRITICAL as it is stale, so that the status it last recorded does not mask an
+// outage. The time the check last completed a run is left unchanged, and the check records its own status again once
+// it next completes a run. Checks are only marked stale while the health check is running.
+func WithStaleAfter(intervals int) Option {
+	return func(hc *HealthCheck) {
+		hc.staleIntervals = intervals
+	}
+}
+
+// WithStateStore configures a store to which the state of the checks is saved each time the status of a check, or the
+// time of the first critical error, changes, and from which it is restored when the health check is started, so that
+// the last success and failure of each check, and the critical timeout, survive a restart of the app. The state is
+// saved in the background, so that a slow store does not hold up the checks, and Stop waits for it to be saved. Only
+// checks that have not yet run are restored, and their results are replaced as soon as they run.
+func WithStateStore(store StateStore) Option {
+	return func(hc *HealthCheck) {
+		hc.stateStore = store
+		hc.stateSaver = newStateSaver(store)
+	}
+}
+
+// WithEncoder configures the encoder used by the health handler to write the health check response.
+// By default the health check is encoded as JSON.
+func WithEncoder(encoder Encoder) Option {
+	return func(hc *HealthCheck) {
+		hc.encoder = encoder
+	}
+}
+
+// WithWatchdog enables a watchdog that restarts the ticker of any check that has not run for longer than the
+// provided number of its intervals, so that a wedged ticker cannot stop a check from updating indefinitely
+func WithWatchdog(missedIntervals int) Option {
+	return func(hc *HealthCheck) {
+		hc.watchdogMissedIntervals = missedIntervals
+	}
+}
+
+// WithStatusListener registers a listener to be called whenever the overall health status changes. Listeners are
+// called synchronously after a check result is recorded or the health handler is called, in registration order,
+// unless a listener queue is configured with WithListenerQueue.
+func WithStatusListener(listener StatusListener) Option {
+	return func(hc *HealthCheck) {
+		hc.statusListeners = append(hc.statusListeners, listener)
+	}
+}
+
+// WithSeverity configures a function that maps the recorded state of the check to the status used for it when
+// aggregating the overall health status. By default the recorded status of the check is used.
+func WithSeverity(severity SeverityFunc) CheckOption {
+	return func(c *Check) {
+		c.severity = severity
+	}
+}
+
+// WithInterval configures the interval at which the check is run, overriding the interval of the health check,
+// e.g. to check a critical database more often than a rarely changing config service
+func WithInterval(interval time.Duration) CheckOption {
+	return func(c *Check) {
+		c.interval = interval
+	}
+}
+
+// WithTimeout configures how long a single run of the check may take before it is abandoned and recorded as
+// critical, overriding the default of the check interval less its maximum jitter
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(c *Check) {
+		c.timeout = timeout
+	}
+}
+
+// WithCriticalTimeout configures how long the check may be critical before the app is considered critical,
+// overriding the critical error timeout of the health check, e.g. to tolerate a longer outage of a dependency that is
+// slow to recover. As with the health check timeout, it is measured from the first critical error since the last
+// success of any check.
+func WithCriticalTimeout(timeout time.Duration) CheckOption {
+	return func(c *Check) {
+		c.criticalTimeout = timeout
+	}
+}
+
+// WithRetries configures the number of times a failed run of the check is retried straight away, after waiting the
+// provided backoff, before the failure is recorded, so that a transient blip such as a dropped connection does not
+// change the status of the check. A run fails if the checker records a critical status or returns an error. The
+// last failure time of the check is updated by each failed attempt, but its status only changes once every attempt
+// has failed.
+func WithRetries(retries int, backoff time.Duration) CheckOption {
+	return func(c *Check) {
+		c.retries = retries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithInformational marks the check as informational, so that it is reported in the health handler response but
+// never contributes to the overall health status, whatever its status
+func WithInformational() CheckOption {
+	return func(c *Check) {
+		c.informational = true
+	}
+}
+
+// WithNonCritical marks the check as non-critical, e.g. for an optional cache or a metrics sink, so that while it is
+// failing the overall health status is at most a warning, however long it has been failing. Its own status is still
+// reported as recorded.
+func WithNonCritical() CheckOption {
+	return func(c *Check) {
+		c.nonCritical = true
+	}
+}
+
+// WithGroup assigns the check to the named group, e.g. "storage" or "messaging" for the dependencies of a subsystem.
+// The status of each group is reported in the health handler response alongside the checks, and a request to the
+// health handler can be limited to a single group.
+func WithGroup(group string) CheckOption {
+	return func(c *Check) {
+		c.state.group = group
+	}
+}
+
+// WithLabel attaches a key/value label to the check, e.g. "tier" and "critical" or "region" and "eu-west-1". The labels
+// of each check are reported in the health handler response, and a request to the health handler can be limited to
+// the checks with a label, e.g. so that a load balancer probes only the critical checks of an endpoint that also
+// serves deep diagnostics.
+func WithLabel(key, value string) CheckOption {
+	return func(c *Check) {
+		if c.state.labels == nil {
+			c.state.labels = make(map[string]string)
+		}
+		c.state.labels[key] = value
+	}
+}
+
+// WithDependsOn declares the checks with the provided names that the check depends on, e.g. elasticsearch for a check of
+// a search API that always fails while elasticsearch is down. While any of them is critical, the check is not run and
+// is instead recorded as SKIPPED, which does not contribute to the overall health status, so that a single failure is
+// not reported more than once. The check is run as normal again once none of them is critical.
+func WithDependsOn(checks ...string) CheckOption {
+	return func(c *Check) {
+		c.dependsOn = append(c.dependsOn, checks...)
+	}
+}
+
+// WithRecordFilter configures a function that decides whether each fresh result of the check is recorded, e.g. to
+// ignore a single result that contradicts a strong trend. Results that are not recorded leave the previous state
+// in place.
+func WithRecordFilter(filter RecordFilter) CheckOption {
+	return func(c *Check) {
+		c.recordFilter = filter
+	}
+}
+
+// WithStatusNames configures the values used for each status in the health handler response, e.g. IETFStatusNames
+func WithStatusNames(names StatusNames) Option {
+	return func(hc *HealthCheck) {
+		hc.statusNames = &names
+	}
+}
+
+// WithPanicPolicy configures what happens when a checker panics, for any check without its own policy.
+// By default the panic is recovered and the check recorded as critical.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(hc *HealthCheck) {
+		hc.panicPolicy = policy
+	}
+}
+
+// WithCheckPanicPolicy configures what happens when the checker of the check panics, overriding the policy of the
+// health check
+func WithCheckPanicPolicy(policy PanicPolicy) CheckOption {
+	return func(c *Check) {
+		c.panicPolicy = policy
+	}
+}
+
+// WithTickerListener registers a listener to be called whenever the ticker of a check is started, stopped or
+// restarted. Listeners are called synchronously while the health check is locked, so must not call its methods.
+func WithTickerListener(listener TickerListener) Option {
+	return func(hc *HealthCheck) {
+		hc.tickerListeners = append(hc.tickerListeners, listener)
+	}
+}
+
+// WithCriticalFailures configures the number of consecutive critical runs after which a check makes the app critical,
+// independently of the check interval. The app becomes critical when either this or the critical error timeout is
+// reached, whichever is first. If the critical error timeout is 0, only the number of failures is used.
+func WithCriticalFailures(failures int) Option {
+	return func(hc *HealthCheck) {
+		hc.criticalFailures = failures
+	}
+}
+
+// WithListenerQueue configures status listeners to be called on a single worker goroutine, rather than by the ticker
+// or handler that changed the status, so that a slow listener (e.g. a webhook) cannot block checks from running.
+// Status changes are notified in the order they occurred. Up to size status changes can be waiting to be notified;
+// further changes are dropped or block until there is space, according to the provided policy. The size must be
+// positive. Listeners are passed a context with the values of the context of the change, e.g. the request to the
+// health handler, that is not cancelled with it, as they may be called once it is done.
+func WithListenerQueue(size int, policy OverflowPolicy) Option {
+	return func(hc *HealthCheck) {
+		hc.listenerQueue = newListenerQueue(size, policy)
+	}
+}
+
+// WithProbeBudget limits the number of checker runs across all checks combined to the provided number of probes per
+// window, so that checks whose intervals align do not burst probes at shared infrastructure. The budget refills
+// continuously. A check due to run while the budget is exhausted is deferred until its next tick, and the deferral
+// is logged and counted in its state. New returns an error if the number of probes or the window is not positive.
+func WithProbeBudget(probes int, per time.Duration) Option {
+	return func(hc *HealthCheck) {
+		hc.probeBudget = newProbeBudget(probes, per)
+	}
+}
+
+// WithJitter configures the fraction of the interval by which each run of a check is randomly offset, e.g. 0.1 for
+// up to ±10%, which spreads the load of checks that share an interval. The default is 0.05, and 0 disables jitter
+// so that checks run at exactly their interval. New returns an error if the jitter is negative or not less than 1.
+func WithJitter(jitter float64) Option {
+	return func(hc *HealthCheck) {
+		hc.jitter = jitter
+	}
+}
+
+// WithStaggeredStart delays the first run of each check when its ticker is started by a random offset of up to the
+// provided fraction of its interval, e.g. 1 to spread the first runs across the whole interval, so that an app with
+// many checks does not call all of its dependencies at once on boot. By default each check is run as soon as its
+// ticker is started. Until a check has first run, the app is reported as starting up and WaitForReady waits for it.
+func WithStaggeredStart(spread float64) Option {
+	return func(hc *HealthCheck) {
+		hc.staggeredStart = spread
+	}
+}
+
+// WithLogger configures the logger used for the events logged while running the checks, serving the health handler
+// and notifying listeners, which are otherwise logged with log.Event. The context of the health check, or of the
+// request to the health handler, is passed to the logger, so that trace IDs are kept.
+func WithLogger(logger Logger) Option {
+	return func(hc *HealthCheck) {
+		hc.logger = logger
+	}
+}
+
+// WithRelativeTimes includes the age of each check timestamp in the health handler response, e.g. last_checked_ago,
+// alongside the absolute timestamp, so that the response can be read without converting between timezones
+func WithRelativeTimes() Option {
+	return func(hc *HealthCheck) {
+		hc.relativeTimes = true
+	}
+}
+
+// WithRefreshOnRequest allows a request to the health handler to run every check before responding, by including the
+// query parameter refresh=true, e.g. for a deployment smoke test that must not see results from before the deployment.
+// The checks are run as Tick runs them, so the probe budget, if any, still applies. As each refresh makes a request to
+// every dependency, it is disabled by default.
+func WithRefreshOnRequest() Option {
+	return func(hc *HealthCheck) {
+		hc.refreshOnRequest = true
+	}
+}
+
+// WithHistory keeps the results of the most recent runs of each check, up to the provided number per check, e.g. to
+// see whether a check that is OK now has been flapping. The results are available from the History method of each
+// check, and are included in the health handler response when requested with the query parameter history=true.
+func WithHistory(size int) Option {
+	return func(hc *HealthCheck) {
+		hc.historySize = size
+	}
+}
+
+// WithClock configures the clock used for the time at which checks are run and recorded, the ticks of their tickers
+// and the critical error timeout, e.g. hctest.FakeClock so that a test can advance time without waiting. The timeout
+// of each run of a check and the backoff between its retries are always measured by the real clock.
+func WithClock(clock Clock) Option {
+	return func(hc *HealthCheck) {
+		hc.clock = clock
+	}
+}
+
+// WithMaxConcurrentChecks limits the number of checks running at once across all checks, so that an app with many
+// checks does not probe all of its dependencies at the same moment. A check due to run while the limit is reached
+// waits for another check to finish, and the timeout of the check only starts once it is running. New returns an
+// error if the maximum is not positive.
+func WithMaxConcurrentChecks(max int) Option {
+	return func(hc *HealthCheck) {
+		hc.workerPool = newWorkerPool(max)
+	}
+}
+
+// WithBackoff configures the check to be run less often while its dependency is down, so that it adds less load
+// during recovery. Once the check has failed the provided number of consecutive runs, its interval is doubled for each
+// further failure, up to the provided maximum, and is reset on its first success. The time the check is next due to
+// run is reported as next_check in the health handler response.
+func WithBackoff(failures int, max time.Duration) CheckOption {
+	return func(c *Check) {
+		c.backoffAfter = failures
+		c.backoffMax = max
+	}
+}
+
+// WithRunHook configures a hook that is called as each run of a check starts and once it has finished, e.g.
+// otel.NewRunHook to wrap each run in an OpenTelemetry span. The context returned by the hook is passed to the checker.
+// It may be passed more than once, e.g. to both trace runs and emit metrics for them, in which case the hooks are
+// started in order, each with the context returned by the previous one, and finished in reverse order.
+func WithRunHook(hook RunHook) Option {
+	return func(hc *HealthCheck) {
+		hc.runHook = chainRunHooks(hc.runHook, hook)
+	}
+}
+
+// WithStatusCodes configures the HTTP status code returned by the health handler for each status, e.g. 503 for
+// CRITICAL for a load balancer, or 503 for both WARNING and CRITICAL so that an app is only served traffic while OK.
+// Any status without a code uses the code of DefaultStatusCodes.
+func WithStatusCodes(codes StatusCodes) Option {
+	return func(hc *HealthCheck) {
+		hc.statusCodes = codes
+	}
+}
+
+// WithoutCheckDetails omits the checks from the health handler response, so that only the overall status, and the
+// status of each group, is reported, e.g. for a handler exposed outside the platform
+func WithoutCheckDetails() Option {
+	return func(hc *HealthCheck) {
+		hc.omitCheckDetails = true
+	}
+}
+
+// WithoutVersion omits the version information of the app from the health handler response, e.g. so as not to reveal
+// the version deployed to a handler exposed outside the platform
+func WithoutVersion() Option {
+	return func(hc *HealthCheck) {
+		hc.omitVersion = true
+	}
+}
+
+// WithLegacyDurations encodes the uptime of the health check as a number of milliseconds, as it was before durations
+// were encoded as both a number of seconds and a string such as 1h23m45s, for consumers that still parse the old
+// format
+func WithLegacyDurations() Option {
+	return func(hc *HealthCheck) {
+		hc.legacyDurations = true
+	}
+}
+
+// WithPaths configures the paths the health, liveness and readiness handlers are served on by Middleware and
+// RegisterRoutes. Any path that is not set uses the path of DefaultPaths.
+func WithPaths(paths Paths) Option {
+	return func(hc *HealthCheck) {
+		hc.paths = paths
+	}
+}
@@ -0,0 +1,59 @@
+// Package otel traces each run of a health check as an OpenTelemetry span. It is kept separate from the healthcheck
+// package so that apps that do not use OpenTelemetry do not depend on it.
+package otel
+
+import (
+	"context"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer used for the spans of the runs of checks
+const instrumentationName = "github.com/ONSdigital/dp-healthcheck/healthcheck"
+
+// Attribute keys set on the span of each run of a check
+const (
+	CheckKey    = attribute.Key("healthcheck.check")
+	StatusKey   = attribute.Key("healthcheck.status")
+	MessageKey  = attribute.Key("healthcheck.message")
+	DurationKey = attribute.Key("healthcheck.duration_ms")
+)
+
+// NewRunHook returns a run hook, to be configured with healthcheck.WithRunHook, that wraps each run of a check in a
+// span from the provided tracer provider, or the global tracer provider if it is nil. The span is a child of any span
+// in the context of the health check, and is passed to the checker in its context, so that the spans of a client
+// instrumented with OpenTelemetry are children of it. The span records the name of the check, and the status, message
+// and duration of the run. A run that returns an error or records a CRITICAL status sets the status of the span to
+// error.
+func NewRunHook(provider trace.TracerProvider) health.RunHook {
+	if provider == nil {
+		provider = otelapi.GetTracerProvider()
+	}
+	tracer := provider.Tracer(instrumentationName)
+
+	return func(ctx context.Context, check string) (context.Context, func(health.CheckResult, error)) {
+		ctx, span := tracer.Start(ctx, "healthcheck "+check, trace.WithAttributes(CheckKey.String(check)))
+
+		return ctx, func(result health.CheckResult, err error) {
+			span.SetAttributes(
+				StatusKey.String(result.Status),
+				MessageKey.String(result.Message),
+				DurationKey.Int64(int64(result.Duration/time.Millisecond)),
+			)
+
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case result.Status == health.StatusCritical:
+				span.SetStatus(codes.Error, result.Message)
+			}
+			span.End()
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package otel
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// attributes returns the attributes of the provided span by key
+func attributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	values := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		values[kv.Key] = kv.Value
+	}
+	return values
+}
+
+// spanNamed returns the ended span with the provided name, or nil if there is none
+func spanNamed(recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, span := range recorder.Ended() {
+		if span.Name() == name {
+			return span
+		}
+	}
+	return nil
+}
+
+func TestNewRunHook(t *testing.T) {
+	Convey("Given a health check that traces each run of its checks", t, func() {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		tracer := provider.Tracer("test")
+
+		hc, err := health.New(health.VersionInfo{}, time.Minute, time.Minute, health.WithRunHook(NewRunHook(provider)))
+		So(err, ShouldBeNil)
+
+		Convey("When a check whose client is instrumented is run within a span", func() {
+			So(hc.AddCheck("database", func(ctx context.Context, state *health.CheckState) error {
+				_, span := tracer.Start(ctx, "database ping")
+				span.End()
+				return state.Update(health.StatusOK, "database is ok", 0)
+			}), ShouldBeNil)
+
+			ctx, parent := tracer.Start(context.Background(), "parent")
+			hc.Tick(ctx)
+			parent.End()
+
+			Convey("Then the run is recorded as a span with the name and outcome of the check", func() {
+				span := spanNamed(recorder, "healthcheck database")
+				So(span, ShouldNotBeNil)
+				values := attributes(span)
+				So(values[CheckKey].AsString(), ShouldEqual, "database")
+				So(values[StatusKey].AsString(), ShouldEqual, health.StatusOK)
+				So(values[MessageKey].AsString(), ShouldEqual, "database is ok")
+				So(values, ShouldContainKey, DurationKey)
+				So(span.Status().Code, ShouldEqual, codes.Unset)
+			})
+
+			Convey("Then the span of the run is a child of the span the health check was run within", func() {
+				span := spanNamed(recorder, "healthcheck database")
+				So(span.Parent().SpanID(), ShouldEqual, parent.SpanContext().SpanID())
+			})
+
+			Convey("Then the span of the client is a child of the span of the run", func() {
+				span := spanNamed(recorder, "healthcheck database")
+				client := spanNamed(recorder, "database ping")
+				So(client, ShouldNotBeNil)
+				So(client.Parent().SpanID(), ShouldEqual, span.SpanContext().SpanID())
+			})
+		})
+
+		Convey("When a check that records a critical status is run", func() {
+			So(hc.AddCheck("queue", func(ctx context.Context, state *health.CheckState) error {
+				return state.Update(health.StatusCritical, "queue is unreachable", 0)
+			}), ShouldBeNil)
+			hc.Tick(context.Background())
+
+			Convey("Then the status of the span is error", func() {
+				span := spanNamed(recorder, "healthcheck queue")
+				So(span, ShouldNotBeNil)
+				So(span.Status().Code, ShouldEqual, codes.Error)
+				So(span.Status().Description, ShouldEqual, "queue is unreachable")
+			})
+		})
+
+		Convey("When a check that returns an error is run", func() {
+			So(hc.AddCheck("cache", func(ctx context.Context, state *health.CheckState) error {
+				return errors.New("connection refused")
+			}), ShouldBeNil)
+			hc.Tick(context.Background())
+
+			Convey("Then the error is recorded on the span", func() {
+				span := spanNamed(recorder, "healthcheck cache")
+				So(span, ShouldNotBeNil)
+				So(span.Status().Code, ShouldEqual, codes.Error)
+				So(span.Status().Description, ShouldEqual, "connection refused")
+				So(span.Events(), ShouldHaveLength, 1)
+				So(span.Events()[0].Name, ShouldEqual, "exception")
+			})
+		})
+	})
+}
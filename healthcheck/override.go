@@ -0,0 +1,66 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Override represents a status forced by the app, e.g. during planned maintenance, that is reported as the overall
+// health status in place of the status of the checks
+type Override struct {
+	Status string    `json:"status"`
+	Reason string    `json:"reason"`
+	Time   time.Time `json:"time"`
+}
+
+// SetOverride forces the overall health status to the provided status, whatever the status of the checks, until
+// ClearOverride is called, e.g. to report CRITICAL during planned maintenance so that load balancers drain traffic.
+// The checks keep running while the override is set. The override, along with the provided reason, is reported in the
+// health handler response, and the status listeners are notified if the status changes. An error is returned if the
+// status is not one of StatusOK, StatusWarning or StatusCritical.
+func (hc *HealthCheck) SetOverride(status, reason string) error {
+	switch status {
+	case StatusOK, StatusWarning, StatusCritical:
+	default:
+		return fmt.Errorf("invalid override status, must be one of %s, %s or %s", StatusOK, StatusWarning, StatusCritical)
+	}
+
+	hc.setOverride(&Override{
+		Status: status,
+		Reason: reason,
+		Time:   hc.now(),
+	})
+	return nil
+}
+
+// ClearOverride clears any status forced by SetOverride, so that the overall health status is again that of the checks
+func (hc *HealthCheck) ClearOverride() {
+	hc.setOverride(nil)
+}
+
+// setOverride records the provided override, recalculating the overall health status and notifying the status
+// listeners if it has changed
+func (hc *HealthCheck) setOverride(override *Override) {
+	ctx := context.Background()
+
+	hc.mutex.Lock()
+	hc.Override = override
+	change, changed := hc.setStatus(hc.getStatus(ctx, false))
+	snapshot := *hc
+	hc.mutex.Unlock()
+
+	hc.notifyStaleChecks(ctx)
+	if changed {
+		hc.notifyStatusChange(ctx, change, snapshot)
+	}
+}
+
+// overrideStatus returns the status of the override, if one is set, otherwise the provided status of the checks.
+// Callers must hold the lock.
+func (hc *HealthCheck) overrideStatus(status string) string {
+	if hc.Override != nil {
+		return hc.Override.Status
+	}
+	return status
+}
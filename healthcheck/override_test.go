@@ -0,0 +1,81 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOverride(t *testing.T) {
+	Convey("Given a health check with a status listener and an OK check", t, func() {
+		var changes []StatusChange
+		hc, err := New(version, criticalTimeout, interval, WithStatusListener(func(ctx context.Context, change StatusChange, hc HealthCheck) {
+			changes = append(changes, change)
+		}))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "", 0)
+		}), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+		hc.Tick(context.Background())
+		So(hc.Status, ShouldEqual, StatusOK)
+		changes = nil
+
+		Convey("Then setting an override with an invalid status fails", func() {
+			So(hc.SetOverride("MAINTENANCE", "planned maintenance"), ShouldNotBeNil)
+			So(hc.Override, ShouldBeNil)
+		})
+
+		Convey("When the status is overridden as critical", func() {
+			So(hc.SetOverride(StatusCritical, "planned maintenance"), ShouldBeNil)
+
+			Convey("Then the overall status is critical and the listeners are notified", func() {
+				So(hc.Status, ShouldEqual, StatusCritical)
+				So(hc.GetStatus(context.Background()), ShouldEqual, StatusCritical)
+				So(changes, ShouldHaveLength, 1)
+				So(changes[0].Current, ShouldEqual, StatusCritical)
+			})
+
+			Convey("Then the app is not healthy", func() {
+				So(hc.IsHealthy(), ShouldBeFalse)
+			})
+
+			Convey("Then the override remains in place as the checks run", func() {
+				hc.Tick(context.Background())
+				So(hc.Status, ShouldEqual, StatusCritical)
+				So(hc.Checks[0].state.Status(), ShouldEqual, StatusOK)
+			})
+
+			Convey("Then the health handler responds critical, reporting the override", func() {
+				w := httptest.NewRecorder()
+				hc.Handler(w, httptest.NewRequest("GET", "/health", nil))
+				So(w.Code, ShouldEqual, http.StatusInternalServerError)
+
+				var response HealthCheck
+				So(json.Unmarshal(w.Body.Bytes(), &response), ShouldBeNil)
+				So(response.Status, ShouldEqual, StatusCritical)
+				So(response.Override, ShouldNotBeNil)
+				So(response.Override.Status, ShouldEqual, StatusCritical)
+				So(response.Override.Reason, ShouldEqual, "planned maintenance")
+				So(response.Override.Time.Equal(hc.Override.Time), ShouldBeTrue)
+			})
+
+			Convey("When the override is cleared", func() {
+				hc.ClearOverride()
+
+				Convey("Then the overall status is again that of the checks", func() {
+					So(hc.Override, ShouldBeNil)
+					So(hc.Status, ShouldEqual, StatusOK)
+					So(hc.IsHealthy(), ShouldBeTrue)
+					So(changes, ShouldHaveLength, 2)
+					So(changes[1].Previous, ShouldEqual, StatusCritical)
+					So(changes[1].Current, ShouldEqual, StatusOK)
+				})
+			})
+		})
+	})
+}
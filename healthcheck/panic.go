@@ -0,0 +1,44 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// panicMessage is the message recorded when a checker panics and the panic is recovered
+const panicMessage = "checker panicked"
+
+// PanicPolicy determines what happens when a checker panics
+type PanicPolicy int
+
+const (
+	// RecoverPanics recovers the panic and records the check as critical, keeping the app alive in a degraded
+	// state. This is the default.
+	RecoverPanics PanicPolicy = iota + 1
+	// CrashOnPanic lets the panic crash the app, so that it fails fast and is restarted by its orchestrator
+	CrashOnPanic
+)
+
+// runChecker runs the checker of the check associated with the ticker against the provided state, handling any panic
+// according to the panic policy of the check, or the health check wide policy if the check has none
+func (ticker *ticker) runChecker(ctx context.Context, state *CheckState) (err error) {
+	policy := ticker.panicPolicy
+	if ticker.check.panicPolicy != 0 {
+		policy = ticker.check.panicPolicy
+	}
+
+	if policy != CrashOnPanic {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%s: %v", panicMessage, r)
+				logData := ticker.logData()
+				logData["stack"] = string(debug.Stack())
+				ticker.logEvent(ctx, levelError, panicMessage, err, logData)
+				state.Update(StatusCritical, err.Error(), 0)
+			}
+		}()
+	}
+
+	return ticker.check.checker(ctx, state)
+}
@@ -0,0 +1,35 @@
+package healthcheck
+
+import (
+	"net/http"
+)
+
+// LivenessHandler responds to a Kubernetes liveness probe, with 200 while the health check is running and 503 once it
+// has been stopped, its context is done, or if it has not been started. The health of the dependencies of the app is
+// not taken into account, so that a failing dependency does not cause the app to be restarted.
+func (hc *HealthCheck) LivenessHandler(w http.ResponseWriter, req *http.Request) {
+	if hc.isAlive() {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// ReadinessHandler responds to a Kubernetes readiness probe, with 200 when the app is healthy, as reported by
+// IsHealthy, and 503 otherwise, so that traffic is only routed to the app once its dependencies are available and
+// stops being routed to it once the critical timeout of a failing dependency has expired
+func (hc *HealthCheck) ReadinessHandler(w http.ResponseWriter, req *http.Request) {
+	if hc.IsHealthy() {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+// isAlive returns true if the health check has been started, and has not since been stopped or had its context done
+func (hc *HealthCheck) isAlive() bool {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	return hc.isStarted() && hc.context.Err() == nil
+}
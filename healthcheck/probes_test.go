@@ -0,0 +1,86 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLivenessHandler(t *testing.T) {
+	probe := func(hc *HealthCheck) int {
+		w := httptest.NewRecorder()
+		hc.LivenessHandler(w, httptest.NewRequest("GET", "/live", nil))
+		return w.Code
+	}
+
+	Convey("Given a Health Check with a critical check", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusCritical, "", 0)
+		}), ShouldBeNil)
+		defer hc.Stop()
+
+		Convey("Then it is not alive before it is started", func() {
+			So(probe(&hc), ShouldEqual, http.StatusServiceUnavailable)
+		})
+
+		Convey("When it is started", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			hc.Start(ctx)
+
+			Convey("Then it is alive whatever the status of its checks", func() {
+				So(probe(&hc), ShouldEqual, http.StatusOK)
+			})
+
+			Convey("Then it is not alive once its context is done", func() {
+				cancel()
+				So(probe(&hc), ShouldEqual, http.StatusServiceUnavailable)
+			})
+
+			Convey("Then it is not alive once it is stopped", func() {
+				hc.Stop()
+				So(probe(&hc), ShouldEqual, http.StatusServiceUnavailable)
+			})
+		})
+	})
+}
+
+func TestReadinessHandler(t *testing.T) {
+	probe := func(hc *HealthCheck) int {
+		w := httptest.NewRecorder()
+		hc.ReadinessHandler(w, httptest.NewRequest("GET", "/ready", nil))
+		return w.Code
+	}
+
+	Convey("Given a Health Check with a check", t, func() {
+		t0 := time.Now().UTC()
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error { return nil }), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+		state := hc.Checks[0].state
+
+		Convey("Then it is not ready before the check has succeeded", func() {
+			So(probe(&hc), ShouldEqual, http.StatusServiceUnavailable)
+		})
+
+		Convey("Then it is ready once the check has succeeded", func() {
+			So(state.Update(StatusOK, "", 0), ShouldBeNil)
+			So(probe(&hc), ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Then it is not ready once the check has been critical for longer than the critical timeout", func() {
+			So(state.Update(StatusCritical, "", 0), ShouldBeNil)
+			t20 := t0.Add(-20 * time.Minute)
+			state.lastSuccess = &t20
+			hc.timeOfFirstCriticalError = t0.Add(-2 * criticalTimeout)
+			So(probe(&hc), ShouldEqual, http.StatusServiceUnavailable)
+		})
+	})
+}
@@ -0,0 +1,94 @@
+// Package prometheus exposes the results of the checks of a health check as Prometheus metrics. It is a separate
+// package so that apps that do not use Prometheus do not depend on its client library.
+package prometheus
+
+import (
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	promclient "github.com/prometheus/client_golang/prometheus"
+)
+
+// statusValues are the values of the status gauge for each check status
+var statusValues = map[string]float64{
+	health.StatusOK:       0,
+	health.StatusWarning:  1,
+	health.StatusCritical: 2,
+}
+
+type collector struct {
+	hc           *health.HealthCheck
+	appStatus    *promclient.Desc
+	status       *promclient.Desc
+	sinceSuccess *promclient.Desc
+	duration     *promclient.Desc
+}
+
+// NewCollector returns a Prometheus collector for the results of the checks of the provided health check. It reports
+// the overall status of the app, once it has been calculated:
+//
+//	healthcheck_app_status the overall status, 0 for OK, 1 for WARNING and 2 for CRITICAL
+//
+// and for each check that has run:
+//
+//	healthcheck_status{name="..."} the most recently recorded status, 0 for OK, 1 for WARNING and 2 for CRITICAL
+//	healthcheck_seconds_since_last_success{name="..."} the time since the check last recorded OK, if it has
+//	healthcheck_duration_seconds{name="..."} how long the most recent run of the check took
+//
+// The metrics are read from a consistent copy of the health check when scraped, and do not trigger the checks to run.
+func NewCollector(hc *health.HealthCheck) promclient.Collector {
+	return &collector{
+		hc: hc,
+		appStatus: promclient.NewDesc(
+			"healthcheck_app_status",
+			"Overall status of the app: 0 for OK, 1 for WARNING and 2 for CRITICAL.",
+			nil, nil,
+		),
+		status: promclient.NewDesc(
+			"healthcheck_status",
+			"Most recently recorded status of the check: 0 for OK, 1 for WARNING and 2 for CRITICAL.",
+			[]string{"name"}, nil,
+		),
+		sinceSuccess: promclient.NewDesc(
+			"healthcheck_seconds_since_last_success",
+			"Seconds since the check last recorded an OK status.",
+			[]string{"name"}, nil,
+		),
+		duration: promclient.NewDesc(
+			"healthcheck_duration_seconds",
+			"Seconds taken by the most recent run of the check.",
+			[]string{"name"}, nil,
+		),
+	}
+}
+
+// Describe sends the descriptors of the metrics reported by the collector
+func (c *collector) Describe(ch chan<- *promclient.Desc) {
+	ch <- c.appStatus
+	ch <- c.status
+	ch <- c.sinceSuccess
+	ch <- c.duration
+}
+
+// Collect sends the metrics for each check of the health check that has run
+func (c *collector) Collect(ch chan<- promclient.Metric) {
+	state := c.hc.GetState()
+	now := time.Now().UTC()
+
+	if value, ok := statusValues[state.Status]; ok {
+		ch <- promclient.MustNewConstMetric(c.appStatus, promclient.GaugeValue, value)
+	}
+
+	for _, check := range state.Checks {
+		checkState := check.State()
+		name := checkState.Name()
+
+		if value, ok := statusValues[checkState.Status()]; ok {
+			ch <- promclient.MustNewConstMetric(c.status, promclient.GaugeValue, value, name)
+			ch <- promclient.MustNewConstMetric(c.duration, promclient.GaugeValue, checkState.Duration().Seconds(), name)
+		}
+		if lastSuccess := checkState.LastSuccess(); lastSuccess != nil {
+			ch <- promclient.MustNewConstMetric(c.sinceSuccess, promclient.GaugeValue, now.Sub(*lastSuccess).Seconds(), name)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package prometheus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	promclient "github.com/prometheus/client_golang/prometheus"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// gather returns the values of the metrics gathered from the provided registry, by metric name and check name, or an
+// empty check name for metrics of the app
+func gather(registry *promclient.Registry) (map[string]map[string]float64, error) {
+	families, err := registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			var name string
+			if labels := metric.GetLabel(); len(labels) > 0 {
+				name = labels[0].GetValue()
+			}
+			values[family.GetName()][name] = metric.GetGauge().GetValue()
+		}
+	}
+	return values, nil
+}
+
+func TestCollector(t *testing.T) {
+	Convey("Given a health check with checks that have recorded each status, and a check that has not run", t, func() {
+		version := health.VersionInfo{Version: "1.0.0"}
+		hc, err := health.New(version, time.Minute, time.Minute)
+		So(err, ShouldBeNil)
+		for name, status := range map[string]string{
+			"mongodb": health.StatusOK,
+			"kafka":   health.StatusWarning,
+			"zebedee": health.StatusCritical,
+		} {
+			status := status
+			So(hc.AddCheck(name, func(ctx context.Context, state *health.CheckState) error {
+				return state.Update(status, "", 0)
+			}), ShouldBeNil)
+		}
+		hc.Tick(context.Background())
+		So(hc.AddCheck("vault", func(ctx context.Context, state *health.CheckState) error {
+			return state.Update(health.StatusOK, "", 0)
+		}), ShouldBeNil)
+
+		registry := promclient.NewPedanticRegistry()
+		So(registry.Register(NewCollector(&hc)), ShouldBeNil)
+
+		Convey("When the metrics are gathered", func() {
+			values, err := gather(registry)
+			So(err, ShouldBeNil)
+
+			Convey("Then the status of each check that has run is reported", func() {
+				So(values["healthcheck_status"], ShouldResemble, map[string]float64{
+					"mongodb": 0,
+					"kafka":   1,
+					"zebedee": 2,
+				})
+			})
+
+			Convey("Then the time since the last success is reported for the check that has succeeded", func() {
+				So(values["healthcheck_seconds_since_last_success"], ShouldHaveLength, 1)
+				So(values["healthcheck_seconds_since_last_success"]["mongodb"], ShouldBeBetween, 0, 1)
+			})
+
+			Convey("Then the overall status of the app is reported", func() {
+				So(values["healthcheck_app_status"], ShouldResemble, map[string]float64{"": 1})
+			})
+
+			Convey("Then the duration of each check that has run is reported", func() {
+				So(values["healthcheck_duration_seconds"], ShouldHaveLength, 3)
+				So(values["healthcheck_duration_seconds"]["mongodb"], ShouldBeBetween, 0, 1)
+			})
+
+			Convey("Then gathering the metrics does not run the checks", func() {
+				So(hc.GetState().Checks[3].State().LastChecked(), ShouldBeNil)
+			})
+		})
+	})
+}
@@ -0,0 +1,122 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+// OverflowPolicy determines what happens when a status change is notified while the listener queue is full
+type OverflowPolicy int
+
+const (
+	// DropOnOverflow discards the status change, logging a warning, so that a slow listener can never block the
+	// ticker or handler that caused the change. Listeners may miss transitions while the queue is full.
+	DropOnOverflow OverflowPolicy = iota
+	// BlockOnOverflow waits for space in the queue, so that listeners see every transition. A slow listener can
+	// block the ticker or handler that caused the change until the queue drains.
+	BlockOnOverflow
+)
+
+// listenerQueue runs notifications one at a time, in the order they were queued, on a single worker goroutine that
+// is only running while there are notifications queued
+type listenerQueue struct {
+	notifications chan func()
+	size          int
+	policy        OverflowPolicy
+	running       bool
+	logger        Logger
+	mutex         *sync.Mutex
+}
+
+// newListenerQueue creates a queue holding up to size notifications waiting to be run. A size that is not positive
+// is rejected when the health check is created.
+func newListenerQueue(size int, policy OverflowPolicy) *listenerQueue {
+	q := &listenerQueue{
+		size:   size,
+		policy: policy,
+		mutex:  &sync.Mutex{},
+	}
+	if size > 0 {
+		q.notifications = make(chan func(), size)
+	}
+	return q
+}
+
+// enqueue queues the provided notification to be run, handling a full queue according to the overflow policy. The
+// notification is passed a context with the values of the provided context that is never cancelled, as it may be run
+// once the caller has returned, e.g. after the request to the health handler that changed the status has completed.
+func (q *listenerQueue) enqueue(ctx context.Context, notification func(ctx context.Context)) {
+	detached := detachedContext{parent: ctx}
+	run := func() {
+		notification(detached)
+	}
+
+	select {
+	case q.notifications <- run:
+	default:
+		if q.policy == DropOnOverflow {
+			logEvent(ctx, q.logger, levelWarn, "dropping status change notification as listener queue is full", nil, log.Data{"queue_size": q.size})
+			return
+		}
+		// the worker is started before waiting for space in the queue, as only the worker makes space
+		q.start()
+		q.notifications <- run
+	}
+	q.start()
+}
+
+// start starts the worker goroutine if it is not already running
+func (q *listenerQueue) start() {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if !q.running {
+		q.running = true
+		go q.run()
+	}
+}
+
+// run runs queued notifications until the queue is empty
+func (q *listenerQueue) run() {
+	for {
+		q.mutex.Lock()
+		select {
+		case notification := <-q.notifications:
+			q.mutex.Unlock()
+			notification()
+		default:
+			q.running = false
+			q.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// detachedContext is a context with the values of its parent, e.g. for the trace ID of logged events, but without its
+// deadline or cancellation
+type detachedContext struct {
+	parent context.Context
+}
+
+// Deadline returns no deadline, as the context is never cancelled
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+// Done returns nil, as the context is never cancelled
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+// Err returns nil, as the context is never cancelled
+func (detachedContext) Err() error {
+	return nil
+}
+
+// Value returns the value of the parent context for the provided key
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
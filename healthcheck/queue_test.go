@@ -0,0 +1,110 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestListenerQueue(t *testing.T) {
+	Convey("Given a listener queue", t, func() {
+		var (
+			mutex sync.Mutex
+			order []int
+		)
+		record := func(i int) func(context.Context) {
+			return func(context.Context) {
+				mutex.Lock()
+				defer mutex.Unlock()
+				order = append(order, i)
+			}
+		}
+		getOrder := func() []int {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return append([]int{}, order...)
+		}
+
+		Convey("When notifications are queued faster than they are run", func() {
+			q := newListenerQueue(10, BlockOnOverflow)
+			release := make(chan bool)
+			q.enqueue(context.Background(), func(context.Context) { <-release })
+			for i := 0; i < 5; i++ {
+				q.enqueue(context.Background(), record(i))
+			}
+			close(release)
+			time.Sleep(50 * time.Millisecond)
+
+			Convey("Then they are run in the order they were queued", func() {
+				So(getOrder(), ShouldResemble, []int{0, 1, 2, 3, 4})
+			})
+		})
+
+		Convey("When the queue is full and the overflow policy is to drop", func() {
+			q := newListenerQueue(1, DropOnOverflow)
+			release := make(chan bool)
+			started := make(chan bool)
+			q.enqueue(context.Background(), func(context.Context) { close(started); <-release })
+			<-started
+			q.enqueue(context.Background(), record(0))
+			q.enqueue(context.Background(), record(1))
+			close(release)
+			time.Sleep(50 * time.Millisecond)
+
+			Convey("Then notifications that do not fit are dropped without blocking", func() {
+				So(getOrder(), ShouldResemble, []int{0})
+			})
+		})
+
+		Convey("When the queue is full and the overflow policy is to block", func() {
+			q := newListenerQueue(1, BlockOnOverflow)
+			release := make(chan bool)
+			started := make(chan bool)
+			q.enqueue(context.Background(), func(context.Context) { close(started); <-release })
+			<-started
+			q.enqueue(context.Background(), record(0))
+
+			queued := make(chan bool)
+			go func() {
+				q.enqueue(context.Background(), record(1))
+				close(queued)
+			}()
+
+			Convey("Then the notification waits for space in the queue", func() {
+				select {
+				case <-queued:
+					t.Error("expected enqueue to block while the queue is full")
+				case <-time.After(50 * time.Millisecond):
+				}
+
+				close(release)
+				<-queued
+				time.Sleep(50 * time.Millisecond)
+				So(getOrder(), ShouldResemble, []int{0, 1})
+			})
+		})
+
+		Convey("When a notification is queued with a context that is then cancelled", func() {
+			q := newListenerQueue(1, DropOnOverflow)
+			ctx, cancel := context.WithCancel(context.WithValue(context.Background(), runHookKey{}, "request"))
+			received := make(chan context.Context, 1)
+			release := make(chan bool)
+			q.enqueue(ctx, func(ctx context.Context) {
+				<-release
+				received <- ctx
+			})
+			cancel()
+			close(release)
+
+			Convey("Then the notification is passed a context with its values that is not cancelled", func() {
+				notified := <-received
+				So(notified.Err(), ShouldBeNil)
+				So(notified.Done(), ShouldBeNil)
+				So(notified.Value(runHookKey{}), ShouldEqual, "request")
+			})
+		})
+	})
+}
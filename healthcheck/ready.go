@@ -0,0 +1,59 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+)
+
+// readyPollInterval is how often WaitForReady checks whether the app is healthy
+const readyPollInterval = 50 * time.Millisecond
+
+// IsHealthy returns true once every check has recorded an OK status at least once, and the app is not critical, e.g.
+// to gate startup or a readiness probe on its dependencies. A check that has never run, or has only ever failed, is
+// not ready. Informational and non-critical checks are ignored. If a status has been forced with SetOverride, the app
+// is healthy unless that status is critical.
+func (hc *HealthCheck) IsHealthy() bool {
+	healthy, ctx := hc.isHealthy()
+	hc.notifyStaleChecks(ctx)
+	return healthy
+}
+
+// isHealthy returns whether the app is healthy, as reported by IsHealthy, and the context of the health check for
+// notifying the checks marked as stale while working it out
+func (hc *HealthCheck) isHealthy() (bool, context.Context) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	ctx := hc.context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	hc.markStaleChecks(hc.now())
+	if hc.Override != nil {
+		return hc.Override.Status != StatusCritical, ctx
+	}
+
+	for _, check := range hc.Checks {
+		if !check.informational && !check.nonCritical && check.state.LastSuccess() == nil {
+			return false, ctx
+		}
+	}
+	return hc.isAppHealthy() != StatusCritical, ctx
+}
+
+// WaitForReady blocks until the app is healthy, as reported by IsHealthy, returning the error of the provided context
+// if it is done first
+func (hc *HealthCheck) WaitForReady(ctx context.Context) error {
+	poll := time.NewTicker(readyPollInterval)
+	defer poll.Stop()
+
+	for !hc.IsHealthy() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-poll.C:
+		}
+	}
+	return nil
+}
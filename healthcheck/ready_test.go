@@ -0,0 +1,74 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestIsHealthy(t *testing.T) {
+	Convey("Given a Health Check with a check that has not yet run", t, func() {
+		var (
+			mutex  sync.Mutex
+			status = StatusCritical
+		)
+		checker := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(status, "", 0)
+		}
+		setStatus := func(s string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			status = s
+		}
+
+		hc, err := New(version, criticalTimeout, interval)
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		So(hc.AddCheck("metric", checker, WithInformational()), ShouldBeNil)
+
+		Convey("Then the app is not healthy", func() {
+			So(hc.IsHealthy(), ShouldBeFalse)
+		})
+
+		Convey("When the health check is started and the check has only failed", func() {
+			hc.Start(context.Background())
+			defer hc.Stop()
+			time.Sleep(2 * interval)
+
+			Convey("Then the app is not healthy", func() {
+				So(hc.IsHealthy(), ShouldBeFalse)
+			})
+
+			Convey("Then waiting for the app to be ready returns the error of the context when it is done", func() {
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				defer cancel()
+				err := hc.WaitForReady(ctx)
+				So(err == context.DeadlineExceeded, ShouldBeTrue)
+			})
+
+			Convey("When the check recovers while waiting for the app to be ready", func() {
+				setStatus(StatusOK)
+				ctx, cancel := context.WithTimeout(context.Background(), 5*interval)
+				defer cancel()
+				err := hc.WaitForReady(ctx)
+
+				Convey("Then the app becomes healthy", func() {
+					So(err, ShouldBeNil)
+					So(hc.IsHealthy(), ShouldBeTrue)
+				})
+
+				Convey("Then the app remains healthy while the check is failing but not yet critical", func() {
+					setStatus(StatusCritical)
+					time.Sleep(2 * interval)
+					So(hc.IsHealthy(), ShouldBeTrue)
+				})
+			})
+		})
+	})
+}
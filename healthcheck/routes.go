@@ -0,0 +1,69 @@
+package healthcheck
+
+import "net/http"
+
+// Paths are the paths the health, liveness and readiness handlers are served on by Middleware and RegisterRoutes
+type Paths struct {
+	Health    string
+	Liveness  string
+	Readiness string
+}
+
+// DefaultPaths are the paths used for any handler whose path has not been configured
+var DefaultPaths = Paths{
+	Health:    "/health",
+	Liveness:  "/health/live",
+	Readiness: "/health/ready",
+}
+
+// withDefaults returns the paths with the default path in place of any that has not been set
+func (p Paths) withDefaults() Paths {
+	if p.Health == "" {
+		p.Health = DefaultPaths.Health
+	}
+	if p.Liveness == "" {
+		p.Liveness = DefaultPaths.Liveness
+	}
+	if p.Readiness == "" {
+		p.Readiness = DefaultPaths.Readiness
+	}
+	return p
+}
+
+// Paths returns the paths the health, liveness and readiness handlers are served on, as configured with WithPaths,
+// e.g. for registering the handlers with a router
+func (hc *HealthCheck) Paths() Paths {
+	return hc.paths.withDefaults()
+}
+
+// Middleware returns a handler that serves GET and HEAD requests for the paths of the health, liveness and readiness
+// handlers, passing any other request to the provided handler, so that the endpoints can be added to an app without
+// registering them with its router
+func (hc *HealthCheck) Middleware(next http.Handler) http.Handler {
+	paths := hc.Paths()
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			switch req.URL.Path {
+			case paths.Health:
+				hc.Handler(w, req)
+				return
+			case paths.Liveness:
+				hc.LivenessHandler(w, req)
+				return
+			case paths.Readiness:
+				hc.ReadinessHandler(w, req)
+				return
+			}
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// RegisterRoutes registers the health, liveness and readiness handlers with the provided mux on their paths. The chi
+// and mux subpackages register them with chi and gorilla/mux routers.
+func (hc *HealthCheck) RegisterRoutes(mux *http.ServeMux) {
+	paths := hc.Paths()
+	mux.HandleFunc(paths.Health, hc.Handler)
+	mux.HandleFunc(paths.Liveness, hc.LivenessHandler)
+	mux.HandleFunc(paths.Readiness, hc.ReadinessHandler)
+}
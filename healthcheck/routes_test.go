@@ -0,0 +1,103 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPaths(t *testing.T) {
+	Convey("Given a Health Check without configured paths", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+
+		Convey("Then the default paths are used", func() {
+			So(hc.Paths(), ShouldResemble, DefaultPaths)
+		})
+	})
+
+	Convey("Given a Health Check with some configured paths", t, func() {
+		hc, err := New(version, criticalTimeout, interval, WithPaths(Paths{Health: "/healthz", Readiness: "/readyz"}))
+		So(err, ShouldBeNil)
+
+		Convey("Then the default path is used for any path that is not configured", func() {
+			So(hc.Paths(), ShouldResemble, Paths{Health: "/healthz", Liveness: "/health/live", Readiness: "/readyz"})
+		})
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	Convey("Given a running Health Check with a check that has failed, wrapping an app handler", t, func() {
+		hc, err := New(version, criticalTimeout, interval, WithPaths(Paths{Liveness: "/livez"}))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusCritical, "", 0)
+		}), ShouldBeNil)
+		ctx := context.Background()
+		hc.Start(ctx)
+		defer hc.Stop()
+		hc.Tick(ctx)
+
+		app := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})
+		handler := hc.Middleware(app)
+		serve := func(method, path string) *httptest.ResponseRecorder {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+			return w
+		}
+
+		Convey("Then a request for the health path is served by the health handler", func() {
+			w := serve("GET", "/health")
+			So(w.Code, ShouldEqual, http.StatusTooManyRequests)
+			So(w.Header().Get("Content-Type"), ShouldStartWith, "application/json")
+		})
+
+		Convey("Then a request for the liveness path is served by the liveness handler", func() {
+			So(serve("GET", "/livez").Code, ShouldEqual, http.StatusOK)
+			So(serve("HEAD", "/livez").Code, ShouldEqual, http.StatusOK)
+		})
+
+		Convey("Then a request for the readiness path is served by the readiness handler", func() {
+			So(serve("GET", "/health/ready").Code, ShouldEqual, http.StatusServiceUnavailable)
+		})
+
+		Convey("Then any other request is passed to the app handler", func() {
+			So(serve("GET", "/datasets").Code, ShouldEqual, http.StatusTeapot)
+			So(serve("GET", "/health/live").Code, ShouldEqual, http.StatusTeapot)
+			So(serve("POST", "/health").Code, ShouldEqual, http.StatusTeapot)
+		})
+	})
+}
+
+func TestRegisterRoutes(t *testing.T) {
+	Convey("Given a running Health Check with a check that has failed, registered with a mux", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusCritical, "", 0)
+		}), ShouldBeNil)
+		ctx := context.Background()
+		hc.Start(ctx)
+		defer hc.Stop()
+		hc.Tick(ctx)
+
+		mux := http.NewServeMux()
+		hc.RegisterRoutes(mux)
+		serve := func(path string) int {
+			w := httptest.NewRecorder()
+			mux.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			return w.Code
+		}
+
+		Convey("Then each handler is served on its path", func() {
+			So(serve("/health"), ShouldEqual, http.StatusTooManyRequests)
+			So(serve("/health/live"), ShouldEqual, http.StatusOK)
+			So(serve("/health/ready"), ShouldEqual, http.StatusServiceUnavailable)
+		})
+	})
+}
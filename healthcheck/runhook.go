@@ -0,0 +1,57 @@
+package healthcheck
+
+import "context"
+
+// RunHook is called as each run of a check starts, e.g. to trace the run. The context it returns is passed to the
+// checker, so that anything the hook adds to it (such as a span) is seen by the instrumentation of the dependency.
+// The function it returns is called once the run has finished, with its result and any error returned by the checker,
+// including a run whose result is discarded as the health check is shutting down.
+type RunHook func(ctx context.Context, check string) (context.Context, func(result CheckResult, err error))
+
+// startRun calls the run hook of the ticker, if any, returning the context to run the checker with and the function
+// to call once the run has finished
+func (ticker *ticker) startRun(ctx context.Context) (context.Context, func(result CheckResult, err error)) {
+	if ticker.runHook == nil {
+		return ctx, func(CheckResult, error) {}
+	}
+
+	runCtx, finish := ticker.runHook(ctx, ticker.check.state.Name())
+	if runCtx == nil {
+		runCtx = ctx
+	}
+	if finish == nil {
+		finish = func(CheckResult, error) {}
+	}
+	return runCtx, finish
+}
+
+// chainRunHooks returns a run hook that starts the first hook and then the second, with the context returned by the
+// first, and finishes them in reverse order. Either hook may be nil.
+func chainRunHooks(first, second RunHook) RunHook {
+	if first == nil {
+		return second
+	}
+	if second == nil {
+		return first
+	}
+
+	return func(ctx context.Context, check string) (context.Context, func(result CheckResult, err error)) {
+		firstCtx, finishFirst := first(ctx, check)
+		if firstCtx == nil {
+			firstCtx = ctx
+		}
+		secondCtx, finishSecond := second(firstCtx, check)
+		if secondCtx == nil {
+			secondCtx = firstCtx
+		}
+
+		return secondCtx, func(result CheckResult, err error) {
+			if finishSecond != nil {
+				finishSecond(result, err)
+			}
+			if finishFirst != nil {
+				finishFirst(result, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type runHookKey struct{}
+
+func TestRunHook(t *testing.T) {
+	Convey("Given a Health Check with a run hook that adds a value to the context of each run", t, func() {
+		var (
+			started  []string
+			results  []CheckResult
+			errs     []error
+			received []interface{}
+		)
+		hook := func(ctx context.Context, check string) (context.Context, func(CheckResult, error)) {
+			started = append(started, check)
+			return context.WithValue(ctx, runHookKey{}, check), func(result CheckResult, err error) {
+				results = append(results, result)
+				errs = append(errs, err)
+			}
+		}
+
+		hc, err := New(version, criticalTimeout, interval, WithRunHook(hook))
+		So(err, ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+
+		Convey("When a check that updates its state is run", func() {
+			So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+				received = append(received, ctx.Value(runHookKey{}))
+				return state.Update(StatusWarning, "degraded", 0)
+			}), ShouldBeNil)
+			hc.Tick(context.Background())
+
+			Convey("Then the checker is passed the context returned by the hook", func() {
+				So(started, ShouldResemble, []string{"check 1"})
+				So(received, ShouldResemble, []interface{}{"check 1"})
+			})
+
+			Convey("Then the hook is called with the result of the run once it has finished", func() {
+				So(results, ShouldHaveLength, 1)
+				So(results[0].Status, ShouldEqual, StatusWarning)
+				So(results[0].Message, ShouldEqual, "degraded")
+				So(results[0].Time, ShouldEqual, *hc.Checks[0].state.LastChecked())
+				So(errs[0], ShouldBeNil)
+			})
+		})
+
+		Convey("When a check that returns an error is run", func() {
+			checkErr := errors.New("connection refused")
+			So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+				return checkErr
+			}), ShouldBeNil)
+			hc.Tick(context.Background())
+
+			Convey("Then the hook is called with the error", func() {
+				So(results, ShouldHaveLength, 1)
+				So(results[0].Message, ShouldEqual, "connection refused")
+				So(errs[0] == checkErr, ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("Given a run hook that returns neither a context nor a finish function", t, func() {
+		hc, err := New(version, criticalTimeout, interval, WithRunHook(func(ctx context.Context, check string) (context.Context, func(CheckResult, error)) {
+			return nil, nil
+		}))
+		So(err, ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+
+		Convey("Then the check is run with the original context", func() {
+			ctx := context.WithValue(context.Background(), runHookKey{}, "original")
+			var received interface{}
+			So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+				received = ctx.Value(runHookKey{})
+				return state.Update(StatusOK, "", 0)
+			}), ShouldBeNil)
+			hc.Tick(ctx)
+
+			So(received, ShouldEqual, "original")
+			So(hc.Checks[0].state.Status(), ShouldEqual, StatusOK)
+		})
+	})
+
+	Convey("Given a Health Check with two run hooks", t, func() {
+		var calls []string
+		hook := func(name string) RunHook {
+			return func(ctx context.Context, check string) (context.Context, func(CheckResult, error)) {
+				calls = append(calls, "start "+name)
+				return context.WithValue(ctx, runHookKey{}, name), func(CheckResult, error) {
+					calls = append(calls, "finish "+name)
+				}
+			}
+		}
+
+		hc, err := New(version, criticalTimeout, interval, WithRunHook(hook("first")), WithRunHook(hook("second")))
+		So(err, ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+
+		Convey("When a check is run", func() {
+			var received interface{}
+			So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+				received = ctx.Value(runHookKey{})
+				return state.Update(StatusOK, "", 0)
+			}), ShouldBeNil)
+			hc.Tick(context.Background())
+
+			Convey("Then the hooks are started in order and finished in reverse order", func() {
+				So(calls, ShouldResemble, []string{"start first", "start second", "finish second", "finish first"})
+			})
+
+			Convey("Then the checker is passed the context returned by the last hook", func() {
+				So(received, ShouldEqual, "second")
+			})
+		})
+	})
+}
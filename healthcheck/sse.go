@@ -0,0 +1,141 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// sseBufferSize is the number of events that can be waiting to be written to each client of the SSE handler, beyond
+// which further events are dropped
+const sseBufferSize = 32
+
+// sseStatusEvent is the data of the status event written by the SSE handler when the overall health status changes
+type sseStatusEvent struct {
+	Status string    `json:"status"`
+	Time   time.Time `json:"time"`
+}
+
+// sseCheckEvent is the data of the check event written by the SSE handler when the status of a check changes
+type sseCheckEvent struct {
+	Check    string     `json:"check"`
+	Previous string     `json:"previous,omitempty"`
+	Current  string     `json:"current"`
+	Time     *time.Time `json:"time,omitempty"`
+}
+
+// SSEHandler streams the health of the app as Server-Sent Events, e.g. for a dashboard to show live dependency health
+// without polling the health handler. A status event is written whenever the overall health status changes, and a
+// check event whenever the status of a check changes, each with JSON data. On connecting, the client is sent the
+// current overall status and the current status of each check that has run. As with Subscribe, only transitions are
+// sent, and events are dropped rather than block the checks if the client is not keeping up. The stream ends when the
+// request is cancelled.
+func (hc *HealthCheck) SSEHandler(w http.ResponseWriter, req *http.Request) {
+	ctx := req.Context()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logEvent(ctx, hc.logger, levelDefault, "response writer does not support streaming server-sent events", nil, nil)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// subscribe before taking the current state, so that no transition is missed in between
+	statuses := make(chan string, sseBufferSize)
+	unsubscribe := hc.Subscribe(statuses)
+	defer unsubscribe()
+
+	checkChanges := make(chan CheckStatusChange, sseBufferSize)
+	unsubscribeChecks := hc.SubscribeChecks(func(ctx context.Context, change CheckStatusChange) {
+		select {
+		case checkChanges <- change:
+		default:
+		}
+	})
+	defer unsubscribeChecks()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	status, checks := hc.currentStatuses()
+	if status != "" {
+		if !hc.writeEvent(w, req, "status", sseStatusEvent{Status: hc.statusName(status), Time: hc.now()}) {
+			return
+		}
+	}
+	for _, check := range checks {
+		if !hc.writeEvent(w, req, "check", check) {
+			return
+		}
+	}
+	flusher.Flush()
+
+	for {
+		var ok bool
+		select {
+		case <-ctx.Done():
+			return
+		case status := <-statuses:
+			ok = hc.writeEvent(w, req, "status", sseStatusEvent{Status: hc.statusName(status), Time: hc.now()})
+		case change := <-checkChanges:
+			ok = hc.writeEvent(w, req, "check", sseCheckEvent{
+				Check:    change.Check,
+				Previous: hc.statusName(change.Previous),
+				Current:  hc.statusName(change.Current),
+				Time:     &change.Time,
+			})
+		}
+		if !ok {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// currentStatuses returns the current overall health status and a check event for the current status of each check
+// that has run
+func (hc *HealthCheck) currentStatuses() (string, []sseCheckEvent) {
+	hc.mutex.RLock()
+	defer hc.mutex.RUnlock()
+
+	var checks []sseCheckEvent
+	for _, check := range hc.Checks {
+		if !check.hasRun() {
+			continue
+		}
+		checks = append(checks, sseCheckEvent{
+			Check:   check.state.Name(),
+			Current: hc.statusName(check.state.Status()),
+			Time:    check.state.LastChecked(),
+		})
+	}
+	return hc.Status, checks
+}
+
+// statusName returns the name configured for the provided status in the health handler response, if any
+func (hc *HealthCheck) statusName(status string) string {
+	if hc.statusNames == nil {
+		return status
+	}
+	return hc.statusNames.name(status)
+}
+
+// writeEvent writes a server-sent event with the provided name and the JSON encoding of the provided data, returning
+// false if it could not be written
+func (hc *HealthCheck) writeEvent(w http.ResponseWriter, req *http.Request, event string, data interface{}) bool {
+	b, err := json.Marshal(data)
+	if err != nil {
+		logEvent(req.Context(), hc.logger, levelDefault, "failed to encode server-sent event", err, nil)
+		return false
+	}
+
+	if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		logEvent(req.Context(), hc.logger, levelDefault, "failed to write server-sent event", err, nil)
+		return false
+	}
+	return true
+}
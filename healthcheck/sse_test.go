@@ -0,0 +1,160 @@
+package healthcheck
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// sseEvent is a server-sent event read from the SSE handler
+type sseEvent struct {
+	name string
+	data map[string]interface{}
+}
+
+// readSSEEvents reads the server-sent events from the provided body, sending each to the returned channel
+func readSSEEvents(t *testing.T, body *bufio.Reader) <-chan sseEvent {
+	events := make(chan sseEvent, 10)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		for {
+			line, err := body.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSuffix(line, "\n")
+			switch {
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.data); err != nil {
+					t.Error(err)
+				}
+			case line == "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return events
+}
+
+// nextSSEEvent returns the next server-sent event, or an empty event if none is received within a second
+func nextSSEEvent(events <-chan sseEvent) sseEvent {
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		return sseEvent{}
+	}
+}
+
+func TestSSEHandler(t *testing.T) {
+	Convey("Given a running health check with a check whose status can be changed", t, func() {
+		var (
+			mutex  sync.Mutex
+			status = StatusOK
+		)
+		checker := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(status, "", 0)
+		}
+
+		hc, err := New(version, time.Hour, time.Hour, WithStatusNames(IETFStatusNames))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		ctx := context.Background()
+		hc.Start(ctx)
+		defer hc.Stop()
+		So(waitFor(hc.Checks[0].hasRun), ShouldBeTrue)
+		So(waitFor(func() bool { return atomic.LoadInt32(&hc.tickers[0].inFlight) == 0 }), ShouldBeTrue)
+		hc.GetStatus(ctx)
+
+		server := httptest.NewServer(http.HandlerFunc(hc.SSEHandler))
+		defer server.Close()
+
+		Convey("When a client connects to the SSE handler", func() {
+			reqCtx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			req, err := http.NewRequest("GET", server.URL, nil)
+			So(err, ShouldBeNil)
+			resp, err := http.DefaultClient.Do(req.WithContext(reqCtx))
+			So(err, ShouldBeNil)
+			defer resp.Body.Close()
+			events := readSSEEvents(t, bufio.NewReader(resp.Body))
+
+			Convey("Then the current status of the app and of each check is streamed", func() {
+				So(resp.Header.Get("Content-Type"), ShouldEqual, "text/event-stream")
+
+				event := nextSSEEvent(events)
+				So(event.name, ShouldEqual, "status")
+				So(event.data["status"], ShouldEqual, "pass")
+
+				event = nextSSEEvent(events)
+				So(event.name, ShouldEqual, "check")
+				So(event.data["check"], ShouldEqual, "check 1")
+				So(event.data["current"], ShouldEqual, "pass")
+				So(event.data, ShouldNotContainKey, "previous")
+			})
+
+			Convey("When the status of the check changes", func() {
+				nextSSEEvent(events)
+				nextSSEEvent(events)
+				mutex.Lock()
+				status = StatusWarning
+				mutex.Unlock()
+				hc.runChecks(ctx, true)
+
+				Convey("Then the transitions of the check and of the app are streamed", func() {
+					received := map[string]sseEvent{}
+					for i := 0; i < 2; i++ {
+						event := nextSSEEvent(events)
+						received[event.name] = event
+					}
+
+					So(received["check"].data["check"], ShouldEqual, "check 1")
+					So(received["check"].data["previous"], ShouldEqual, "pass")
+					So(received["check"].data["current"], ShouldEqual, "warn")
+					So(received["status"].data["status"], ShouldEqual, "warn")
+				})
+			})
+
+			Convey("When the client disconnects", func() {
+				nextSSEEvent(events)
+				nextSSEEvent(events)
+				cancel()
+
+				Convey("Then the handler unsubscribes from the health check", func() {
+					So(waitFor(func() bool {
+						hc.mutex.RLock()
+						defer hc.mutex.RUnlock()
+						return len(hc.statusSubscriptions) == 0 && len(hc.checkSubscriptions) == 0
+					}), ShouldBeTrue)
+				})
+			})
+		})
+	})
+}
+
+// waitFor polls the provided condition for up to a second, returning whether it became true
+func waitFor(condition func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return condition()
+}
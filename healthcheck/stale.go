@@ -0,0 +1,107 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+// staleMessage is the message recorded for a check that has not completed a run within the configured number of its
+// intervals
+const staleMessage = "check is stale as it has not completed a run since %s"
+
+// checkInterval returns the interval at which the provided check is run, allowing for any backoff
+func (hc *HealthCheck) checkInterval(check *Check) time.Duration {
+	interval := hc.interval
+	if check.interval > 0 {
+		interval = check.interval
+	}
+	return check.backoffInterval(interval)
+}
+
+// markStaleChecks records as CRITICAL any check of the running health check that has run since it was started, but
+// has not completed a run within the configured number of its intervals since, e.g. as its ticker is wedged, so that the status it last
+// recorded does not mask an outage. The transitions of the stale checks are kept to be notified by notifyStaleChecks
+// once the lock is released. Callers must hold the write lock.
+func (hc *HealthCheck) markStaleChecks(now time.Time) {
+	if hc.staleIntervals <= 0 || !hc.isStarted() || hc.context.Err() != nil {
+		return
+	}
+
+	for _, check := range hc.Checks {
+		// a restored result is as old as the time the app was last running, so is not stale until the check has run
+		lastChecked := check.state.LastChecked()
+		if lastChecked == nil || check.state.isRestored() {
+			continue
+		}
+		staleAfter := time.Duration(hc.staleIntervals) * hc.checkInterval(check)
+		if !now.After(lastChecked.Add(staleAfter)) {
+			continue
+		}
+		if previous, ok := check.state.markStale(now); ok {
+			logEvent(hc.context, hc.logger, levelWarn, "marking check as stale as it has not completed a run within its intervals", nil,
+				log.Data{"external_service": check.state.Name(), "last_checked": *lastChecked})
+			hc.staleChanges = append(hc.staleChanges, CheckStatusChange{
+				Check:    check.state.Name(),
+				Previous: previous,
+				Current:  StatusCritical,
+				Time:     now,
+			})
+		}
+	}
+}
+
+// notifyStaleChecks notifies the check status listeners of each check marked as stale since it was last called whose
+// status changed, and saves the state of the checks, as is done for the result of a run of a check. Callers must not
+// hold the lock.
+func (hc *HealthCheck) notifyStaleChecks(ctx context.Context) {
+	hc.mutex.Lock()
+	changes := hc.staleChanges
+	hc.staleChanges = nil
+	hc.mutex.Unlock()
+
+	if len(changes) == 0 {
+		return
+	}
+	for _, change := range changes {
+		if change.Previous != change.Current {
+			hc.notifyCheckStatusChange(ctx, change)
+		}
+	}
+	hc.saveState(ctx)
+}
+
+// markStale records the check as CRITICAL at the provided time as it is stale, counting it as a failure but leaving
+// the time it last completed a run unchanged. It returns the previous status, and true unless the check has already
+// been marked stale since it last completed a run.
+func (s *CheckState) markStale(now time.Time) (previous string, ok bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stale {
+		return s.status, false
+	}
+	previous = s.status
+	if previous != StatusCritical {
+		s.lastStatusChange = &now
+	}
+	s.consecutiveFailures++
+	s.totalFailures++
+	s.stale = true
+	s.status = StatusCritical
+	s.statusCode = 0
+	s.message = fmt.Sprintf(staleMessage, s.lastChecked.Format(time.RFC3339))
+	s.lastFailure = &now
+	return previous, true
+}
+
+// IsStale returns true if the check has been marked as stale, as it has not completed a run within the configured
+// number of its intervals, and has not completed a run since
+func (s *CheckState) IsStale() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.stale
+}
@@ -0,0 +1,208 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMarkStaleChecks(t *testing.T) {
+	now := time.Now().UTC()
+	lastChecked := now.Add(-3 * time.Minute)
+
+	newCheck := func(interval time.Duration) *Check {
+		return &Check{
+			interval: interval,
+			state: &CheckState{
+				name:        "check 1",
+				status:      StatusOK,
+				message:     "check 1 is OK",
+				lastChecked: &lastChecked,
+				lastSuccess: &lastChecked,
+				mutex:       &sync.RWMutex{},
+			},
+		}
+	}
+
+	Convey("Given a running health check that marks checks stale after two intervals", t, func() {
+		hc := HealthCheck{
+			interval:       time.Minute,
+			staleIntervals: 2,
+			context:        context.Background(),
+		}
+
+		Convey("When a check has not completed a run for longer than two intervals", func() {
+			check := newCheck(0)
+			hc.Checks = []*Check{check}
+			hc.markStaleChecks(now)
+
+			Convey("Then it is recorded as critical as it is stale", func() {
+				So(check.state.Status(), ShouldEqual, StatusCritical)
+				So(check.state.Message(), ShouldEqual, "check is stale as it has not completed a run since "+lastChecked.Format(time.RFC3339))
+				So(check.state.IsStale(), ShouldBeTrue)
+				So(*check.state.LastFailure(), ShouldEqual, now)
+			})
+
+			Convey("Then it is counted as a failure and its transition is kept to be notified", func() {
+				So(check.state.ConsecutiveFailures(), ShouldEqual, 1)
+				So(check.state.TotalFailures(), ShouldEqual, 1)
+				So(hc.staleChanges, ShouldResemble, []CheckStatusChange{
+					{Check: "check 1", Previous: StatusOK, Current: StatusCritical, Time: now},
+				})
+			})
+
+			Convey("Then the time it last completed a run is unchanged", func() {
+				So(*check.state.LastChecked(), ShouldEqual, lastChecked)
+				So(*check.state.LastSuccess(), ShouldEqual, lastChecked)
+			})
+
+			Convey("Then it is not marked again while it remains stale", func() {
+				later := now.Add(time.Minute)
+				hc.markStaleChecks(later)
+				So(*check.state.LastFailure(), ShouldEqual, now)
+				So(check.state.ConsecutiveFailures(), ShouldEqual, 1)
+				So(hc.staleChanges, ShouldHaveLength, 1)
+			})
+
+			Convey("Then it is no longer stale once it completes a run", func() {
+				t1 := now.Add(time.Second)
+				check.state.set(&CheckState{status: StatusOK, message: "check 1 is OK", lastChecked: &t1, mutex: &sync.RWMutex{}})
+				So(check.state.Status(), ShouldEqual, StatusOK)
+				So(check.state.IsStale(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When a check with a longer interval has not completed a run for longer than two intervals of the health check", func() {
+			check := newCheck(2 * time.Minute)
+			hc.Checks = []*Check{check}
+			hc.markStaleChecks(now)
+
+			Convey("Then it is not stale within two of its own intervals", func() {
+				So(check.state.Status(), ShouldEqual, StatusOK)
+				So(check.state.IsStale(), ShouldBeFalse)
+			})
+		})
+
+		Convey("When a check has never run", func() {
+			check := newCheck(0)
+			check.state.lastChecked = nil
+			check.state.status = ""
+			hc.Checks = []*Check{check}
+			hc.markStaleChecks(now)
+
+			Convey("Then it is not stale", func() {
+				So(check.state.IsStale(), ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("Given a health check that marks checks stale but is not running", t, func() {
+		check := newCheck(0)
+		hc := HealthCheck{
+			interval:       time.Minute,
+			staleIntervals: 2,
+			Checks:         []*Check{check},
+		}
+
+		Convey("Then its checks are not marked stale", func() {
+			hc.markStaleChecks(now)
+			So(check.state.Status(), ShouldEqual, StatusOK)
+		})
+	})
+
+	Convey("Given a running health check that does not mark checks stale", t, func() {
+		check := newCheck(0)
+		hc := HealthCheck{
+			interval: time.Minute,
+			context:  context.Background(),
+			Checks:   []*Check{check},
+		}
+
+		Convey("Then its checks are not marked stale", func() {
+			hc.markStaleChecks(now)
+			So(check.state.Status(), ShouldEqual, StatusOK)
+		})
+	})
+}
+
+func TestStaleChecksAggregation(t *testing.T) {
+	Convey("Given a running health check with a check that has stopped completing runs", t, func() {
+		hc, err := New(version, time.Hour, time.Minute, WithStaleAfter(2))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "", 0)
+		}), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		So(waitFor(hc.Checks[0].hasRun), ShouldBeTrue)
+
+		lastChecked := time.Now().UTC().Add(-3 * time.Minute)
+		hc.Checks[0].state.mutex.Lock()
+		hc.Checks[0].state.lastChecked = &lastChecked
+		hc.Checks[0].state.lastSuccess = &lastChecked
+		hc.Checks[0].state.mutex.Unlock()
+
+		Convey("When the status of the app is calculated", func() {
+			status := hc.GetStatus(context.Background())
+
+			Convey("Then the stale check is no longer reported as OK", func() {
+				So(status, ShouldEqual, StatusWarning)
+				So(hc.Checks[0].state.Status(), ShouldEqual, StatusCritical)
+				So(hc.Checks[0].state.IsStale(), ShouldBeTrue)
+			})
+		})
+	})
+}
+
+func TestStaleChecksNotification(t *testing.T) {
+	Convey("Given a running health check with a subscriber to a check that has stopped completing runs", t, func() {
+		hc, err := New(version, time.Hour, time.Minute, WithStaleAfter(2))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "", 0)
+		}), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+		So(waitFor(hc.Checks[0].hasRun), ShouldBeTrue)
+
+		changes := make(chan CheckStatusChange, 10)
+		unsubscribe := hc.SubscribeChecks(func(ctx context.Context, change CheckStatusChange) {
+			changes <- change
+		}, "check 1")
+		defer unsubscribe()
+
+		lastChecked := time.Now().UTC().Add(-3 * time.Minute)
+		hc.Checks[0].state.mutex.Lock()
+		hc.Checks[0].state.lastChecked = &lastChecked
+		hc.Checks[0].state.lastSuccess = &lastChecked
+		hc.Checks[0].state.mutex.Unlock()
+
+		Convey("When the status of the app is calculated", func() {
+			hc.GetStatus(context.Background())
+
+			Convey("Then the subscriber is notified that the check became critical", func() {
+				select {
+				case change := <-changes:
+					So(change.Check, ShouldEqual, "check 1")
+					So(change.Previous, ShouldEqual, StatusOK)
+					So(change.Current, ShouldEqual, StatusCritical)
+				case <-time.After(time.Second):
+					t.Fatal("expected the stale transition to be notified")
+				}
+			})
+
+			Convey("Then the transition is notified only once", func() {
+				<-changes
+				hc.GetStatus(context.Background())
+				select {
+				case change := <-changes:
+					t.Fatalf("unexpected transition %+v", change)
+				case <-time.After(50 * time.Millisecond):
+				}
+			})
+		})
+	})
+}
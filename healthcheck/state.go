@@ -0,0 +1,274 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StateSnapshot is the state of the checks of a health check, as saved by a state store so that it can be restored
+// when the app restarts
+type StateSnapshot struct {
+	// Time is the time the snapshot was taken
+	Time time.Time `json:"time"`
+	// TimeOfFirstCriticalError is the time of the first critical error since the last success, if any, from which the
+	// critical timeout is measured
+	TimeOfFirstCriticalError *time.Time    `json:"time_of_first_critical_error,omitempty"`
+	Checks                   []*CheckState `json:"checks"`
+}
+
+// StateStore saves and loads snapshots of the state of the checks of a health check, so that their last success and
+// failure, and the critical timeout, survive a restart of the app. It must be safe for concurrent use.
+type StateStore interface {
+	// Save saves the provided snapshot, replacing any saved before
+	Save(ctx context.Context, snapshot StateSnapshot) error
+	// Load returns the most recently saved snapshot, or nil if none has been saved
+	Load(ctx context.Context) (*StateSnapshot, error)
+}
+
+// fileStateStore is a state store that saves snapshots as JSON to a file
+type fileStateStore struct {
+	path string
+}
+
+// NewFileStateStore returns a state store that saves each snapshot as JSON to the file at the provided path, e.g. on
+// a volume that is kept when the app is restarted. The file is replaced atomically, so that a snapshot is never
+// partially written.
+func NewFileStateStore(path string) StateStore {
+	return &fileStateStore{path: path}
+}
+
+// Save writes the provided snapshot to a temporary file, which then replaces the file of the store
+func (s *fileStateStore) Save(ctx context.Context, snapshot StateSnapshot) error {
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// Load reads the snapshot from the file of the store, returning nil if the file does not exist
+func (s *fileStateStore) Load(ctx context.Context) (*StateSnapshot, error) {
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &StateSnapshot{}
+	if err := json.Unmarshal(b, snapshot); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// stateSnapshot returns a snapshot of the state of the checks of the health check. Callers must hold the read lock.
+func (hc *HealthCheck) stateSnapshot() StateSnapshot {
+	snapshot := StateSnapshot{
+		Time:   hc.now(),
+		Checks: make([]*CheckState, 0, len(hc.Checks)),
+	}
+	if !hc.timeOfFirstCriticalError.IsZero() {
+		t := hc.timeOfFirstCriticalError
+		snapshot.TimeOfFirstCriticalError = &t
+	}
+	for _, check := range hc.Checks {
+		snapshot.Checks = append(snapshot.Checks, check.state.clone())
+	}
+	return snapshot
+}
+
+// saveState queues a snapshot of the state of the checks to be saved to the state store, if one has been configured,
+// unless the status of no check, nor the time of the first critical error, has changed since the last snapshot queued
+func (hc *HealthCheck) saveState(ctx context.Context) {
+	if hc.stateSaver == nil {
+		return
+	}
+
+	hc.stateSaver.save(ctx, func() StateSnapshot {
+		hc.mutex.RLock()
+		defer hc.mutex.RUnlock()
+
+		return hc.stateSnapshot()
+	})
+}
+
+// restoreState restores the state of each check that has not yet run from the snapshot in the state store, if one has
+// been configured, along with the time of the first critical error if it is not set. Callers must hold the write lock.
+func (hc *HealthCheck) restoreState(ctx context.Context) {
+	if hc.stateStore == nil {
+		return
+	}
+
+	snapshot, err := hc.stateStore.Load(ctx)
+	if err != nil {
+		logEvent(ctx, hc.logger, levelDefault, "failed to load health check state", err, nil)
+		return
+	}
+	if snapshot == nil {
+		return
+	}
+
+	saved := make(map[string]*CheckState, len(snapshot.Checks))
+	for _, state := range snapshot.Checks {
+		if state != nil {
+			saved[state.Name()] = state
+		}
+	}
+	for _, check := range hc.Checks {
+		if state, ok := saved[check.state.Name()]; ok && !check.hasRun() {
+			check.state.restore(state)
+		}
+	}
+
+	if hc.timeOfFirstCriticalError.IsZero() && snapshot.TimeOfFirstCriticalError != nil {
+		hc.timeOfFirstCriticalError = *snapshot.TimeOfFirstCriticalError
+	}
+}
+
+// restore records the results of the provided saved state as the current check state, leaving the name, group and
+// labels of the check, which are configured in code, unchanged
+func (s *CheckState) restore(saved *CheckState) {
+	saved.mutex.RLock()
+	defer saved.mutex.RUnlock()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.status = saved.status
+	s.statusCode = saved.statusCode
+	s.message = saved.message
+	s.lastChecked = saved.lastChecked
+	s.lastSuccess = saved.lastSuccess
+	s.lastFailure = saved.lastFailure
+	s.lastError = saved.lastError
+	s.timeouts = saved.timeouts
+	s.deferrals = saved.deferrals
+	s.consecutiveFailures = saved.consecutiveFailures
+	s.totalFailures = saved.totalFailures
+	s.duration = saved.duration
+	s.restored = true
+}
+
+// isRestored returns true if the recorded result was restored from a state store and the check has not completed a
+// run since
+func (s *CheckState) isRestored() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.restored
+}
+
+// stateSaver saves snapshots of the state of the checks to a state store on a single worker goroutine, that is only
+// running while there is a snapshot waiting to be saved, so that a slow store cannot block the checks. Only the most
+// recent snapshot waiting is saved, so that an older snapshot never replaces a newer one.
+type stateSaver struct {
+	store StateStore
+	// pending is the snapshot waiting to be saved, if any, and ctx the context it is saved with
+	pending *StateSnapshot
+	ctx     context.Context
+	// lastKey identifies the statuses of the last snapshot queued, to skip snapshots in which no status has changed
+	lastKey string
+	running bool
+	saving  *sync.WaitGroup
+	logger  Logger
+	mutex   *sync.Mutex
+}
+
+// newStateSaver creates a stateSaver that saves snapshots to the provided store
+func newStateSaver(store StateStore) *stateSaver {
+	return &stateSaver{
+		store:  store,
+		saving: &sync.WaitGroup{},
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// save queues the snapshot returned by the provided function to be saved, unless no status has changed since the
+// last snapshot queued. The snapshot is taken while holding the lock of the saver, so that snapshots are queued in
+// the order they were taken.
+func (s *stateSaver) save(ctx context.Context, snapshot func() StateSnapshot) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	next := snapshot()
+	key := snapshotKey(next)
+	if key == s.lastKey {
+		return
+	}
+	s.lastKey = key
+	s.pending = &next
+	// the snapshot is saved once the caller has returned, e.g. after the request to the health handler has completed
+	s.ctx = detachedContext{parent: ctx}
+
+	if !s.running {
+		s.running = true
+		s.saving.Add(1)
+		go s.run()
+	}
+}
+
+// run saves the pending snapshot until there is none
+func (s *stateSaver) run() {
+	defer s.saving.Done()
+
+	for {
+		s.mutex.Lock()
+		snapshot, ctx := s.pending, s.ctx
+		s.pending = nil
+		if snapshot == nil {
+			s.running = false
+			s.mutex.Unlock()
+			return
+		}
+		s.mutex.Unlock()
+
+		if err := s.store.Save(ctx, *snapshot); err != nil {
+			logEvent(ctx, s.logger, levelDefault, "failed to save health check state", err, nil)
+			// the next snapshot is saved whether or not a status has changed, so that the store catches up
+			s.mutex.Lock()
+			s.lastKey = ""
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// wait waits for any snapshot waiting to be saved to be saved
+func (s *stateSaver) wait() {
+	s.saving.Wait()
+}
+
+// snapshotKey returns a key identifying the status of each check in the provided snapshot and the time of the first
+// critical error, which together determine the overall health status
+func snapshotKey(snapshot StateSnapshot) string {
+	var b strings.Builder
+	if snapshot.TimeOfFirstCriticalError != nil {
+		b.WriteString(snapshot.TimeOfFirstCriticalError.Format(time.RFC3339Nano))
+	}
+	for _, state := range snapshot.Checks {
+		fmt.Fprintf(&b, "\n%q=%s", state.Name(), state.Status())
+	}
+	return b.String()
+}
@@ -0,0 +1,292 @@
+package healthcheck
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// stateStoreMock is a state store that keeps the last snapshot saved in memory, optionally blocking each save until
+// it is released
+type stateStoreMock struct {
+	mutex    sync.Mutex
+	snapshot *StateSnapshot
+	saves    int
+	release  chan struct{}
+}
+
+func (s *stateStoreMock) Save(ctx context.Context, snapshot StateSnapshot) error {
+	if s.release != nil {
+		<-s.release
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.snapshot = &snapshot
+	s.saves++
+	return nil
+}
+
+func (s *stateStoreMock) Load(ctx context.Context) (*StateSnapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.snapshot, nil
+}
+
+// getSaves returns the number of snapshots saved
+func (s *stateStoreMock) getSaves() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.saves
+}
+
+func TestFileStateStore(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a file state store in an empty directory", t, func() {
+		dir, err := ioutil.TempDir("", "state")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		store := NewFileStateStore(filepath.Join(dir, "health.json"))
+
+		Convey("Then no snapshot is loaded before one has been saved", func() {
+			snapshot, err := store.Load(ctx)
+			So(err, ShouldBeNil)
+			So(snapshot, ShouldBeNil)
+		})
+
+		Convey("When a snapshot is saved", func() {
+			t0 := time.Unix(0, 0).UTC()
+			state := NewCheckState("check 1")
+			So(state.Update(StatusCritical, "connection refused", 0), ShouldBeNil)
+			state.lastSuccess = &t0
+			So(store.Save(ctx, StateSnapshot{Time: t0, TimeOfFirstCriticalError: &t0, Checks: []*CheckState{state}}), ShouldBeNil)
+
+			Convey("Then it is loaded", func() {
+				snapshot, err := store.Load(ctx)
+				So(err, ShouldBeNil)
+				So(snapshot.Time, ShouldEqual, t0)
+				So(*snapshot.TimeOfFirstCriticalError, ShouldEqual, t0)
+				So(snapshot.Checks, ShouldHaveLength, 1)
+				So(snapshot.Checks[0].Name(), ShouldEqual, "check 1")
+				So(snapshot.Checks[0].Status(), ShouldEqual, StatusCritical)
+				So(*snapshot.Checks[0].LastSuccess(), ShouldEqual, t0)
+			})
+
+			Convey("Then no temporary file is left behind", func() {
+				files, err := ioutil.ReadDir(dir)
+				So(err, ShouldBeNil)
+				So(files, ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestSaveState(t *testing.T) {
+	Convey("Given a Health Check with a state store", t, func() {
+		var (
+			mutex  sync.Mutex
+			status = StatusWarning
+		)
+		store := &stateStoreMock{}
+		hc, err := New(version, criticalTimeout, interval, WithStateStore(store))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(status, "degraded", 0)
+		}), ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+		setStatus := func(s string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			status = s
+		}
+
+		Convey("When a check records a result", func() {
+			hc.Tick(context.Background())
+			hc.stateSaver.wait()
+
+			Convey("Then the state of the checks is saved", func() {
+				So(store.getSaves(), ShouldEqual, 1)
+				snapshot, err := store.Load(context.Background())
+				So(err, ShouldBeNil)
+				So(snapshot.Checks, ShouldHaveLength, 1)
+				So(snapshot.Checks[0].Status(), ShouldEqual, StatusWarning)
+				So(snapshot.Checks[0].Message(), ShouldEqual, "degraded")
+				So(snapshot.TimeOfFirstCriticalError, ShouldBeNil)
+			})
+
+			Convey("Then the state is not saved again when the check records the same status", func() {
+				hc.runChecks(context.Background(), true)
+				hc.stateSaver.wait()
+				So(store.getSaves(), ShouldEqual, 1)
+			})
+
+			Convey("Then the state is saved again when the status of the check changes", func() {
+				setStatus(StatusOK)
+				hc.runChecks(context.Background(), true)
+				hc.stateSaver.wait()
+				So(store.getSaves(), ShouldEqual, 2)
+				snapshot, _ := store.Load(context.Background())
+				So(snapshot.Checks[0].Status(), ShouldEqual, StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a running Health Check with a state store that is slow to save", t, func() {
+		store := &stateStoreMock{release: make(chan struct{})}
+		hc, err := New(version, time.Hour, time.Hour, WithStateStore(store))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "", 0)
+		}), ShouldBeNil)
+		hc.Start(context.Background())
+		So(waitFor(hc.Checks[0].hasRun), ShouldBeTrue)
+
+		Convey("When the checks are run while the state is being saved", func() {
+			ticked := make(chan struct{})
+			go func() {
+				hc.runChecks(context.Background(), true)
+				close(ticked)
+			}()
+
+			Convey("Then the checks are not held up by the store", func() {
+				select {
+				case <-ticked:
+				case <-time.After(time.Second):
+					t.Error("expected the checks to run while the state is being saved")
+				}
+				close(store.release)
+				hc.Stop()
+			})
+		})
+
+		Convey("When the health check is stopped", func() {
+			stopped := make(chan struct{})
+			go func() {
+				hc.Stop()
+				close(stopped)
+			}()
+
+			Convey("Then it waits for the state to be saved", func() {
+				select {
+				case <-stopped:
+					t.Error("expected stop to wait for the state to be saved")
+				case <-time.After(50 * time.Millisecond):
+				}
+				close(store.release)
+				<-stopped
+				So(store.getSaves(), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestRestoreState(t *testing.T) {
+	Convey("Given a state store with a check that has been critical for longer than the critical timeout", t, func() {
+		now := time.Now().UTC()
+		lastSuccess := now.Add(-2 * time.Hour)
+		firstCriticalError := now.Add(-90 * time.Minute)
+		lastChecked := now.Add(-time.Minute)
+
+		saved := NewCheckState("check 1")
+		saved.status = StatusCritical
+		saved.message = "connection refused"
+		saved.lastChecked = &lastChecked
+		saved.lastSuccess = &lastSuccess
+		saved.lastFailure = &lastChecked
+		saved.consecutiveFailures = 12
+		store := &stateStoreMock{snapshot: &StateSnapshot{
+			Time:                     lastChecked,
+			TimeOfFirstCriticalError: &firstCriticalError,
+			Checks:                   []*CheckState{saved, NewCheckState("removed check")},
+		}}
+
+		release := make(chan struct{})
+		checker := func(ctx context.Context, state *CheckState) error {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return state.Update(StatusOK, "", 0)
+		}
+
+		hc, err := New(version, time.Hour, time.Hour, WithStateStore(store))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+
+		Convey("When the health check is started, before the check has run", func() {
+			hc.Start(context.Background())
+			defer hc.Stop()
+			defer close(release)
+
+			Convey("Then the state of the check is restored", func() {
+				state := hc.Checks[0].state
+				So(state.Status(), ShouldEqual, StatusCritical)
+				So(state.Message(), ShouldEqual, "connection refused")
+				So(*state.LastSuccess(), ShouldEqual, lastSuccess)
+				So(state.ConsecutiveFailures(), ShouldEqual, 12)
+			})
+
+			Convey("Then the critical timeout is measured from the restored time of the first critical error", func() {
+				So(hc.GetStatus(context.Background()), ShouldEqual, StatusCritical)
+			})
+		})
+
+		Convey("When the health check is started after the check has run", func() {
+			close(release)
+			hc.Tick(context.Background())
+			hc.Start(context.Background())
+			defer hc.Stop()
+
+			Convey("Then the result of the check is kept", func() {
+				So(hc.Checks[0].state.Status(), ShouldEqual, StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a state store with a check saved for longer than the stale window before the health check is started", t, func() {
+		lastChecked := time.Now().UTC().Add(-time.Hour)
+		saved := NewCheckState("check 1")
+		saved.status = StatusOK
+		saved.lastChecked = &lastChecked
+		saved.lastSuccess = &lastChecked
+		store := &stateStoreMock{snapshot: &StateSnapshot{Time: lastChecked, Checks: []*CheckState{saved}}}
+
+		release := make(chan struct{})
+		checker := func(ctx context.Context, state *CheckState) error {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+			return state.Update(StatusOK, "", 0)
+		}
+
+		hc, err := New(version, time.Hour, time.Minute, WithStateStore(store), WithStaleAfter(2))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+
+		Convey("When the status of the app is calculated before the check has run", func() {
+			hc.Start(context.Background())
+			defer hc.Stop()
+			defer close(release)
+			status := hc.GetStatus(context.Background())
+
+			Convey("Then the restored check is not marked stale", func() {
+				So(status, ShouldEqual, StatusOK)
+				So(hc.Checks[0].state.Status(), ShouldEqual, StatusOK)
+				So(hc.Checks[0].state.IsStale(), ShouldBeFalse)
+			})
+		})
+	})
+}
@@ -0,0 +1,94 @@
+// Package statsd emits the result and duration of each run of a check as StatsD metrics, e.g. for apps whose metrics
+// are collected by a Datadog agent rather than scraped by Prometheus.
+package statsd
+
+import (
+	"context"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// The names of the metrics emitted for each run of a check
+const (
+	StatusMetric   = "healthcheck.status"
+	DurationMetric = "healthcheck.duration"
+	RunMetric      = "healthcheck.run"
+	ErrorMetric    = "healthcheck.error"
+)
+
+// statusValues are the values of the status gauge for each check status
+var statusValues = map[string]float64{
+	health.StatusOK:       0,
+	health.StatusWarning:  1,
+	health.StatusCritical: 2,
+}
+
+// MetricsSink sends metrics to a StatsD server. It is implemented by the client of the Datadog StatsD library,
+// github.com/DataDog/datadog-go/statsd, so that its client can be used as the sink, and can be implemented by a thin
+// wrapper around any other StatsD client.
+type MetricsSink interface {
+	Gauge(name string, value float64, tags []string, rate float64) error
+	Timing(name string, value time.Duration, tags []string, rate float64) error
+	Incr(name string, tags []string, rate float64) error
+}
+
+// Option configures optional behaviour of a run hook
+type Option func(*runHook)
+
+// WithLogger configures the logger used for metrics that fail to send, which by default are logged with
+// healthcheck.DefaultLogger
+func WithLogger(logger health.Logger) Option {
+	return func(h *runHook) {
+		h.logger = logger
+	}
+}
+
+// runHook sends metrics for each run of a check to a sink
+type runHook struct {
+	sink   MetricsSink
+	logger health.Logger
+}
+
+// NewRunHook returns a run hook, to be configured with healthcheck.WithRunHook, that sends metrics for each run of a
+// check to the provided sink once it has finished, tagged with the name of the check as check:<name>:
+//
+//	healthcheck.status the recorded status, 0 for OK, 1 for WARNING and 2 for CRITICAL
+//	healthcheck.duration how long the run took
+//	healthcheck.run a count of runs, also tagged with the recorded status as status:<status>
+//	healthcheck.error a count of runs whose checker returned an error
+//
+// Any error from the sink is logged, and does not affect the check.
+func NewRunHook(sink MetricsSink, opts ...Option) health.RunHook {
+	h := &runHook{
+		sink:   sink,
+		logger: health.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h.onRun
+}
+
+// onRun returns the function that sends the metrics for the run of the check once it has finished
+func (h *runHook) onRun(ctx context.Context, check string) (context.Context, func(health.CheckResult, error)) {
+	sink := h.sink
+	return ctx, func(result health.CheckResult, err error) {
+		tags := []string{"check:" + check}
+		send := func(metric string, sendErr error) {
+			if sendErr != nil {
+				h.logger(ctx, "failed to send health check metric", sendErr, map[string]interface{}{"check": check, "metric": metric})
+			}
+		}
+
+		if value, ok := statusValues[result.Status]; ok {
+			send(StatusMetric, sink.Gauge(StatusMetric, value, tags, 1))
+		}
+		send(DurationMetric, sink.Timing(DurationMetric, result.Duration, tags, 1))
+		send(RunMetric, sink.Incr(RunMetric, []string{"check:" + check, "status:" + result.Status}, 1))
+		if err != nil {
+			send(ErrorMetric, sink.Incr(ErrorMetric, tags, 1))
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package statsd
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// metric is a metric sent to the sink
+type metric struct {
+	kind  string
+	name  string
+	value float64
+	tags  []string
+}
+
+// sinkMock records the metrics sent to it, returning the configured error
+type sinkMock struct {
+	metrics []metric
+	err     error
+}
+
+func (s *sinkMock) Gauge(name string, value float64, tags []string, rate float64) error {
+	s.metrics = append(s.metrics, metric{kind: "gauge", name: name, value: value, tags: tags})
+	return s.err
+}
+
+func (s *sinkMock) Timing(name string, value time.Duration, tags []string, rate float64) error {
+	s.metrics = append(s.metrics, metric{kind: "timing", name: name, value: value.Seconds(), tags: tags})
+	return s.err
+}
+
+func (s *sinkMock) Incr(name string, tags []string, rate float64) error {
+	s.metrics = append(s.metrics, metric{kind: "count", name: name, value: 1, tags: tags})
+	return s.err
+}
+
+func TestNewRunHook(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("Given a run hook with a sink", t, func() {
+		sink := &sinkMock{}
+		hook := NewRunHook(sink)
+
+		Convey("When a run of a check records WARNING", func() {
+			runCtx, finish := hook(ctx, "zebedee API")
+			finish(health.CheckResult{Status: health.StatusWarning, Duration: 2 * time.Second}, nil)
+
+			Convey("Then the checker is passed the context of the health check", func() {
+				So(runCtx == ctx, ShouldBeTrue)
+			})
+
+			Convey("Then the status, duration and run of the check are sent to the sink", func() {
+				So(sink.metrics, ShouldResemble, []metric{
+					{kind: "gauge", name: StatusMetric, value: 1, tags: []string{"check:zebedee API"}},
+					{kind: "timing", name: DurationMetric, value: 2, tags: []string{"check:zebedee API"}},
+					{kind: "count", name: RunMetric, value: 1, tags: []string{"check:zebedee API", "status:WARNING"}},
+				})
+			})
+		})
+
+		Convey("When the checker of a run returns an error", func() {
+			_, finish := hook(ctx, "zebedee API")
+			finish(health.CheckResult{Status: health.StatusCritical}, errors.New("connection refused"))
+
+			Convey("Then the error is counted", func() {
+				So(sink.metrics, ShouldHaveLength, 4)
+				So(sink.metrics[3], ShouldResemble, metric{kind: "count", name: ErrorMetric, value: 1, tags: []string{"check:zebedee API"}})
+			})
+		})
+
+		Convey("When a run of a check is skipped", func() {
+			_, finish := hook(ctx, "zebedee API")
+			finish(health.CheckResult{Status: health.StatusSkipped}, nil)
+
+			Convey("Then no status is sent, but the run is counted", func() {
+				So(sink.metrics, ShouldHaveLength, 2)
+				So(sink.metrics[0].name, ShouldEqual, DurationMetric)
+				So(sink.metrics[1].tags, ShouldResemble, []string{"check:zebedee API", "status:SKIPPED"})
+			})
+		})
+	})
+
+	Convey("Given a run hook with a sink that fails to send metrics", t, func() {
+		sink := &sinkMock{err: errors.New("connection refused")}
+		hook := NewRunHook(sink)
+
+		Convey("When a run of a check finishes", func() {
+			_, finish := hook(ctx, "zebedee API")
+			finish(health.CheckResult{Status: health.StatusOK}, nil)
+
+			Convey("Then each metric is still sent", func() {
+				So(sink.metrics, ShouldHaveLength, 3)
+			})
+		})
+	})
+
+	Convey("Given a run hook with a logger and a sink that fails to send metrics", t, func() {
+		sink := &sinkMock{err: errors.New("connection refused")}
+		var metrics []interface{}
+		logger := func(ctx context.Context, event string, err error, data map[string]interface{}) {
+			metrics = append(metrics, data["metric"])
+		}
+		hook := NewRunHook(sink, WithLogger(logger))
+
+		Convey("When a run of a check finishes", func() {
+			_, finish := hook(ctx, "zebedee API")
+			finish(health.CheckResult{Status: health.StatusOK}, nil)
+
+			Convey("Then each metric that failed to send is logged with the logger", func() {
+				So(metrics, ShouldResemble, []interface{}{StatusMetric, DurationMetric, RunMetric})
+			})
+		})
+	})
+}
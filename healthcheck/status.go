@@ -0,0 +1,229 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+)
+
+// StatusChange represents a transition of the overall health status of the app
+type StatusChange struct {
+	Previous string
+	Current  string
+	// Check is the name of the check whose result triggered the transition, or empty if it was not triggered by the
+	// result of a check, e.g. as the critical timeout of a check expired when the status was next calculated
+	Check string
+	Time  time.Time
+}
+
+// StatusListener is called with each transition of the overall health status, along with the health check as it
+// was when the transition occurred
+type StatusListener func(ctx context.Context, change StatusChange, hc HealthCheck)
+
+// GetStatus recalculates and returns the overall health status of the app, as reported by the health handler, e.g.
+// for an app that reports its health by other means. The critical timeout is applied as of the time it is called, so
+// a check that has been failing for longer than the timeout makes the app critical even if it has not since run.
+// The status listeners are notified if the status has changed.
+func (hc *HealthCheck) GetStatus(ctx context.Context) string {
+	return hc.recalculateStatus(ctx, "")
+}
+
+// recalculateStatus recalculates and returns the overall health status of the app, notifying the status listeners,
+// with the provided name of the check that triggered it, if the status has changed
+func (hc *HealthCheck) recalculateStatus(ctx context.Context, check string) string {
+	hc.mutex.Lock()
+	change, changed := hc.setStatus(hc.getStatus(ctx, false))
+	change.Check = check
+	snapshot := *hc
+	hc.mutex.Unlock()
+
+	hc.notifyStaleChecks(ctx)
+	if changed {
+		hc.notifyStatusChange(ctx, change, snapshot)
+	}
+	return change.Current
+}
+
+// CheckStatusChange represents a transition of the status of a check. The previous status is empty for the first
+// status recorded by the check.
+type CheckStatusChange struct {
+	Check    string
+	Previous string
+	Current  string
+	Time     time.Time
+}
+
+// CheckStatusListener is called with each transition of the status of a check it has subscribed to
+type CheckStatusListener func(ctx context.Context, change CheckStatusChange)
+
+// statusSubscription is a channel subscribed to transitions of the overall health status, or a watch
+type statusSubscription struct {
+	ch    chan<- string
+	watch *statusWatch
+}
+
+// checkSubscription is a check status listener along with the names of the checks it has subscribed to
+type checkSubscription struct {
+	listener CheckStatusListener
+	// checks are the names of the checks subscribed to, or nil for every check
+	checks map[string]bool
+}
+
+// updateStatus recalculates the overall health status once the check with the provided name, if any, has recorded a
+// result, notifying the status listeners if it has changed
+func (hc *HealthCheck) updateStatus(ctx context.Context, check string) {
+	hc.recalculateStatus(ctx, check)
+	hc.saveState(ctx)
+}
+
+// setStatus records the provided overall status and the current uptime, returning the status change and
+// whether the status differs from the previously recorded one. Callers must hold the write lock.
+func (hc *HealthCheck) setStatus(status string) (StatusChange, bool) {
+	now := hc.now()
+
+	change := StatusChange{
+		Previous: hc.Status,
+		Current:  status,
+		Time:     now,
+	}
+
+	hc.Status = status
+	hc.Groups = hc.groupStatuses()
+	hc.Uptime = hc.uptime(now) / time.Millisecond
+
+	return change, change.Previous != change.Current
+}
+
+// uptime returns the time the health check has been running at the provided time, or the time it ran for if it
+// has been stopped. Callers must hold the lock.
+func (hc *HealthCheck) uptime(now time.Time) time.Duration {
+	if hc.StopTime != nil {
+		return hc.StopTime.Sub(hc.StartTime)
+	}
+	return now.Sub(hc.StartTime)
+}
+
+// notifyStatusChange calls each of the status listeners with the provided status change, via the listener queue
+// if one has been configured
+func (hc *HealthCheck) notifyStatusChange(ctx context.Context, change StatusChange, snapshot HealthCheck) {
+	if len(snapshot.statusListeners) == 0 && len(snapshot.statusSubscriptions) == 0 {
+		return
+	}
+
+	notify := func(ctx context.Context) {
+		for _, listener := range snapshot.statusListeners {
+			listener(ctx, change, snapshot)
+		}
+		for _, subscription := range snapshot.statusSubscriptions {
+			if subscription.watch != nil {
+				subscription.watch.send(change)
+				continue
+			}
+			select {
+			case subscription.ch <- change.Current:
+			default:
+			}
+		}
+	}
+
+	if hc.listenerQueue != nil {
+		hc.listenerQueue.enqueue(ctx, notify)
+		return
+	}
+	notify(ctx)
+}
+
+// Subscribe registers a channel to be sent the new overall health status whenever it changes, e.g. to page on-call
+// or flip a load balancer flag as soon as the app becomes critical. Only transitions are sent: recalculating the
+// same status on each run of a check, or each call to the health handler, sends nothing. The send never blocks, so
+// if the channel is not ready to receive the status is dropped; use a buffered channel to avoid missing a transition.
+// It is safe to subscribe while the health check is running. The returned function unsubscribes the channel, after
+// which it is not sent the status of any later transition.
+func (hc *HealthCheck) Subscribe(ch chan<- string) (unsubscribe func()) {
+	return hc.subscribe(&statusSubscription{ch: ch})
+}
+
+// subscribe registers the provided subscription to transitions of the overall health status, returning the function
+// that unsubscribes it
+func (hc *HealthCheck) subscribe(subscription *statusSubscription) (unsubscribe func()) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	hc.statusSubscriptions = append(hc.statusSubscriptions, subscription)
+
+	return func() {
+		hc.mutex.Lock()
+		defer hc.mutex.Unlock()
+
+		// a new slice is made as snapshots of the health check being notified may share the existing one
+		subscriptions := make([]*statusSubscription, 0, len(hc.statusSubscriptions))
+		for _, s := range hc.statusSubscriptions {
+			if s != subscription {
+				subscriptions = append(subscriptions, s)
+			}
+		}
+		hc.statusSubscriptions = subscriptions
+	}
+}
+
+// SubscribeChecks registers a listener to be called whenever the status of one of the checks with the provided names
+// changes, or of any check if no names are provided, e.g. for a consumer to stop consuming while a dependency it
+// relies on is unhealthy. Only transitions are notified: a check recording the same status on each run notifies
+// nothing. As with status listeners, listeners are called by the ticker that ran the check, unless a listener queue
+// has been configured with WithListenerQueue to call them on a separate goroutine. It is safe to subscribe while the
+// health check is running. The returned function unsubscribes the listener, after which it is not called for any
+// later transition.
+func (hc *HealthCheck) SubscribeChecks(listener CheckStatusListener, names ...string) (unsubscribe func()) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	subscription := &checkSubscription{listener: listener}
+	if len(names) > 0 {
+		subscription.checks = make(map[string]bool, len(names))
+		for _, name := range names {
+			subscription.checks[name] = true
+		}
+	}
+	hc.checkSubscriptions = append(hc.checkSubscriptions, subscription)
+
+	return func() {
+		hc.mutex.Lock()
+		defer hc.mutex.Unlock()
+
+		subscriptions := make([]*checkSubscription, 0, len(hc.checkSubscriptions))
+		for _, s := range hc.checkSubscriptions {
+			if s != subscription {
+				subscriptions = append(subscriptions, s)
+			}
+		}
+		hc.checkSubscriptions = subscriptions
+	}
+}
+
+// notifyCheckStatusChange calls each of the check status listeners subscribed to the check with the provided change,
+// via the listener queue if one has been configured
+func (hc *HealthCheck) notifyCheckStatusChange(ctx context.Context, change CheckStatusChange) {
+	hc.mutex.RLock()
+	var listeners []CheckStatusListener
+	for _, subscription := range hc.checkSubscriptions {
+		if subscription.checks == nil || subscription.checks[change.Check] {
+			listeners = append(listeners, subscription.listener)
+		}
+	}
+	hc.mutex.RUnlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+
+	notify := func(ctx context.Context) {
+		for _, listener := range listeners {
+			listener(ctx, change)
+		}
+	}
+
+	if hc.listenerQueue != nil {
+		hc.listenerQueue.enqueue(ctx, notify)
+		return
+	}
+	notify(ctx)
+}
@@ -0,0 +1,276 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStatusListener(t *testing.T) {
+	Convey("Given a Health Check with a status listener and a check whose status can be changed", t, func() {
+		var (
+			mutex   sync.Mutex
+			changes []StatusChange
+			status  = StatusOK
+		)
+
+		listener := func(ctx context.Context, change StatusChange, hc HealthCheck) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			changes = append(changes, change)
+		}
+		checker := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(status, "", 0)
+		}
+		getChanges := func() []StatusChange {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return append([]StatusChange{}, changes...)
+		}
+
+		hc, err := New(version, criticalTimeout, interval, WithStatusListener(listener))
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		Convey("When the check has run several times with the same status", func() {
+			time.Sleep(4 * interval)
+
+			Convey("Then the listener is notified of the initial status only", func() {
+				c := getChanges()
+				So(len(c), ShouldEqual, 1)
+				So(c[0].Previous, ShouldEqual, "")
+				So(c[0].Current, ShouldEqual, StatusOK)
+			})
+
+			Convey("When the status of the check changes", func() {
+				mutex.Lock()
+				status = StatusWarning
+				mutex.Unlock()
+				time.Sleep(3 * interval)
+
+				Convey("Then the listener is notified of the transition once", func() {
+					c := getChanges()
+					So(len(c), ShouldEqual, 2)
+					So(c[1].Previous, ShouldEqual, StatusOK)
+					So(c[1].Current, ShouldEqual, StatusWarning)
+				})
+			})
+		})
+	})
+}
+
+func TestSubscribe(t *testing.T) {
+	Convey("Given a Health Check with a subscriber and a check whose status can be changed", t, func() {
+		var (
+			mutex  sync.Mutex
+			status = StatusOK
+		)
+		checker := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(status, "", 0)
+		}
+
+		hc, err := New(version, criticalTimeout, interval)
+
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		statuses := make(chan string, 10)
+		hc.Subscribe(statuses)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		Convey("When the check has run several times and its status then changes", func() {
+			time.Sleep(4 * interval)
+			mutex.Lock()
+			status = StatusWarning
+			mutex.Unlock()
+			time.Sleep(3 * interval)
+
+			Convey("Then the subscriber is sent each transition once", func() {
+				So(len(statuses), ShouldEqual, 2)
+				So(<-statuses, ShouldEqual, StatusOK)
+				So(<-statuses, ShouldEqual, StatusWarning)
+			})
+		})
+	})
+
+	Convey("Given a Health Check with a subscriber that has unsubscribed", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		statuses := make(chan string, 10)
+		other := make(chan string, 10)
+		unsubscribe := hc.Subscribe(statuses)
+		hc.Subscribe(other)
+		unsubscribe()
+
+		Convey("When the status changes", func() {
+			hc.mutex.Lock()
+			change, _ := hc.setStatus(StatusOK)
+			snapshot := hc
+			hc.mutex.Unlock()
+			hc.notifyStatusChange(context.Background(), change, snapshot)
+
+			Convey("Then only the subscriber that is still subscribed is sent the status", func() {
+				So(statuses, ShouldBeEmpty)
+				So(<-other, ShouldEqual, StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a Health Check with a subscriber that is not receiving", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		statuses := make(chan string)
+		hc.Subscribe(statuses)
+
+		Convey("Then notifying a status change does not block", func() {
+			done := make(chan struct{})
+			go func() {
+				hc.notifyStatusChange(context.Background(), StatusChange{Current: StatusOK}, hc)
+				close(done)
+			}()
+
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Error("notifying a status change blocked on a subscriber")
+			}
+		})
+	})
+}
+
+func TestSubscribeChecks(t *testing.T) {
+	Convey("Given a Health Check with 2 checks whose statuses can be changed and a listener subscribed to one of them", t, func() {
+		statuses := map[string]string{"check 1": StatusOK, "check 2": StatusOK}
+		checker := func(name string) Checker {
+			return func(ctx context.Context, state *CheckState) error {
+				return state.Update(statuses[name], "", 0)
+			}
+		}
+
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker("check 1")), ShouldBeNil)
+		So(hc.AddCheck("check 2", checker("check 2")), ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+
+		var (
+			mutex           sync.Mutex
+			subscribed, all []CheckStatusChange
+		)
+		unsubscribe := hc.SubscribeChecks(func(ctx context.Context, change CheckStatusChange) {
+			subscribed = append(subscribed, change)
+		}, "check 1")
+		hc.SubscribeChecks(func(ctx context.Context, change CheckStatusChange) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			all = append(all, change)
+		})
+
+		Convey("When the checks run several times and then both change status", func() {
+			ctx := context.Background()
+			hc.Tick(ctx)
+			hc.runChecks(ctx, true)
+			statuses["check 1"] = StatusCritical
+			statuses["check 2"] = StatusWarning
+			hc.runChecks(ctx, true)
+			hc.runChecks(ctx, true)
+
+			Convey("Then the listener subscribed to one check hears each of its transitions once", func() {
+				So(subscribed, ShouldHaveLength, 2)
+				So(subscribed[0].Check, ShouldEqual, "check 1")
+				So(subscribed[0].Previous, ShouldEqual, "")
+				So(subscribed[0].Current, ShouldEqual, StatusOK)
+				So(subscribed[1].Previous, ShouldEqual, StatusOK)
+				So(subscribed[1].Current, ShouldEqual, StatusCritical)
+				So(subscribed[1].Time, ShouldEqual, *hc.Checks[0].state.lastStatusChange)
+			})
+
+			Convey("Then the listener subscribed to every check hears the transitions of both", func() {
+				So(all, ShouldHaveLength, 4)
+			})
+		})
+
+		Convey("When the listener subscribed to one check is unsubscribed before its status changes", func() {
+			ctx := context.Background()
+			hc.Tick(ctx)
+			unsubscribe()
+			statuses["check 1"] = StatusCritical
+			hc.runChecks(ctx, true)
+
+			Convey("Then it does not hear the transition", func() {
+				So(subscribed, ShouldHaveLength, 1)
+				So(subscribed[0].Current, ShouldEqual, StatusOK)
+			})
+		})
+	})
+}
+
+func TestSetStatus(t *testing.T) {
+	Convey("Given a health check with an OK status", t, func() {
+		hc := HealthCheck{Status: StatusOK, StartTime: time.Now().UTC().Add(-time.Minute)}
+
+		Convey("Then setting the same status is not a change", func() {
+			_, changed := hc.setStatus(StatusOK)
+			So(changed, ShouldBeFalse)
+			So(hc.Uptime, ShouldBeGreaterThanOrEqualTo, time.Minute/time.Millisecond)
+		})
+
+		Convey("Then setting a different status is a change", func() {
+			change, changed := hc.setStatus(StatusCritical)
+			So(changed, ShouldBeTrue)
+			So(change.Previous, ShouldEqual, StatusOK)
+			So(change.Current, ShouldEqual, StatusCritical)
+			So(hc.Status, ShouldEqual, StatusCritical)
+		})
+	})
+}
+
+func TestGetStatusAppliesCriticalTimeout(t *testing.T) {
+	Convey("Given a health check with an OK status and a check that has been critical for longer than the critical timeout", t, func() {
+		t0 := time.Now().UTC()
+		t20 := t0.Add(-20 * time.Minute)
+		check, err := NewCheck("check", func(ctx context.Context, state *CheckState) error { return nil })
+		So(err, ShouldBeNil)
+		check.state.status = StatusCritical
+		check.state.lastChecked = &t0
+		check.state.lastFailure = &t20
+
+		var changes []StatusChange
+		hc, err := New(version, 10*time.Minute, interval, WithStatusListener(func(ctx context.Context, change StatusChange, hc HealthCheck) {
+			changes = append(changes, change)
+		}))
+		So(err, ShouldBeNil)
+		hc.Status = StatusOK
+		hc.Checks = []*Check{check}
+		hc.timeOfFirstCriticalError = t20
+
+		Convey("When the status is got", func() {
+			status := hc.GetStatus(context.Background())
+
+			Convey("Then the critical timeout is applied and the status is critical", func() {
+				So(status, ShouldEqual, StatusCritical)
+				So(hc.Status, ShouldEqual, StatusCritical)
+			})
+
+			Convey("Then the status listeners are notified of the change", func() {
+				So(changes, ShouldHaveLength, 1)
+				So(changes[0].Previous, ShouldEqual, StatusOK)
+				So(changes[0].Current, ShouldEqual, StatusCritical)
+			})
+		})
+	})
+}
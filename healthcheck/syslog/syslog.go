@@ -0,0 +1,80 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+// Package syslog reports transitions of the overall health status as syslog messages, for environments whose
+// alerting is based on syslog. It is not available on Windows or Plan 9, which do not support log/syslog.
+package syslog
+
+import (
+	"context"
+	"fmt"
+	gosyslog "log/syslog"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// Writer writes messages to syslog at the severities used for health status transitions, as implemented by
+// *syslog.Writer from the standard library
+type Writer interface {
+	Err(m string) error
+	Warning(m string) error
+	Notice(m string) error
+}
+
+// ensure the standard library syslog writer can be used by the reporter
+var _ Writer = &gosyslog.Writer{}
+
+// Option configures optional behaviour of a reporter
+type Option func(*reporter)
+
+// WithLogger configures the logger used for messages that fail to be written, which by default are logged with
+// healthcheck.DefaultLogger
+func WithLogger(logger health.Logger) Option {
+	return func(r *reporter) {
+		r.logger = logger
+	}
+}
+
+// reporter writes a syslog message for each transition of the overall health status
+type reporter struct {
+	w      Writer
+	logger health.Logger
+}
+
+// NewReporter returns a status listener that writes a syslog message using the provided writer whenever the
+// overall health status changes. Transitions to CRITICAL are written with the err severity, transitions to WARNING
+// with the warning severity and transitions to OK with the notice severity.
+func NewReporter(w Writer, opts ...Option) health.StatusListener {
+	r := &reporter{
+		w:      w,
+		logger: health.DefaultLogger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r.onStatusChange
+}
+
+// onStatusChange writes a syslog message for the status change at the severity of the current status
+func (r *reporter) onStatusChange(ctx context.Context, change health.StatusChange, hc health.HealthCheck) {
+	previous := change.Previous
+	if previous == "" {
+		previous = "unknown"
+	}
+	message := fmt.Sprintf("health status changed from %s to %s", previous, change.Current)
+
+	var err error
+	switch change.Current {
+	case health.StatusCritical:
+		err = r.w.Err(message)
+	case health.StatusWarning:
+		err = r.w.Warning(message)
+	default:
+		err = r.w.Notice(message)
+	}
+
+	if err != nil {
+		r.logger(ctx, "failed to write health status syslog message", err, map[string]interface{}{"previous": change.Previous, "current": change.Current})
+	}
+}
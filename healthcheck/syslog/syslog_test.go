@@ -0,0 +1,84 @@
+//go:build !windows && !plan9
+// +build !windows,!plan9
+
+package syslog
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// message is a syslog message written to the test writer
+type message struct {
+	severity string
+	text     string
+}
+
+// testWriter records the syslog messages written to it, returning err from each write
+type testWriter struct {
+	messages []message
+	err      error
+}
+
+func (w *testWriter) Err(m string) error {
+	w.messages = append(w.messages, message{"err", m})
+	return w.err
+}
+
+func (w *testWriter) Warning(m string) error {
+	w.messages = append(w.messages, message{"warning", m})
+	return w.err
+}
+
+func (w *testWriter) Notice(m string) error {
+	w.messages = append(w.messages, message{"notice", m})
+	return w.err
+}
+
+func TestReporter(t *testing.T) {
+	Convey("Given a syslog reporter", t, func() {
+		w := &testWriter{}
+		reporter := NewReporter(w)
+		report := func(previous, current string) {
+			reporter(context.Background(), health.StatusChange{Previous: previous, Current: current, Time: time.Now().UTC()}, health.HealthCheck{})
+		}
+
+		Convey("When the overall status changes through each status", func() {
+			report("", health.StatusOK)
+			report(health.StatusOK, health.StatusWarning)
+			report(health.StatusWarning, health.StatusCritical)
+			report(health.StatusCritical, health.StatusOK)
+
+			Convey("Then a message is written for each transition with the severity of the new status", func() {
+				So(w.messages, ShouldResemble, []message{
+					{"notice", "health status changed from unknown to OK"},
+					{"warning", "health status changed from OK to WARNING"},
+					{"err", "health status changed from WARNING to CRITICAL"},
+					{"notice", "health status changed from CRITICAL to OK"},
+				})
+			})
+		})
+	})
+
+	Convey("Given a syslog reporter with a logger and a writer that fails", t, func() {
+		w := &testWriter{err: errors.New("connection refused")}
+		var logged []map[string]interface{}
+		logger := func(ctx context.Context, event string, err error, data map[string]interface{}) {
+			logged = append(logged, data)
+		}
+		reporter := NewReporter(w, WithLogger(logger))
+
+		Convey("When the overall status changes", func() {
+			reporter(context.Background(), health.StatusChange{Previous: health.StatusOK, Current: health.StatusCritical}, health.HealthCheck{})
+
+			Convey("Then the failure is logged with the logger", func() {
+				So(logged, ShouldResemble, []map[string]interface{}{{"previous": health.StatusOK, "current": health.StatusCritical}})
+			})
+		})
+	})
+}
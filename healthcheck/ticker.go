@@ -3,102 +3,508 @@ package healthcheck
 import (
 	"context"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/ONSdigital/log.go/log"
 )
 
+// noResultMessage is the message recorded when a checker returns without error but does not update its state
+const noResultMessage = "checker returned no result"
+
+// timeoutMessage is the message recorded when a checker does not return a result before its timeout
+const timeoutMessage = "check timed out"
+
 type ticker struct {
-	timeTicker *time.Ticker
-	closing    chan bool
-	closed     chan bool
-	check      *Check
+	timeTicker Ticker
+	interval   time.Duration
+	jitter     float64
+	timeout    time.Duration
+	lastTick   time.Time
+	// lastRun is the time the ticker, or Tick, last started a run of the check
+	lastRun time.Time
+	// inFlight is set while a run of the check started by the ticker, or by Tick, is in flight
+	inFlight int32
+	// ticksSinceRun is the number of ticks since the check was last run, for backing off a failing check
+	ticksSinceRun int
+	closing       chan bool
+	closeOnce     *sync.Once
+	closed        chan bool
+	check         *Check
+	// onUpdate is called with the name of the check once a run of the check has recorded a result
+	onUpdate func(ctx context.Context, check string)
+	// onStatusChange is called when a run of the check records a different status to the previous run
+	onStatusChange func(ctx context.Context, change CheckStatusChange)
+	panicPolicy    PanicPolicy
+	budget         *probeBudget
+	workers        *workerPool
+	gitCommit      string
+	logger         Logger
+	// historySize is the number of results of the check kept in its history
+	historySize int
+	runHook     RunHook
+	// failedDependency returns the name of a check that the check depends on that is critical, if any
+	failedDependency func(check *Check) string
+	// staggeredStart is the fraction of the interval across which the first run of the check is randomly delayed
+	// when the ticker is started, or 0 to run the check as soon as the ticker is started
+	staggeredStart float64
+	// checksInFlight tracks the runs of the checker started by the ticker that have not yet finished
+	checksInFlight *sync.WaitGroup
+	clock          Clock
+	mutex          *sync.RWMutex
 }
 
-// createTicker will create a ticker that calls an individual check's checker function at the provided interval
-func createTicker(interval time.Duration, check *Check) *ticker {
-	intervalWithJitter := calcIntervalWithJitter(interval)
+// createTicker will create a ticker that calls an individual check's checker function at the provided interval of
+// the provided clock, randomly offset on each tick by up to the provided fraction of the interval once it is started
+func createTicker(interval time.Duration, jitter float64, check *Check, clock Clock) *ticker {
 	return &ticker{
-		timeTicker: time.NewTicker(intervalWithJitter),
-		closing:    make(chan bool),
-		closed:     make(chan bool),
-		check:      check,
+		timeTicker:     clock.NewTicker(interval),
+		clock:          clock,
+		interval:       interval,
+		jitter:         jitter,
+		timeout:        interval - time.Duration(getMaxJitter(interval, jitter)),
+		closing:        make(chan bool),
+		closeOnce:      &sync.Once{},
+		closed:         make(chan bool),
+		check:          check,
+		checksInFlight: &sync.WaitGroup{},
+		mutex:          &sync.RWMutex{},
+	}
+}
+
+// newTimeTicker returns a Ticker of the provided clock that ticks first after the provided delay, if positive, and
+// then at the provided interval, randomly offset on each tick by up to the provided fraction of the interval
+func newTimeTicker(clock Clock, interval time.Duration, jitter float64, delay time.Duration) Ticker {
+	if getMaxJitter(interval, jitter) == 0 && delay <= 0 {
+		return clock.NewTicker(interval)
 	}
+	return newJitterTicker(clock, interval, jitter, delay)
 }
 
-// start creates a goroutine to read the given ticker channel (which spins off a check for that ticker)
+// start creates a goroutine to read the given ticker channel (which spins off a check for that ticker). The check is
+// also run straight away, so that its state is populated without waiting for the first tick, unless its first run is
+// staggered, in which case the ticker first ticks after a random delay.
 func (ticker *ticker) start(ctx context.Context, wg *sync.WaitGroup) {
+	now := ticker.clock.Now().UTC()
+	ticker.setLastTick(now)
+
+	// only one run of the check is in flight at a time, so that a hung dependency cannot leak a goroutine on every tick
+	var checkInFlight bool
+	checkDone := make(chan bool, 1)
+
+	// the ticker of the clock is only replaced by one that is jittered on each tick once started, as the jittered
+	// ticker has a goroutine that would be leaked by a health check that is never started, and so never stopped
+	delay := calcStartDelay(ticker.interval, ticker.staggeredStart)
+	if delay > 0 || getMaxJitter(ticker.interval, ticker.jitter) > 0 {
+		ticker.restartTimeTicker(delay)
+	}
+	if delay <= 0 && ctx.Err() == nil && ticker.claimRun() {
+		if ticker.takeBudget(ctx, now) {
+			// the initial run is added to the waitgroup by the caller, so that it is waited for by a subsequent stop
+			checkInFlight = true
+			ticker.goRunCheck(ctx, wg, checkDone)
+		} else {
+			ticker.releaseRun()
+		}
+	}
+	ticker.recordNextCheck()
+
 	go func() {
 		defer close(ticker.closed)
 
-		const maxChecks int = 5 // Max number of healthchecks in flight
-		var checkInFlight int
-		checkDone := make(chan bool, maxChecks)
-
 		for {
-			//fmt.Printf("count: %v\n", checkInFlight)
 			select {
 			case <-ctx.Done():
-				ticker.stop()
+				ticker.signalStop()
+				return
 			case <-ticker.closing:
-				close(checkDone)
+				// checkDone is not closed as an in flight check may still send to it, which never
+				// blocks as it is buffered to hold a value for the check that can be in flight
 				return
-			case <-ticker.timeTicker.C:
-				if checkInFlight < maxChecks {
-					checkInFlight++
-					wg.Add(1)
-					go ticker.runCheck(ctx, wg, checkDone)
+			case t := <-ticker.timeTicker.C():
+				ticker.setLastTick(t.UTC())
+				due := ticker.isDue()
+				ticker.recordNextCheck()
+				if !due {
+					continue
 				}
+				// a checker abandoned at its timeout that has still not returned, or a run started by Tick, also skips
+				// the tick
+				if checkInFlight || ticker.check.isRunning() || !ticker.claimRun() {
+					ticker.logEvent(ctx, levelWarn, "skipping check as its previous run is still in flight", nil, ticker.logData())
+					continue
+				}
+				if !ticker.takeBudget(ctx, t) {
+					ticker.releaseRun()
+					continue
+				}
+				checkInFlight = true
+				ticker.goRunCheck(ctx, wg, checkDone)
 			case <-checkDone:
-				checkInFlight--
+				checkInFlight = false
 			}
 		}
 	}()
 }
 
+// goRunCheck runs the check associated with the ticker in a new goroutine, tracking it as in flight
+func (ticker *ticker) goRunCheck(ctx context.Context, wg *sync.WaitGroup, done chan bool) {
+	ticker.setRun()
+	wg.Add(1)
+	ticker.checksInFlight.Add(1)
+	go func() {
+		defer ticker.checksInFlight.Done()
+		ticker.runCheck(ctx, wg, done)
+	}()
+}
+
+// takeBudget returns true if the check may run at the provided time according to the probe budget, if any,
+// otherwise recording that the run was deferred
+func (ticker *ticker) takeBudget(ctx context.Context, t time.Time) bool {
+	if ticker.budget == nil || ticker.budget.take(t) {
+		return true
+	}
+
+	ticker.logEvent(ctx, levelWarn, "deferring check as probe budget is exhausted", nil, ticker.logData())
+	ticker.check.state.recordDeferral()
+	return false
+}
+
 // runCheck runs a checker function of the check associated with the ticker, notifying the provided waitgroup
 func (ticker *ticker) runCheck(ctx context.Context, wg *sync.WaitGroup, done chan bool) {
-
 	defer func() {
-		if x := recover(); x != nil {
-			// do nothing ... just handle timing corner case and avoid "panic: send on closed channel"
-			//fmt.Printf("in recover of runCheck : %v\n", x)
-		}
-	}()
-
-	defer func() { // this gets called before the defer above
+		ticker.releaseRun()
 		wg.Done()
 		done <- true
 	}()
 
-	err := ticker.check.checker(ctx, ticker.check.state)
-	if err != nil {
-		name := "no check has been made yet"
-		if ticker.check.state != nil {
-			name = ticker.check.state.Name()
+	if ticker.workers != nil {
+		if !ticker.workers.acquire(ctx, ticker.closing) {
+			return
 		}
-		log.Event(nil, "failed", log.Error(err), log.Data{"external_service": name})
+		defer ticker.workers.release()
+	}
+
+	ctx, finish := ticker.startRun(ctx)
+
+	// the checker updates a copy of the state, which is only recorded if the health check is not shutting down
+	state := ticker.check.state.clone()
+	lastChecked, lastError := state.lastChecked, state.lastError
+	var err error
+	defer func() {
+		finish(newCheckResult(state, err, ticker.clock.Now().UTC()), err)
+	}()
+
+	start := ticker.clock.Now()
+	if dependency := ticker.dependencyDown(); dependency != "" {
+		ticker.logEvent(ctx, levelDefault, "skipping check as a check it depends on is critical", nil, ticker.logData())
+		state.skip(dependency)
+	} else {
+		state, err = ticker.runCheckerWithRetries(ctx, state)
+	}
+	duration := ticker.clock.Now().Sub(start)
+	state.recordDuration(duration)
+	if ticker.check.isDebug() {
+		logData := ticker.logData()
+		logData["status"] = state.Status()
+		logData["message"] = state.Message()
+		logData["duration"] = duration.String()
+		ticker.logEvent(ctx, levelInfo, "health check run", err, logData)
+	}
+	if err != nil {
+		ticker.logEvent(ctx, levelDefault, "failed", err, ticker.logData())
+		state.setError(err)
+	}
+
+	if ctx.Err() != nil || ticker.isStopping() {
+		ticker.logEvent(ctx, levelDefault, "discarding check result as health check is shutting down", nil, ticker.logData())
 		return
 	}
+	if err == nil && !state.isUpdate(lastChecked, lastError) {
+		ticker.logEvent(ctx, levelDefault, "checker returned no result", nil, ticker.logData())
+		state.Update(StatusCritical, noResultMessage, 0)
+	}
+	if isTimeout(err) {
+		ticker.check.state.recordTimeout()
+	}
+	if state.isUpdate(lastChecked, lastError) {
+		// every fresh result is kept in the history, including any the record filter discards
+		ticker.check.state.recordHistory(newCheckResult(state, err, ticker.clock.Now().UTC()), ticker.historySize)
+		if !ticker.check.shouldRecord(state) {
+			ticker.logEvent(ctx, levelDefault, "check result not recorded by record filter", nil, ticker.logData())
+			return
+		}
+		previous := ticker.check.state.set(state)
+		if current := state.Status(); current != previous && ticker.onStatusChange != nil {
+			ticker.onStatusChange(ctx, CheckStatusChange{
+				Check:    state.Name(),
+				Previous: previous,
+				Current:  current,
+				Time:     *state.LastChecked(),
+			})
+		}
+		// the backoff of the check may have changed with the result
+		ticker.recordNextCheck()
+		if ticker.onUpdate != nil {
+			ticker.onUpdate(ctx, state.Name())
+		}
+	}
 }
 
-// stop the ticker
-func (ticker *ticker) stop() {
-	if ticker.isStopping() {
-		return
+// dependencyDown returns the name of a check that the check of the ticker depends on that is critical, if any
+func (ticker *ticker) dependencyDown() string {
+	if ticker.failedDependency == nil {
+		return ""
 	}
-	ticker.timeTicker.Stop()
+	return ticker.failedDependency(ticker.check)
+}
 
-	defer func() {
-		if x := recover(); x != nil {
-			// do nothing ... just handle timing corner case and avoid "panic: send on closed channel"
-			//fmt.Printf("in recover of stop : %v\n", x)
+// newCheckResult returns the result of a run of the checker that produced the provided state and error. The time of a
+// run that returned an error without updating the state is the provided time it finished.
+func newCheckResult(state *CheckState, err error, finished time.Time) CheckResult {
+	result := CheckResult{
+		Time:     finished,
+		Status:   state.Status(),
+		Duration: state.Duration(),
+		Message:  state.Message(),
+	}
+	if lastChecked := state.LastChecked(); lastChecked != nil {
+		result.Time = *lastChecked
+	}
+	if err != nil {
+		result.Message = err.Error()
+	}
+	return result
+}
+
+// runCheckerWithRetries runs the checker against the provided state, retrying up to the configured number of times
+// while it fails. The last failure time of each failed attempt that is retried is recorded against the check, but the
+// result of only the final attempt is returned to be recorded.
+func (ticker *ticker) runCheckerWithRetries(ctx context.Context, state *CheckState) (*CheckState, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := ticker.runCheckerWithTimeout(ctx, state)
+		if attempt > ticker.check.retries || (err == nil && result.Status() != StatusCritical) {
+			return result, err
+		}
+		if ctx.Err() != nil || ticker.isStopping() {
+			return result, err
 		}
-		<-ticker.closed
-		//fmt.Printf("got ticker.closed\n")
+
+		if lastFailure := result.LastFailure(); lastFailure != nil {
+			ticker.check.state.recordRetriedFailure(lastFailure)
+		}
+		logData := ticker.logData()
+		logData["attempt"] = attempt
+		ticker.logEvent(ctx, levelDefault, "retrying failed check", err, logData)
+
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-ticker.closing:
+			return result, err
+		case <-time.After(ticker.check.retryBackoff):
+		}
+		state = ticker.check.state.clone()
+	}
+}
+
+// runCheckerWithTimeout runs the checker against the provided state, abandoning it if it has not returned before the
+// timeout of the check. The checker is passed a context that is cancelled at the timeout, so that it can abort early,
+// but is left running if it does not, and the check is recorded as critical against a fresh copy of its state.
+func (ticker *ticker) runCheckerWithTimeout(ctx context.Context, state *CheckState) (*CheckState, error) {
+	timeout := ticker.timeout
+	if ticker.check.timeout > 0 {
+		timeout = ticker.check.timeout
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	lastChecked := state.LastChecked()
+	result := make(chan error, 1)
+	atomic.AddInt32(&ticker.check.running, 1)
+	go func() {
+		defer atomic.AddInt32(&ticker.check.running, -1)
+		result <- ticker.runChecker(checkCtx, state)
 	}()
 
-	close(ticker.closing)
+	select {
+	case err := <-result:
+		// a checker that aborts at the timeout without recording a result is treated as having timed out
+		if checkCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil && sameTime(state.LastChecked(), lastChecked) {
+			state.Update(StatusCritical, timeoutMessage, 0)
+		}
+		return state, err
+	case <-checkCtx.Done():
+	}
+
+	if ctx.Err() != nil {
+		return state, ctx.Err()
+	}
+
+	ticker.logEvent(ctx, levelWarn, "abandoning check as it has timed out", nil, ticker.logData())
+	timedOut := ticker.check.state.clone()
+	timedOut.Update(StatusCritical, timeoutMessage, 0)
+	return timedOut, checkCtx.Err()
+}
+
+// logData returns the data logged with events for the check associated with the ticker, including the git commit
+// of the app so that a check that starts failing can be correlated with the deploy that configured it
+func (ticker *ticker) logData() log.Data {
+	logData := log.Data{"external_service": ticker.check.state.Name()}
+	if ticker.gitCommit != "" {
+		logData["git_commit"] = ticker.gitCommit
+	}
+	return logData
+}
+
+// logEvent logs an event for the check associated with the ticker, with the logger of the health check if one has
+// been configured
+func (ticker *ticker) logEvent(ctx context.Context, lvl level, event string, err error, data log.Data) {
+	logEvent(ctx, ticker.logger, lvl, event, err, data)
+}
+
+// isTimeout returns true if the provided error was caused by a context deadline or a network timeout
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if err == context.DeadlineExceeded {
+		return true
+	}
+	timeoutErr, ok := err.(interface{ Timeout() bool })
+	return ok && timeoutErr.Timeout()
+}
+
+// restartTimeTicker replaces the ticker of the clock with one that ticks first after the provided delay, if positive,
+// and then at the interval of the ticker, randomly offset on each tick by up to its jitter
+func (ticker *ticker) restartTimeTicker(delay time.Duration) {
+	ticker.mutex.Lock()
+	defer ticker.mutex.Unlock()
+
+	ticker.timeTicker.Stop()
+	ticker.timeTicker = newTimeTicker(ticker.clock, ticker.interval, ticker.jitter, delay)
+}
+
+// setLastTick records the time the ticker was started or last ticked
+func (ticker *ticker) setLastTick(t time.Time) {
+	ticker.mutex.Lock()
+	defer ticker.mutex.Unlock()
+
+	ticker.lastTick = t
+}
+
+// setRun records that the ticker has run its check
+func (ticker *ticker) setRun() {
+	ticker.mutex.Lock()
+	defer ticker.mutex.Unlock()
+
+	ticker.ticksSinceRun = 0
+	ticker.lastRun = ticker.clock.Now().UTC()
+}
+
+// claimRun claims the run of the check for the caller, returning false if a run of the check started by the ticker,
+// or by Tick, is still in flight. The claim is released once the run has finished.
+func (ticker *ticker) claimRun() bool {
+	return atomic.CompareAndSwapInt32(&ticker.inFlight, 0, 1)
+}
+
+// releaseRun releases the claim on the run of the check
+func (ticker *ticker) releaseRun() {
+	atomic.StoreInt32(&ticker.inFlight, 0)
+}
+
+// isDueAt returns true if the check has not yet been run, or if its interval, allowing for any backoff, has passed
+// at the provided time since it was last run. The maximum jitter of the interval is allowed for, so that a check
+// driven by Tick at its interval is not skipped as the previous run started a little late.
+func (ticker *ticker) isDueAt(now time.Time) bool {
+	ticker.mutex.RLock()
+	defer ticker.mutex.RUnlock()
+
+	if ticker.lastRun.IsZero() {
+		return true
+	}
+	interval := ticker.check.backoffInterval(ticker.interval) - time.Duration(getMaxJitter(ticker.interval, ticker.jitter))
+	return !now.Before(ticker.lastRun.Add(interval))
+}
+
+// isDue counts a tick of the ticker, returning true if enough ticks have passed since the check was last run for it
+// to be due, allowing for any backoff of the check
+func (ticker *ticker) isDue() bool {
+	ticker.mutex.Lock()
+	defer ticker.mutex.Unlock()
+
+	ticker.ticksSinceRun++
+	return ticker.ticksSinceRun >= ticker.backoffTicks()
+}
+
+// backoffTicks returns the number of ticks between runs of the check, allowing for any backoff of the check
+func (ticker *ticker) backoffTicks() int {
+	return int((ticker.check.backoffInterval(ticker.interval) + ticker.interval - 1) / ticker.interval)
+}
+
+// nextRun returns the time the ticker is next due to run its check, allowing for any backoff of the check, or false
+// if the ticker is not running
+func (ticker *ticker) nextRun() (time.Time, bool) {
+	ticker.mutex.RLock()
+	defer ticker.mutex.RUnlock()
+
+	if ticker.lastTick.IsZero() || ticker.isStopping() {
+		return time.Time{}, false
+	}
+
+	ticks := ticker.backoffTicks() - ticker.ticksSinceRun
+	if ticks < 1 {
+		ticks = 1
+	}
+	// the interval until the next tick is randomly offset afresh on each tick, so is only known for the next tick
+	next := ticker.interval
+	if jittered, ok := ticker.timeTicker.(*jitterTicker); ok {
+		next = jittered.nextInterval()
+	}
+	return ticker.lastTick.Add(next + time.Duration(ticks-1)*ticker.interval), true
+}
+
+// recordNextCheck records the time the ticker is next due to run its check in the state of the check
+func (ticker *ticker) recordNextCheck() {
+	if next, ok := ticker.nextRun(); ok {
+		ticker.check.state.setNextCheck(&next)
+	}
+}
+
+// isStale returns the time the ticker last ticked and whether it has been running without ticking for longer
+// than the provided number of its intervals
+func (ticker *ticker) isStale(now time.Time, missedIntervals int) (time.Time, bool) {
+	ticker.mutex.RLock()
+	defer ticker.mutex.RUnlock()
+
+	if ticker.lastTick.IsZero() || ticker.isStopping() {
+		return ticker.lastTick, false
+	}
+	return ticker.lastTick, now.After(ticker.lastTick.Add(time.Duration(missedIntervals) * ticker.interval))
+}
+
+// abandon stops the ticker without waiting for its goroutine to exit, for use when the goroutine is unresponsive
+func (ticker *ticker) abandon() {
+	ticker.signalStop()
+}
+
+// signalStop stops the ticker and signals its goroutine to exit, without waiting for it to do so. It is safe to call
+// more than once, including concurrently with the goroutine stopping itself when its context is done.
+func (ticker *ticker) signalStop() {
+	ticker.timeTicker.Stop()
+	ticker.closeOnce.Do(func() {
+		close(ticker.closing)
+	})
+}
+
+// stop the ticker, waiting for its goroutine to exit
+func (ticker *ticker) stop() {
+	if ticker.isStopping() {
+		return
+	}
+	ticker.signalStop()
+	<-ticker.closed
 }
 
 func (ticker *ticker) isStopping() bool {
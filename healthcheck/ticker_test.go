@@ -0,0 +1,600 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/log.go/log"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRunCheckDuringShutdown(t *testing.T) {
+	checkerDuration := interval / 2
+
+	slowChecker := func(ctx context.Context, state *CheckState) error {
+		time.Sleep(checkerDuration)
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with a slow checker", t, func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		err = hc.AddCheck("slow check", slowChecker)
+		So(err, ShouldBeNil)
+		hc.Start(ctx)
+
+		Convey("When the context is cancelled while the check is in flight", func() {
+			time.Sleep(checkerDuration / 2) // give the initial run on start time to start running
+			cancel()
+			hc.Stop()
+
+			Convey("Then the result of the in flight check is not recorded", func() {
+				state := hc.Checks[0].state
+				So(state.Status(), ShouldEqual, "")
+				So(state.Message(), ShouldEqual, "")
+				So(state.LastChecked(), ShouldBeNil)
+				So(state.LastSuccess(), ShouldBeNil)
+			})
+		})
+
+		Convey("When the check completes before the context is cancelled", func() {
+			time.Sleep(interval + 2*checkerDuration)
+			cancel()
+			hc.Stop()
+
+			Convey("Then the result of the check is recorded", func() {
+				state := hc.Checks[0].state
+				So(state.Status(), ShouldEqual, StatusOK)
+				So(state.LastChecked(), ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestTickerStopsWhenContextIsDone(t *testing.T) {
+	Convey("Given a started ticker", t, func() {
+		check, err := NewCheck("check", func(ctx context.Context, state *CheckState) error {
+			return state.Update(StatusOK, "I'm OK", 0)
+		})
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		ctx, cancel := context.WithCancel(context.Background())
+		wg := &sync.WaitGroup{}
+		tkr.start(ctx, wg)
+
+		Convey("When its context is cancelled", func() {
+			cancel()
+
+			Convey("Then its goroutine exits and stopping it does not block", func() {
+				select {
+				case <-tkr.closed:
+				case <-time.After(interval):
+					t.Fatal("ticker goroutine did not exit")
+				}
+				So(tkr.isStopping(), ShouldBeTrue)
+				tkr.stop()
+				wg.Wait()
+			})
+		})
+	})
+}
+
+func TestRunCheckLastError(t *testing.T) {
+	Convey("Given a checker that updates its state and returns an error", t, func() {
+		checker := func(ctx context.Context, state *CheckState) error {
+			state.Update(StatusCritical, "failed to connect to dependency", 0)
+			return errors.New("dial tcp 127.0.0.1:27017: connect: connection refused")
+		}
+		check, err := NewCheck("check", checker)
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run", func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+
+			Convey("Then the human readable message and the raw error are both recorded", func() {
+				So(check.state.Status(), ShouldEqual, StatusCritical)
+				So(check.state.Message(), ShouldEqual, "failed to connect to dependency")
+				So(check.state.LastError(), ShouldEqual, "dial tcp 127.0.0.1:27017: connect: connection refused")
+			})
+
+			Convey("Then the duration of the run is recorded", func() {
+				So(check.state.Duration(), ShouldBeGreaterThan, 0)
+			})
+		})
+	})
+}
+
+// timeoutError is a network error that reports whether it was caused by a timeout
+type timeoutError struct {
+	timeout bool
+}
+
+func (e timeoutError) Error() string   { return "i/o error" }
+func (e timeoutError) Timeout() bool   { return e.timeout }
+func (e timeoutError) Temporary() bool { return false }
+
+func TestRunCheckTimeouts(t *testing.T) {
+	Convey("Given a check whose checker returns a different error on each run", t, func() {
+		errs := []error{
+			context.DeadlineExceeded,
+			errors.New("connection refused"),
+			timeoutError{timeout: true},
+			timeoutError{timeout: false},
+		}
+		run := 0
+		checker := func(ctx context.Context, state *CheckState) error {
+			err := errs[run]
+			run++
+			return err
+		}
+		check, err := NewCheck("check", checker)
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run for each error", func() {
+			for range errs {
+				wg := &sync.WaitGroup{}
+				wg.Add(1)
+				tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+			}
+
+			Convey("Then only the runs that timed out are counted", func() {
+				So(check.state.Timeouts(), ShouldEqual, 2)
+				So(check.state.LastError(), ShouldEqual, "i/o error")
+			})
+		})
+	})
+}
+
+func TestRunCheckTimeout(t *testing.T) {
+	Convey("Given a ticker for a check with the default timeout", t, func() {
+		check, err := NewCheck("check", func(ctx context.Context, state *CheckState) error { return nil })
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("Then the timeout is the interval less its maximum jitter", func() {
+			So(tkr.timeout, ShouldEqual, interval-time.Duration(getMaxJitter(interval, defaultJitter)))
+		})
+	})
+
+	Convey("Given a checker that hangs without respecting its context", t, func() {
+		release := make(chan struct{})
+		defer close(release)
+		checker := func(ctx context.Context, state *CheckState) error {
+			<-release
+			return state.Update(StatusOK, "", 0)
+		}
+		check, err := NewCheck("check", checker, WithTimeout(10*time.Millisecond))
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run", func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+
+			Convey("Then the check is abandoned and recorded as critical", func() {
+				So(check.state.Status(), ShouldEqual, StatusCritical)
+				So(check.state.Message(), ShouldEqual, "check timed out")
+				So(check.state.LastChecked(), ShouldNotBeNil)
+				So(check.state.LastFailure(), ShouldNotBeNil)
+				So(check.state.Timeouts(), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a checker that aborts when its context is cancelled", t, func() {
+		checker := func(ctx context.Context, state *CheckState) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		check, err := NewCheck("check", checker, WithTimeout(10*time.Millisecond))
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run", func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+
+			Convey("Then the check is recorded as critical", func() {
+				So(check.state.Status(), ShouldEqual, StatusCritical)
+				So(check.state.Message(), ShouldEqual, "check timed out")
+				So(check.state.LastChecked(), ShouldNotBeNil)
+				So(check.state.LastError(), ShouldEqual, context.DeadlineExceeded.Error())
+				So(check.state.Timeouts(), ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("Given a check that has already run, whose checker then aborts when its context is cancelled", t, func() {
+		var runs int32
+		checker := func(ctx context.Context, state *CheckState) error {
+			if atomic.AddInt32(&runs, 1) == 1 {
+				return state.Update(StatusOK, "I'm OK", 0)
+			}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		check, err := NewCheck("check", checker)
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		wg := &sync.WaitGroup{}
+		wg.Add(1)
+		tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+		So(check.state.Status(), ShouldEqual, StatusOK)
+
+		Convey("When the check is run again with a timeout that has already passed", func() {
+			// the checker may return before the timeout is noticed, so the check is run repeatedly to cover both
+			check.timeout = time.Nanosecond
+
+			Convey("Then each run is recorded as timed out", func() {
+				for i := 0; i < 10; i++ {
+					previous := check.state.LastChecked()
+					time.Sleep(time.Millisecond)
+					wg.Add(1)
+					tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+
+					So(check.state.Status(), ShouldEqual, StatusCritical)
+					So(check.state.Message(), ShouldEqual, "check timed out")
+					So(*check.state.LastChecked(), ShouldHappenAfter, *previous)
+				}
+			})
+		})
+	})
+}
+
+func TestRunCheckRetries(t *testing.T) {
+	Convey("Given a check that is retried and whose checker records a sequence of statuses", t, func() {
+		var (
+			mutex    sync.Mutex
+			statuses []string
+			attempts int
+		)
+		checker := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			status := statuses[attempts]
+			attempts++
+			return state.Update(status, "", 0)
+		}
+		check, err := NewCheck("check", checker, WithRetries(2, 50*time.Millisecond))
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		runCheck := func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+		}
+
+		Convey("When the check recovers before the retries are exhausted", func() {
+			statuses = []string{StatusCritical, StatusCritical, StatusOK}
+			runCheck()
+
+			Convey("Then the checker is retried and the final result is recorded", func() {
+				So(attempts, ShouldEqual, 3)
+				So(check.state.Status(), ShouldEqual, StatusOK)
+				So(check.state.LastFailure(), ShouldNotBeNil)
+				So(check.state.ConsecutiveFailures(), ShouldEqual, 0)
+			})
+		})
+
+		Convey("When every attempt fails", func() {
+			statuses = []string{StatusCritical, StatusCritical, StatusCritical}
+			runCheck()
+
+			Convey("Then the failure is recorded once the retries are exhausted", func() {
+				So(attempts, ShouldEqual, 3)
+				So(check.state.Status(), ShouldEqual, StatusCritical)
+				So(check.state.ConsecutiveFailures(), ShouldEqual, 1)
+			})
+		})
+
+		Convey("When an attempt fails after the check has recorded OK", func() {
+			statuses = []string{StatusOK, StatusCritical, StatusOK}
+			runCheck()
+			lastChecked := *check.state.LastChecked()
+
+			done := make(chan struct{})
+			go func() {
+				runCheck()
+				close(done)
+			}()
+			time.Sleep(25 * time.Millisecond)
+
+			Convey("Then the last failure is updated while the status is unchanged during the backoff", func() {
+				So(check.state.Status(), ShouldEqual, StatusOK)
+				So(*check.state.LastChecked(), ShouldEqual, lastChecked)
+				So(check.state.LastFailure(), ShouldNotBeNil)
+				So(*check.state.LastFailure(), ShouldHappenAfter, lastChecked)
+				<-done
+				So(check.state.Status(), ShouldEqual, StatusOK)
+			})
+		})
+	})
+}
+
+func TestRunCheckNoResult(t *testing.T) {
+	Convey("Given a misbehaving checker that returns no error without updating its state", t, func() {
+		checker := func(ctx context.Context, state *CheckState) error {
+			return nil
+		}
+		check, err := NewCheck("check", checker)
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run", func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			So(func() { tkr.runCheck(context.Background(), wg, make(chan bool, 1)) }, ShouldNotPanic)
+
+			Convey("Then the check is recorded as critical with a clear message", func() {
+				So(check.state.Status(), ShouldEqual, StatusCritical)
+				So(check.state.Message(), ShouldEqual, "checker returned no result")
+				So(check.state.LastChecked(), ShouldNotBeNil)
+				So(check.state.LastFailure(), ShouldNotBeNil)
+			})
+		})
+	})
+
+	Convey("Given a checker that returns an error without updating its state", t, func() {
+		checker := func(ctx context.Context, state *CheckState) error {
+			return errors.New("failed to run")
+		}
+		check, err := NewCheck("check", checker)
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run", func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+
+			Convey("Then only the error is recorded", func() {
+				So(check.state.Status(), ShouldEqual, "")
+				So(check.state.LastChecked(), ShouldBeNil)
+				So(check.state.LastError(), ShouldEqual, "failed to run")
+			})
+		})
+	})
+}
+
+func TestRunCheckPanic(t *testing.T) {
+	checker := func(ctx context.Context, state *CheckState) error {
+		panic("nil map")
+	}
+
+	Convey("Given a checker that panics", t, func() {
+		check, err := NewCheck("check", checker)
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		Convey("When the check is run with the default panic policy", func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			So(func() { tkr.runCheck(context.Background(), wg, make(chan bool, 1)) }, ShouldNotPanic)
+
+			Convey("Then the check is recorded as critical", func() {
+				So(check.state.Status(), ShouldEqual, StatusCritical)
+				So(check.state.Message(), ShouldEqual, "checker panicked: nil map")
+				So(check.state.LastError(), ShouldEqual, "checker panicked: nil map")
+			})
+		})
+
+		Convey("When the health check is configured to crash on panic", func() {
+			tkr.panicPolicy = CrashOnPanic
+
+			Convey("Then the panic is not recovered", func() {
+				So(func() { tkr.runChecker(context.Background(), check.state.clone()) }, ShouldPanic)
+			})
+
+			Convey("And the check overrides the policy to recover panics", func() {
+				WithCheckPanicPolicy(RecoverPanics)(check)
+
+				Convey("Then the panic is recovered", func() {
+					var err error
+					So(func() { err = tkr.runChecker(context.Background(), check.state.clone()) }, ShouldNotPanic)
+					So(err, ShouldNotBeNil)
+				})
+			})
+		})
+	})
+}
+
+func TestRunCheckConsecutiveFailures(t *testing.T) {
+	Convey("Given a check whose checker returns a sequence of statuses", t, func() {
+		statuses := []string{StatusCritical, StatusCritical, StatusWarning, StatusCritical}
+		run := 0
+		checker := func(ctx context.Context, state *CheckState) error {
+			status := statuses[run]
+			run++
+			return state.Update(status, "", 0)
+		}
+		check, err := NewCheck("check", checker)
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		runCheck := func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+		}
+
+		Convey("When consecutive runs are critical", func() {
+			runCheck()
+			runCheck()
+
+			Convey("Then each run is counted", func() {
+				So(check.state.ConsecutiveFailures(), ShouldEqual, 2)
+				So(check.state.TotalFailures(), ShouldEqual, 2)
+			})
+
+			Convey("And a run is not critical followed by a critical run", func() {
+				runCheck()
+				So(check.state.ConsecutiveFailures(), ShouldEqual, 0)
+				So(check.state.TotalFailures(), ShouldEqual, 2)
+				runCheck()
+
+				Convey("Then the count of consecutive failures restarts", func() {
+					So(check.state.ConsecutiveFailures(), ShouldEqual, 1)
+				})
+
+				Convey("Then the count of total failures does not", func() {
+					So(check.state.TotalFailures(), ShouldEqual, 3)
+				})
+			})
+		})
+	})
+}
+
+func TestTickerLogData(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a Health Check with version information including a git commit", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+
+		Convey("Then the log data of the check includes the git commit", func() {
+			So(hc.tickers[0].logData(), ShouldResemble, log.Data{"external_service": "check 1", "git_commit": version.GitCommit})
+		})
+	})
+
+	Convey("Given a Health Check with version information without a git commit", t, func() {
+		hc, err := New(VersionInfo{}, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		defer hc.tickers[0].timeTicker.Stop()
+
+		Convey("Then the log data of the check does not include a git commit", func() {
+			So(hc.tickers[0].logData(), ShouldResemble, log.Data{"external_service": "check 1"})
+		})
+	})
+}
+
+func TestRunCheckRecordFilter(t *testing.T) {
+	Convey("Given an OK check with a record filter that ignores a single critical result", t, func() {
+		status := StatusOK
+		checker := func(ctx context.Context, state *CheckState) error {
+			return state.Update(status, "", 0)
+		}
+		var previous, current []string
+		filter := func(prev, cur Check) bool {
+			previous = append(previous, prev.State().Status())
+			current = append(current, cur.State().Status())
+			return cur.State().Status() != StatusCritical || prev.State().Status() != StatusOK
+		}
+		check, err := NewCheck("check", checker, WithRecordFilter(filter))
+		So(err, ShouldBeNil)
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		runCheck := func() {
+			wg := &sync.WaitGroup{}
+			wg.Add(1)
+			tkr.runCheck(context.Background(), wg, make(chan bool, 1))
+		}
+		runCheck()
+		lastChecked := check.state.LastChecked()
+
+		Convey("When the check returns a critical result", func() {
+			status = StatusCritical
+			runCheck()
+
+			Convey("Then the filter is called with the previous and fresh results", func() {
+				So(previous, ShouldResemble, []string{"", StatusOK})
+				So(current, ShouldResemble, []string{StatusOK, StatusCritical})
+			})
+
+			Convey("Then the result is not recorded and the previous state is kept", func() {
+				So(check.state.Status(), ShouldEqual, StatusOK)
+				So(*check.state.LastChecked(), ShouldEqual, *lastChecked)
+			})
+		})
+	})
+}
+
+func TestTickerSkipsTicksWhileCheckInFlight(t *testing.T) {
+	Convey("Given a started ticker whose checker hangs for several intervals", t, func() {
+		var runs int32
+		release := make(chan struct{})
+		check, err := NewCheck("hung check", func(ctx context.Context, state *CheckState) error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return state.Update(StatusOK, "", 0)
+		}, WithTimeout(time.Minute))
+		So(err, ShouldBeNil)
+
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		wg := &sync.WaitGroup{}
+		tkr.start(context.Background(), wg)
+
+		Convey("When several intervals pass", func() {
+			time.Sleep(4 * interval)
+
+			Convey("Then the checker is only run once", func() {
+				So(atomic.LoadInt32(&runs), ShouldEqual, 1)
+			})
+
+			Convey("When the checker returns", func() {
+				close(release)
+				time.Sleep(2 * interval)
+				tkr.stop()
+
+				Convey("Then the check runs again on the following ticks", func() {
+					So(atomic.LoadInt32(&runs), ShouldBeGreaterThan, 1)
+					So(check.state.Status(), ShouldEqual, StatusOK)
+				})
+			})
+		})
+	})
+
+	Convey("Given a started ticker whose checker hangs beyond its timeout", t, func() {
+		var runs int32
+		release := make(chan struct{})
+		check, err := NewCheck("hung check", func(ctx context.Context, state *CheckState) error {
+			atomic.AddInt32(&runs, 1)
+			<-release
+			return nil
+		}, WithTimeout(interval/2))
+		So(err, ShouldBeNil)
+
+		tkr := createTicker(interval, defaultJitter, check, realClock{})
+		tkr.start(context.Background(), &sync.WaitGroup{})
+		defer tkr.stop()
+		defer close(release)
+
+		Convey("When several intervals pass", func() {
+			time.Sleep(4 * interval)
+
+			Convey("Then the check is recorded as timed out, and the abandoned checker is not run again", func() {
+				So(check.state.Message(), ShouldEqual, timeoutMessage)
+				So(atomic.LoadInt32(&runs), ShouldEqual, 1)
+			})
+		})
+	})
+}
@@ -5,20 +5,28 @@ import (
 	"time"
 )
 
-const jitterFactor = 0.05
+// defaultJitter is the fraction of the interval of a check by which each run is randomly offset, by default
+const defaultJitter = 0.05
 
 // init seeds rand at app startup
 func init() {
 	rand.Seed(time.Now().UnixNano())
 }
 
-func getMaxJitter(interval time.Duration) int64 {
-	return int64(float64(interval) * jitterFactor)
+// getMaxJitter returns the maximum offset of the provided interval for the provided jitter fraction
+func getMaxJitter(interval time.Duration, jitter float64) int64 {
+	if jitter <= 0 {
+		return 0
+	}
+	return int64(float64(interval) * jitter)
 }
 
-// calcIntervalWithJitter returns a new duration based on a provided interval and a jitter of ±jitterFactor
-func calcIntervalWithJitter(interval time.Duration) time.Duration {
-	maxJitter := getMaxJitter(interval)
+// calcIntervalWithJitter returns a new duration based on a provided interval and a jitter of ±jitter of the interval
+func calcIntervalWithJitter(interval time.Duration, jitter float64) time.Duration {
+	maxJitter := getMaxJitter(interval, jitter)
+	if maxJitter == 0 {
+		return interval
+	}
 	minJitter := -maxJitter
 	jitterToApply := time.Duration(random(minJitter, maxJitter))
 	return interval + jitterToApply
@@ -28,3 +36,24 @@ func calcIntervalWithJitter(interval time.Duration) time.Duration {
 func random(min, max int64) int64 {
 	return min + rand.Int63n(max-min)
 }
+
+// calcStartDelay returns a random delay of up to the provided fraction of the provided interval, or 0 if the fraction
+// is not positive. The fraction is capped at 1, so that the delay is always less than the interval.
+func calcStartDelay(interval time.Duration, spread float64) time.Duration {
+	if spread > 1 {
+		spread = 1
+	}
+	maxDelay := getMaxJitter(interval, spread)
+	if maxDelay <= 0 {
+		return 0
+	}
+	return time.Duration(random(0, maxDelay))
+}
+
+// sameTime returns true if the provided times are both nil or are the same instant
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -14,12 +14,50 @@ func TestJitter(t *testing.T) {
 	timeRefWithInterval := timeRef.Add(interval)
 
 	Convey("check calcIntervalWithJitter is returning values in the expected range", t, func() {
-		jitterMax := time.Duration(getMaxJitter(interval))
+		jitterMax := time.Duration(getMaxJitter(interval, defaultJitter))
 		So(jitterMax, ShouldBeGreaterThan, 0)
 
 		for i := 1; i < 20; i++ {
-			timeWithJitteredInterval := timeRef.Add(calcIntervalWithJitter(interval))
+			timeWithJitteredInterval := timeRef.Add(calcIntervalWithJitter(interval, defaultJitter))
 			So(timeWithJitteredInterval, ShouldHappenWithin, jitterMax, timeRefWithInterval)
 		}
 	})
+
+	Convey("check calcIntervalWithJitter returns the interval unchanged when jitter is disabled", t, func() {
+		So(getMaxJitter(interval, 0), ShouldEqual, 0)
+		So(calcIntervalWithJitter(interval, 0), ShouldEqual, interval)
+		So(calcIntervalWithJitter(interval, -0.1), ShouldEqual, interval)
+	})
+}
+
+func TestStartDelay(t *testing.T) {
+
+	interval := 120 * time.Second
+
+	Convey("check calcStartDelay is returning values within the provided fraction of the interval", t, func() {
+		for i := 1; i < 20; i++ {
+			So(calcStartDelay(interval, 0.5), ShouldBeBetweenOrEqual, 0, interval/2)
+			So(calcStartDelay(interval, 2), ShouldBeBetweenOrEqual, 0, interval)
+		}
+	})
+
+	Convey("check calcStartDelay returns no delay when the start is not staggered", t, func() {
+		So(calcStartDelay(interval, 0), ShouldEqual, 0)
+		So(calcStartDelay(interval, -0.1), ShouldEqual, 0)
+	})
+}
+
+func TestSameTime(t *testing.T) {
+
+	timeRef := time.Now().UTC()
+	sameInstant := timeRef.In(time.Local)
+	later := timeRef.Add(time.Second)
+
+	Convey("check sameTime compares the instants of the provided times rather than the pointers", t, func() {
+		So(sameTime(nil, nil), ShouldBeTrue)
+		So(sameTime(&timeRef, nil), ShouldBeFalse)
+		So(sameTime(nil, &timeRef), ShouldBeFalse)
+		So(sameTime(&timeRef, &sameInstant), ShouldBeTrue)
+		So(sameTime(&timeRef, &later), ShouldBeFalse)
+	})
 }
@@ -0,0 +1,69 @@
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Validate runs the checker of every registered check once, without recording the results or starting the tickers,
+// returning an error describing each check that has no name, whose checker returns an error or panics, or whose
+// checker does not record a valid result. This allows misconfigured checkers to be caught in CI or at startup.
+func (hc *HealthCheck) Validate(ctx context.Context) error {
+	hc.mutex.RLock()
+	checks := make([]*Check, len(hc.Checks))
+	copy(checks, hc.Checks)
+	hc.mutex.RUnlock()
+
+	problems := make([]string, len(checks))
+	wg := &sync.WaitGroup{}
+	for i, check := range checks {
+		wg.Add(1)
+		go func(i int, check *Check) {
+			defer wg.Done()
+			problems[i] = validateCheck(ctx, check)
+		}(i, check)
+	}
+	wg.Wait()
+
+	var invalid []string
+	for _, problem := range problems {
+		if problem != "" {
+			invalid = append(invalid, problem)
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("invalid checks: %s", strings.Join(invalid, "; "))
+	}
+	return nil
+}
+
+// validateCheck runs the checker of the provided check against a new state, returning a description of the problem
+// with the check, or an empty string if it is valid
+func validateCheck(ctx context.Context, check *Check) (problem string) {
+	name := check.state.Name()
+	if name == "" {
+		return "check has no name"
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			problem = fmt.Sprintf("%s: %s: %v", name, panicMessage, r)
+		}
+	}()
+
+	state := NewCheckState(name)
+	if err := check.checker(ctx, state); err != nil {
+		return fmt.Sprintf("%s: %s", name, err.Error())
+	}
+
+	switch state.Status() {
+	case StatusOK, StatusWarning, StatusCritical:
+		return ""
+	case "":
+		return fmt.Sprintf("%s: %s", name, noResultMessage)
+	default:
+		return fmt.Sprintf("%s: invalid status: %s", name, state.Status())
+	}
+}
@@ -0,0 +1,58 @@
+package healthcheck
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidate(t *testing.T) {
+	ok := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusCritical, "dependency is down", 0)
+	}
+
+	Convey("Given a Health Check whose checkers all record a result", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", ok), ShouldBeNil)
+		So(hc.AddCheck("check 2", ok), ShouldBeNil)
+
+		Convey("When the checks are validated", func() {
+			err := hc.Validate(context.Background())
+
+			Convey("Then no error is returned and no results are recorded", func() {
+				So(err, ShouldBeNil)
+				So(hc.Checks[0].state.LastChecked(), ShouldBeNil)
+				So(hc.Checks[1].state.LastChecked(), ShouldBeNil)
+			})
+		})
+	})
+
+	Convey("Given a Health Check with misconfigured checkers", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("valid", ok), ShouldBeNil)
+		So(hc.AddCheck("erroring", func(ctx context.Context, state *CheckState) error {
+			return errors.New("no such host")
+		}), ShouldBeNil)
+		So(hc.AddCheck("silent", func(ctx context.Context, state *CheckState) error {
+			return nil
+		}), ShouldBeNil)
+		So(hc.AddCheck("panicking", func(ctx context.Context, state *CheckState) error {
+			panic("nil pointer")
+		}), ShouldBeNil)
+		So(hc.AddCheck("", ok), ShouldBeNil)
+
+		Convey("When the checks are validated", func() {
+			err := hc.Validate(context.Background())
+
+			Convey("Then an error describing each invalid check is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "invalid checks: erroring: no such host; silent: checker returned no result; "+
+					"panicking: checker panicked: nil pointer; check has no name")
+			})
+		})
+	})
+}
@@ -0,0 +1,61 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+)
+
+// watchBufferSize is the number of transitions that can be waiting to be received from the channel returned by Watch,
+// beyond which further transitions are dropped
+const watchBufferSize = 32
+
+// statusWatch is a channel sent transitions of the overall health status until it is closed
+type statusWatch struct {
+	ch     chan StatusChange
+	closed bool
+	mutex  *sync.Mutex
+}
+
+// send sends the provided transition to the channel of the watch without blocking, unless the watch has been closed
+func (w *statusWatch) send(change StatusChange) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.closed {
+		return
+	}
+	select {
+	case w.ch <- change:
+	default:
+	}
+}
+
+// close closes the channel of the watch, after which nothing more is sent to it
+func (w *statusWatch) close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.closed = true
+	close(w.ch)
+}
+
+// Watch returns a channel that is sent each transition of the overall health status, with the previous and new
+// status, the name of the check whose result triggered it, if any, and its time, e.g. for a consumer to pause in its
+// select loop while the app is critical. As with Subscribe, only transitions are sent, and a transition is dropped
+// rather than block the checks if more than 32 are waiting to be received. The channel is closed once the provided
+// context is done, after which it is sent nothing more.
+func (hc *HealthCheck) Watch(ctx context.Context) <-chan StatusChange {
+	watch := &statusWatch{
+		ch:    make(chan StatusChange, watchBufferSize),
+		mutex: &sync.Mutex{},
+	}
+	unsubscribe := hc.subscribe(&statusSubscription{watch: watch})
+
+	go func() {
+		<-ctx.Done()
+		unsubscribe()
+		watch.close()
+	}()
+
+	return watch.ch
+}
@@ -0,0 +1,116 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// nextStatusChange returns the next transition received from the provided channel, and false if the channel is closed
+// or none is received within a second
+func nextStatusChange(changes <-chan StatusChange) (StatusChange, bool) {
+	select {
+	case change, ok := <-changes:
+		return change, ok
+	case <-time.After(time.Second):
+		return StatusChange{}, false
+	}
+}
+
+func TestWatch(t *testing.T) {
+	Convey("Given a Health Check with a check whose status can be changed", t, func() {
+		var (
+			mutex  sync.Mutex
+			status = StatusOK
+		)
+		checker := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return state.Update(status, "", 0)
+		}
+
+		hc, err := New(version, time.Hour, time.Hour)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", checker), ShouldBeNil)
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		changes := hc.Watch(ctx)
+
+		Convey("When the check records its first result", func() {
+			hc.Tick(context.Background())
+
+			Convey("Then the transition is sent along with the check that triggered it", func() {
+				change, ok := nextStatusChange(changes)
+				So(ok, ShouldBeTrue)
+				So(change.Previous, ShouldEqual, "")
+				So(change.Current, ShouldEqual, StatusOK)
+				So(change.Check, ShouldEqual, "check 1")
+				So(change.Time.IsZero(), ShouldBeFalse)
+			})
+
+			Convey("And the check records the same status again", func() {
+				nextStatusChange(changes)
+				hc.runChecks(context.Background(), true)
+
+				Convey("Then nothing is sent", func() {
+					select {
+					case change := <-changes:
+						t.Errorf("unexpected status change %+v", change)
+					case <-time.After(50 * time.Millisecond):
+					}
+				})
+			})
+
+			Convey("And the status of the check changes", func() {
+				nextStatusChange(changes)
+				mutex.Lock()
+				status = StatusWarning
+				mutex.Unlock()
+				hc.runChecks(context.Background(), true)
+
+				Convey("Then the transition is sent", func() {
+					change, ok := nextStatusChange(changes)
+					So(ok, ShouldBeTrue)
+					So(change.Previous, ShouldEqual, StatusOK)
+					So(change.Current, ShouldEqual, StatusWarning)
+					So(change.Check, ShouldEqual, "check 1")
+				})
+			})
+		})
+
+		Convey("When the status is recalculated other than by a check", func() {
+			hc.GetStatus(context.Background())
+
+			Convey("Then the transition is sent without a check", func() {
+				change, ok := nextStatusChange(changes)
+				So(ok, ShouldBeTrue)
+				So(change.Current, ShouldEqual, StatusWarning)
+				So(change.Check, ShouldEqual, "")
+			})
+		})
+
+		Convey("When the context of the watch is done", func() {
+			cancel()
+
+			Convey("Then the channel is closed and unsubscribed", func() {
+				_, ok := nextStatusChange(changes)
+				So(ok, ShouldBeFalse)
+				So(waitFor(func() bool {
+					hc.mutex.RLock()
+					defer hc.mutex.RUnlock()
+					return len(hc.statusSubscriptions) == 0
+				}), ShouldBeTrue)
+				hc.Tick(context.Background())
+			})
+		})
+	})
+}
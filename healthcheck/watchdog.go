@@ -0,0 +1,62 @@
+package healthcheck
+
+import (
+	"context"
+	"time"
+)
+
+// startWatchdog creates a goroutine that periodically restarts any ticker that has not ticked within the
+// configured number of its intervals. Callers must hold the write lock.
+func (hc *HealthCheck) startWatchdog(ctx context.Context) {
+	closing := make(chan bool)
+	hc.watchdogClosing = closing
+
+	wg := hc.tickersWaitgroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		timeTicker := hc.clock.NewTicker(hc.interval)
+		defer timeTicker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-closing:
+				return
+			case t := <-timeTicker.C():
+				hc.restartStaleTickers(t.UTC())
+			}
+		}
+	}()
+}
+
+// stopWatchdog stops the watchdog goroutine, if running. Callers must hold the write lock.
+func (hc *HealthCheck) stopWatchdog() {
+	if hc.watchdogClosing != nil {
+		close(hc.watchdogClosing)
+		hc.watchdogClosing = nil
+	}
+}
+
+// restartStaleTickers replaces any ticker that has not ticked within the configured number of its intervals
+// with a new running ticker for the same check
+func (hc *HealthCheck) restartStaleTickers(now time.Time) {
+	hc.mutex.Lock()
+	defer hc.mutex.Unlock()
+
+	for i, ticker := range hc.tickers {
+		lastTick, stale := ticker.isStale(now, hc.watchdogMissedIntervals)
+		if !stale {
+			continue
+		}
+
+		ticker.abandon()
+		hc.tickers[i] = hc.newTicker(ticker.check)
+		hc.notifyTickerEvent(TickerRestarted, ticker.check)
+		logData := ticker.logData()
+		logData["last_tick"] = lastTick
+		logEvent(hc.context, hc.logger, levelDefault, "restarted stale health check ticker", nil, logData)
+	}
+}
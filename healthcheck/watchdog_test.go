@@ -0,0 +1,95 @@
+package healthcheck
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestWatchdog(t *testing.T) {
+	cf := func(ctx context.Context, state *CheckState) error {
+		return state.Update(StatusOK, "I'm OK", 0)
+	}
+
+	Convey("Given a started Health Check with a watchdog and a ticker that has stopped ticking", t, func() {
+		hc, err := New(version, criticalTimeout, interval, WithWatchdog(2))
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		hc.mutex.RLock()
+		wedged := hc.tickers[0]
+		hc.mutex.RUnlock()
+		wedged.timeTicker.Stop()
+
+		Convey("When more than the configured number of intervals have passed", func() {
+			time.Sleep(5 * interval)
+
+			Convey("Then the ticker is replaced by a running ticker for the same check", func() {
+				hc.mutex.RLock()
+				restarted := hc.tickers[0]
+				hc.mutex.RUnlock()
+
+				So(restarted != wedged, ShouldBeTrue)
+				So(restarted.check == wedged.check, ShouldBeTrue)
+				So(wedged.isStopping(), ShouldBeTrue)
+
+				lastChecked := hc.Checks[0].state.LastChecked()
+				So(lastChecked, ShouldNotBeNil)
+				So(*lastChecked, ShouldHappenWithin, 2*interval, time.Now().UTC())
+			})
+		})
+	})
+
+	Convey("Given a started Health Check without a watchdog and a ticker that has stopped ticking", t, func() {
+		hc, err := New(version, criticalTimeout, interval)
+		So(err, ShouldBeNil)
+		So(hc.AddCheck("check 1", cf), ShouldBeNil)
+		hc.Start(context.Background())
+		defer hc.Stop()
+
+		wedged := hc.tickers[0]
+		wedged.timeTicker.Stop()
+
+		Convey("When several intervals have passed", func() {
+			time.Sleep(4 * interval)
+
+			Convey("Then the ticker is not replaced and the check has only run when it was started", func() {
+				So(hc.tickers[0] == wedged, ShouldBeTrue)
+				lastChecked := hc.Checks[0].state.LastChecked()
+				So(lastChecked, ShouldNotBeNil)
+				So(*lastChecked, ShouldHappenBefore, time.Now().UTC().Add(-3*interval))
+			})
+		})
+	})
+}
+
+func TestTickerIsStale(t *testing.T) {
+	Convey("Given a ticker that last ticked 3 intervals ago", t, func() {
+		check, _ := NewCheck("check", func(ctx context.Context, state *CheckState) error { return nil })
+		tkr := createTicker(time.Minute, defaultJitter, check, realClock{})
+		defer tkr.timeTicker.Stop()
+
+		now := time.Now().UTC()
+		tkr.lastTick = now.Add(-3 * tkr.interval)
+
+		Convey("Then it is stale when more than 2 missed intervals are allowed", func() {
+			_, stale := tkr.isStale(now, 2)
+			So(stale, ShouldBeTrue)
+		})
+
+		Convey("Then it is not stale when more than 4 missed intervals are allowed", func() {
+			_, stale := tkr.isStale(now, 4)
+			So(stale, ShouldBeFalse)
+		})
+
+		Convey("Then it is not stale once it is stopping", func() {
+			tkr.abandon()
+			_, stale := tkr.isStale(now, 2)
+			So(stale, ShouldBeFalse)
+		})
+	})
+}
@@ -0,0 +1,206 @@
+// Package webhook notifies endpoints of transitions of the overall health status, e.g. to page on dependency failure
+// without running a separate poller
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+)
+
+// ContentType is the media type of the payload POSTed by an HTTPNotifier
+const ContentType = "application/json; charset=utf-8"
+
+// Payload represents a transition of the overall health status, along with the health check as it was when the
+// transition occurred
+type Payload struct {
+	Previous string             `json:"previous"`
+	Current  string             `json:"current"`
+	Time     time.Time          `json:"time"`
+	Health   health.HealthCheck `json:"health"`
+}
+
+// Notifier sends a status transition to an endpoint
+type Notifier interface {
+	Notify(ctx context.Context, payload Payload) error
+}
+
+// Option configures optional behaviour of a reporter
+type Option func(*reporter)
+
+// WithRetries configures the number of times a notification that fails is retried, waiting the provided backoff
+// before the first retry and doubling it before each subsequent retry. By default a failed notification is not retried.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(r *reporter) {
+		r.retries = retries
+		r.backoff = backoff
+	}
+}
+
+// WithMinInterval configures the minimum time between notifications, so that a flapping check does not spam the
+// endpoint. Transitions within the interval of the previous notification are coalesced: once the interval has passed,
+// only the latest status is notified, as a transition from the status last notified, and nothing is notified if the
+// status has returned to the status last notified.
+func WithMinInterval(interval time.Duration) Option {
+	return func(r *reporter) {
+		r.minInterval = interval
+	}
+}
+
+// WithLogger configures the logger used for failed notifications, which by default are logged with
+// healthcheck.DefaultLogger
+func WithLogger(logger health.Logger) Option {
+	return func(r *reporter) {
+		r.logger = logger
+	}
+}
+
+// reporter notifies a notifier of transitions of the overall health status
+type reporter struct {
+	notifier    Notifier
+	retries     int
+	backoff     time.Duration
+	minInterval time.Duration
+	logger      health.Logger
+
+	mutex *sync.Mutex
+	// lastSent is the time of the most recent notification
+	lastSent time.Time
+	// lastStatus is the status of the most recent notification
+	lastStatus string
+	// pending is the latest transition waiting for the minimum interval to pass, if any
+	pending *Payload
+}
+
+// NewReporter returns a status listener that notifies the provided notifier whenever the overall health status
+// changes. Notifications, including any retries, are sent by the goroutine that changed the status, so consider
+// configuring the health check with WithListenerQueue, except for coalesced transitions, which are sent on a separate
+// goroutine once the minimum interval has passed.
+func NewReporter(notifier Notifier, opts ...Option) health.StatusListener {
+	r := &reporter{
+		notifier: notifier,
+		logger:   health.DefaultLogger,
+		mutex:    &sync.Mutex{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r.onStatusChange
+}
+
+// onStatusChange notifies the status change straight away, or holds it until the minimum interval has passed
+func (r *reporter) onStatusChange(ctx context.Context, change health.StatusChange, hc health.HealthCheck) {
+	payload := Payload{
+		Previous: change.Previous,
+		Current:  change.Current,
+		Time:     change.Time,
+		Health:   hc,
+	}
+
+	r.mutex.Lock()
+	now := time.Now()
+	if wait := r.lastSent.Add(r.minInterval).Sub(now); r.minInterval > 0 && wait > 0 {
+		if r.pending == nil {
+			time.AfterFunc(wait, r.sendPending)
+		}
+		r.pending = &payload
+		r.mutex.Unlock()
+		return
+	}
+	r.lastSent, r.lastStatus = now, payload.Current
+	r.mutex.Unlock()
+
+	r.send(ctx, payload)
+}
+
+// sendPending notifies the latest transition held while waiting for the minimum interval to pass, unless the status
+// has returned to the status last notified
+func (r *reporter) sendPending() {
+	r.mutex.Lock()
+	payload := *r.pending
+	r.pending = nil
+	if payload.Current == r.lastStatus {
+		r.mutex.Unlock()
+		return
+	}
+	payload.Previous = r.lastStatus
+	r.lastSent, r.lastStatus = time.Now(), payload.Current
+	r.mutex.Unlock()
+
+	r.send(context.Background(), payload)
+}
+
+// send notifies the notifier of the payload, retrying as configured while it fails
+func (r *reporter) send(ctx context.Context, payload Payload) {
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		err := r.notifier.Notify(ctx, payload)
+		if err == nil {
+			return
+		}
+
+		logData := map[string]interface{}{"attempt": attempt, "status": payload.Current}
+		if attempt > r.retries {
+			r.logger(ctx, "failed to send health status notification", err, logData)
+			return
+		}
+		r.logger(ctx, "retrying failed health status notification", err, logData)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// HTTPNotifier POSTs each status transition as JSON to a webhook URL
+type HTTPNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPNotifier returns a notifier that POSTs to the provided URL using the provided client, or the default client
+// if nil
+func NewHTTPNotifier(url string, client *http.Client) *HTTPNotifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &HTTPNotifier{
+		URL:    url,
+		Client: client,
+	}
+}
+
+// Notify POSTs the payload, returning an error if it is not accepted
+func (n *HTTPNotifier) Notify(ctx context.Context, payload Payload) error {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, n.URL, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", ContentType)
+
+	resp, err := n.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status code sending notification: %d", resp.StatusCode)
+	}
+	return nil
+}
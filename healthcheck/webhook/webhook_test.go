@@ -0,0 +1,190 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	health "github.com/ONSdigital/dp-healthcheck/healthcheck"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// notifierMock records the payloads it is asked to send, failing the first failures of them
+type notifierMock struct {
+	mutex    sync.Mutex
+	payloads []Payload
+	failures int
+}
+
+func (n *notifierMock) Notify(ctx context.Context, payload Payload) error {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	n.payloads = append(n.payloads, payload)
+	if len(n.payloads) <= n.failures {
+		return errors.New("endpoint unavailable")
+	}
+	return nil
+}
+
+func (n *notifierMock) getPayloads() []Payload {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	return append([]Payload{}, n.payloads...)
+}
+
+func TestReporter(t *testing.T) {
+	ctx := context.Background()
+	hc := health.HealthCheck{Status: health.StatusCritical, Checks: []*health.Check{}}
+	change := func(previous, current string) health.StatusChange {
+		return health.StatusChange{Previous: previous, Current: current, Time: time.Now().UTC()}
+	}
+
+	Convey("Given a reporter with a notifier", t, func() {
+		notifier := &notifierMock{}
+		reporter := NewReporter(notifier)
+
+		Convey("When the overall status changes", func() {
+			c := change(health.StatusOK, health.StatusCritical)
+			reporter(ctx, c, hc)
+
+			Convey("Then the notifier is sent the transition and the health check", func() {
+				payloads := notifier.getPayloads()
+				So(payloads, ShouldHaveLength, 1)
+				So(payloads[0].Previous, ShouldEqual, health.StatusOK)
+				So(payloads[0].Current, ShouldEqual, health.StatusCritical)
+				So(payloads[0].Time, ShouldEqual, c.Time)
+				So(payloads[0].Health.Status, ShouldEqual, health.StatusCritical)
+			})
+		})
+	})
+
+	Convey("Given a reporter with retries and a notifier that fails twice", t, func() {
+		notifier := &notifierMock{failures: 2}
+		reporter := NewReporter(notifier, WithRetries(3, time.Millisecond))
+
+		Convey("When the overall status changes", func() {
+			reporter(ctx, change(health.StatusOK, health.StatusCritical), hc)
+
+			Convey("Then the notification is retried until it succeeds", func() {
+				So(notifier.getPayloads(), ShouldHaveLength, 3)
+			})
+		})
+	})
+
+	Convey("Given a reporter with 1 retry and a notifier that always fails", t, func() {
+		notifier := &notifierMock{failures: 10}
+		reporter := NewReporter(notifier, WithRetries(1, time.Millisecond))
+
+		Convey("Then the notification is attempted twice without panicking", func() {
+			So(func() { reporter(ctx, change(health.StatusOK, health.StatusCritical), hc) }, ShouldNotPanic)
+			So(notifier.getPayloads(), ShouldHaveLength, 2)
+		})
+	})
+
+	Convey("Given a reporter with 1 retry, a logger and a notifier that always fails", t, func() {
+		notifier := &notifierMock{failures: 10}
+		var events []string
+		logger := func(ctx context.Context, event string, err error, data map[string]interface{}) {
+			events = append(events, event+": "+err.Error())
+		}
+		reporter := NewReporter(notifier, WithRetries(1, time.Millisecond), WithLogger(logger))
+
+		Convey("When the overall status changes", func() {
+			reporter(ctx, change(health.StatusOK, health.StatusCritical), hc)
+
+			Convey("Then the retry and the failure are logged with the logger", func() {
+				So(events, ShouldResemble, []string{
+					"retrying failed health status notification: endpoint unavailable",
+					"failed to send health status notification: endpoint unavailable",
+				})
+			})
+		})
+	})
+
+	Convey("Given a reporter with a minimum interval", t, func() {
+		notifier := &notifierMock{}
+		reporter := NewReporter(notifier, WithMinInterval(50*time.Millisecond))
+		reporter(ctx, change("", health.StatusOK), hc)
+
+		Convey("When the status flaps and settles on a different status within the interval", func() {
+			reporter(ctx, change(health.StatusOK, health.StatusCritical), hc)
+			reporter(ctx, change(health.StatusCritical, health.StatusOK), hc)
+			reporter(ctx, change(health.StatusOK, health.StatusWarning), hc)
+
+			Convey("Then nothing more is notified until the interval has passed", func() {
+				So(notifier.getPayloads(), ShouldHaveLength, 1)
+			})
+
+			Convey("Then only the latest status is notified once the interval has passed, as a transition from the status last notified", func() {
+				time.Sleep(100 * time.Millisecond)
+				payloads := notifier.getPayloads()
+				So(payloads, ShouldHaveLength, 2)
+				So(payloads[1].Previous, ShouldEqual, health.StatusOK)
+				So(payloads[1].Current, ShouldEqual, health.StatusWarning)
+			})
+		})
+
+		Convey("When the status flaps back to the status last notified within the interval", func() {
+			reporter(ctx, change(health.StatusOK, health.StatusCritical), hc)
+			reporter(ctx, change(health.StatusCritical, health.StatusOK), hc)
+			time.Sleep(100 * time.Millisecond)
+
+			Convey("Then nothing more is notified", func() {
+				So(notifier.getPayloads(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestHTTPNotifier(t *testing.T) {
+	ctx := context.Background()
+	hc := health.HealthCheck{Status: health.StatusOK, Checks: []*health.Check{}}
+	payload := Payload{Previous: health.StatusCritical, Current: health.StatusOK, Time: time.Now().UTC(), Health: hc}
+
+	Convey("Given a webhook endpoint that accepts notifications", t, func() {
+		var (
+			contentType string
+			received    Payload
+		)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			contentType = req.Header.Get("Content-Type")
+			b, _ := ioutil.ReadAll(req.Body)
+			json.Unmarshal(b, &received)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		Convey("When a payload is sent with an HTTP notifier", func() {
+			err := NewHTTPNotifier(server.URL, nil).Notify(ctx, payload)
+
+			Convey("Then the payload is POSTed as JSON", func() {
+				So(err, ShouldBeNil)
+				So(contentType, ShouldEqual, ContentType)
+				So(received.Previous, ShouldEqual, health.StatusCritical)
+				So(received.Current, ShouldEqual, health.StatusOK)
+				So(received.Health.Status, ShouldEqual, health.StatusOK)
+			})
+		})
+	})
+
+	Convey("Given a webhook endpoint that rejects notifications", t, func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		Convey("Then sending a payload returns an error", func() {
+			err := NewHTTPNotifier(server.URL, nil).Notify(ctx, payload)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "unexpected status code sending notification: 503")
+		})
+	})
+}
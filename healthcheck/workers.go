@@ -0,0 +1,37 @@
+package healthcheck
+
+import "context"
+
+// workerPool limits the number of checks running at once across all checks
+type workerPool struct {
+	size  int
+	slots chan struct{}
+}
+
+// newWorkerPool creates a worker pool allowing up to the provided number of checks to run at once. The slots are only
+// made for a positive size, as any other size is rejected when the health check is created.
+func newWorkerPool(size int) *workerPool {
+	p := &workerPool{size: size}
+	if size > 0 {
+		p.slots = make(chan struct{}, size)
+	}
+	return p
+}
+
+// acquire waits for a worker to be free, returning false if the provided context is done or the provided closing
+// channel is closed first
+func (p *workerPool) acquire(ctx context.Context, closing <-chan bool) bool {
+	select {
+	case p.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-closing:
+		return false
+	}
+}
+
+// release frees a worker acquired by acquire
+func (p *workerPool) release() {
+	<-p.slots
+}
@@ -0,0 +1,78 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMaxConcurrentChecks(t *testing.T) {
+	Convey("Given a health check limited to 2 concurrent checks, with 5 slow checks", t, func() {
+		var (
+			mutex            sync.Mutex
+			running, maxSeen int
+		)
+		checker := func(ctx context.Context, state *CheckState) error {
+			mutex.Lock()
+			running++
+			if running > maxSeen {
+				maxSeen = running
+			}
+			mutex.Unlock()
+
+			time.Sleep(20 * time.Millisecond)
+
+			mutex.Lock()
+			running--
+			mutex.Unlock()
+			return state.Update(StatusOK, "", 0)
+		}
+
+		hc, err := New(version, criticalTimeout, interval, WithMaxConcurrentChecks(2))
+		So(err, ShouldBeNil)
+		for _, name := range []string{"check 1", "check 2", "check 3", "check 4", "check 5"} {
+			So(hc.AddCheck(name, checker), ShouldBeNil)
+		}
+		defer func() {
+			for _, tkr := range hc.tickers {
+				tkr.timeTicker.Stop()
+			}
+		}()
+
+		Convey("When every check is run", func() {
+			hc.Tick(context.Background())
+
+			Convey("Then no more than 2 checks ran at once, and every check was recorded", func() {
+				So(maxSeen, ShouldEqual, 2)
+				for _, check := range hc.Checks {
+					So(check.state.Status(), ShouldEqual, StatusOK)
+				}
+			})
+		})
+	})
+
+	Convey("Given a worker pool whose workers are all busy", t, func() {
+		pool := newWorkerPool(1)
+		So(pool.acquire(context.Background(), nil), ShouldBeTrue)
+
+		Convey("Then waiting for a worker gives up when the context is done", func() {
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			So(pool.acquire(ctx, nil), ShouldBeFalse)
+		})
+
+		Convey("Then waiting for a worker gives up when the ticker is closing", func() {
+			closing := make(chan bool)
+			close(closing)
+			So(pool.acquire(context.Background(), closing), ShouldBeFalse)
+		})
+
+		Convey("Then a worker can be acquired once released", func() {
+			pool.release()
+			So(pool.acquire(context.Background(), nil), ShouldBeTrue)
+		})
+	})
+}